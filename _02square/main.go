@@ -1,11 +1,10 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"runtime"
-	"strings"
 
+	"github.com/alexniver/opengl-dev-go/gfx"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
@@ -47,114 +46,25 @@ func init() {
 	runtime.LockOSThread()
 }
 
-func initGlfw() *glfw.Window {
-	// init glfw
-	if err := glfw.Init(); nil != err {
-		panic(err)
-	}
-
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-
-	window, err := glfw.CreateWindow(800, 600, "Trinagle", nil, nil)
+func main() {
+	window, err := gfx.InitGlfw(800, 600, "Square")
 	if nil != err {
-		panic(err)
+		log.Fatal(err)
 	}
-	window.MakeContextCurrent()
-
-	return window
-}
-
-func initOpenGL() {
-	// init gl and get a program
-	if err := gl.Init(); nil != err {
-		panic(err)
-	}
-	version := gl.GoStr(gl.GetString(gl.VERSION))
-	log.Println("Opengl version", version)
-}
-
-func makeVbo(vertices []float32) uint32 {
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
-
-	return vbo
-}
-
-func makeVao(vbo uint32) uint32 {
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-	gl.EnableVertexAttribArray(0)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
-	return vao
-}
-
-func makeEbo(indices []uint32) uint32 {
-	var ebo uint32
-	gl.GenBuffers(1, &ebo)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
-	return ebo
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if gl.FALSE == status {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		return 0, fmt.Errorf("failed to compile %v : %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func main() {
-	window := initGlfw()
 	defer glfw.Terminate()
 
-	initOpenGL()
-	prog1 := gl.CreateProgram()
-	prog2 := gl.CreateProgram()
-
-	// attach shader
-	vertexShader1, err := compileShader(vertexShaderSource1, gl.VERTEX_SHADER)
-	if nil != err {
-		log.Panic(err)
-	}
-	fragmentShader1, err := compileShader(fragmentShaderSource1, gl.FRAGMENT_SHADER)
-	if nil != err {
-		log.Panic(err)
+	if err := gfx.InitOpenGL(); nil != err {
+		log.Fatal(err)
 	}
-	vertexShader2, err := compileShader(vertexShaderSource2, gl.VERTEX_SHADER)
+
+	prog1, err := gfx.NewProgram(vertexShaderSource1, fragmentShaderSource1)
 	if nil != err {
 		log.Panic(err)
 	}
-	fragmentShader2, err := compileShader(fragmentShaderSource2, gl.FRAGMENT_SHADER)
+	prog2, err := gfx.NewProgram(vertexShaderSource2, fragmentShaderSource2)
 	if nil != err {
 		log.Panic(err)
 	}
-	gl.AttachShader(prog1, vertexShader1)
-	gl.AttachShader(prog1, fragmentShader1)
-	gl.LinkProgram(prog1)
-	gl.AttachShader(prog2, vertexShader2)
-	gl.AttachShader(prog2, fragmentShader2)
-	gl.LinkProgram(prog2)
 
 	vertices := []float32{
 		-1, 0.5, 0,
@@ -168,9 +78,11 @@ func main() {
 		1, 2, 3,
 	}
 
-	vbo := makeVbo(vertices)
-	makeVao(vbo)
-	makeEbo(indices)
+	vbo := gfx.MakeVbo(vertices)
+	gfx.MakeVao(vbo)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
+	gfx.MakeEbo(indices)
 
 	// gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 
@@ -178,10 +90,10 @@ func main() {
 		gl.ClearColor(0.5, 0.5, 1, 1.0)
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-		gl.UseProgram(prog1)
+		prog1.Use()
 		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
 
-		gl.UseProgram(prog2)
+		prog2.Use()
 		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
 
 		glfw.PollEvents()
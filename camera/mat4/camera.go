@@ -1,38 +1,171 @@
 package main
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 俯仰角的限制，到 90 度时 Front 和 Up 共线，LookAt 就退化了
+const maxPitch = 89
+
+// 投影的近、远裁剪面
+const (
+	nearPlane = 0.1
+	farPlane  = 100
+)
+
+// 滚轮缩放的范围：透视投影的视野（角度），正交投影视野的半高
+const (
+	minFov       = 1
+	maxFov       = 90
+	minOrthoSize = 0.1
+	maxOrthoSize = 100
+)
+
+// Projection 是摄像机的投影方式
+type Projection int
+
+const (
+	Perspective  Projection = iota // 透视投影
+	Orthographic                   // 正交投影，视野大小由 OrthoSize 决定
+	OffAxis                        // 离轴透视投影，视锥按 Shift 斜向一边
+)
 
 type Camera struct {
-    Pos   mgl32.Vec3 // 摄像机的位置vec3
-    Front mgl32.Vec3 // 摄像机向前的向量
-    Up    mgl32.Vec3 // 摄像机的上向量
+	Pos   mgl32.Vec3 // 摄像机的位置vec3
+	Front mgl32.Vec3 // 摄像机向前的向量
+	Up    mgl32.Vec3 // 摄像机的上向量
 
-    Yaw   float32 // 偏航角
-    Pitch float32 // 俯仰角
-    Roll  float32 // 滚轴角
-    LastX float32
-    LastY float32
-    Fov   float32 // Field of view or fov defines how much we can see of the scene
+	Yaw   float32 // 偏航角
+	Pitch float32 // 俯仰角
+	Roll  float32 // 滚轴角
+	LastX float32
+	LastY float32
+	Fov   float32 // Field of view or fov defines how much we can see of the scene
 
-    MoveSpeed float32
-    CursorSensitivity float32
+	MoveSpeed         float32
+	CursorSensitivity float32
+
+	Projection Projection // 投影方式
+	Aspect     float32    // 视口的宽高比，Resize 时更新
+	OrthoSize  float32    // 正交投影视野的半高
+	Shift      mgl32.Vec2 // 离轴投影的视锥偏移，以视野的半宽、半高为单位
 }
 
 // NewCamera 创建一个新的摄像机
 func NewCamera(pos, front, up mgl32.Vec3, yaw, pitch, roll, lastX, lastY, fov, moveSpeed, cursorSensitivity float32) *Camera {
-    return &Camera{
-        Pos : pos,
-        Front:front,
-        Up:up,
-        Yaw:yaw,
-        Pitch:pitch,
-        Roll:roll,
-        LastX:lastX,
-        LastY:lastY,
-        Fov:fov,
-        MoveSpeed:moveSpeed,
-        CursorSensitivity: cursorSensitivity,
-    }
+	return &Camera{
+		Pos:               pos,
+		Front:             front,
+		Up:                up,
+		Yaw:               yaw,
+		Pitch:             pitch,
+		Roll:              roll,
+		LastX:             lastX,
+		LastY:             lastY,
+		Fov:               fov,
+		MoveSpeed:         moveSpeed,
+		CursorSensitivity: cursorSensitivity,
+		Aspect:            1,
+		OrthoSize:         2,
+	}
 }
 
+// Update 用一帧的输入更新摄像机：光标转动视角，滚轮缩放，WASD 沿视线
+// 前后、左右移动。dt 是这一帧的秒数
+func (c *Camera) Update(dt float32, input Input) {
+	c.Zoom(float32(input.Scroll))
+
+	// 光标向下 y 增大，视线应该向下
+	c.Yaw += float32(input.CursorChange[0]) * c.CursorSensitivity
+	c.Pitch -= float32(input.CursorChange[1]) * c.CursorSensitivity
+	c.Pitch = mgl32.Clamp(c.Pitch, -maxPitch, maxPitch)
+	c.updateFront()
 
+	var move mgl32.Vec3
+	right := c.Right()
+	if input.Keys[glfw.KeyW] {
+		move = move.Add(c.Front)
+	}
+	if input.Keys[glfw.KeyS] {
+		move = move.Sub(c.Front)
+	}
+	if input.Keys[glfw.KeyD] {
+		move = move.Add(right)
+	}
+	if input.Keys[glfw.KeyA] {
+		move = move.Sub(right)
+	}
+	// 斜着走不比直着走快
+	if l := move.Len(); l > 0 {
+		c.Pos = c.Pos.Add(move.Mul(c.MoveSpeed * dt / l))
+	}
+}
+
+// updateFront 由偏航角和俯仰角算出 Front，偏航角 -90 度时看向 -z
+func (c *Camera) updateFront() {
+	yaw := float64(mgl32.DegToRad(c.Yaw))
+	pitch := float64(mgl32.DegToRad(c.Pitch))
+	c.Front = mgl32.Vec3{
+		float32(math.Cos(yaw) * math.Cos(pitch)),
+		float32(math.Sin(pitch)),
+		float32(math.Sin(yaw) * math.Cos(pitch)),
+	}.Normalize()
+}
+
+// Right 返回摄像机的右向量
+func (c *Camera) Right() mgl32.Vec3 {
+	return c.Front.Cross(c.Up).Normalize()
+}
+
+// ViewMatrix 返回观察矩阵，Roll 让上向量绕视线转动
+func (c *Camera) ViewMatrix() mgl32.Mat4 {
+	up := c.Right().Cross(c.Front)
+	if 0 != c.Roll {
+		up = mgl32.QuatRotate(mgl32.DegToRad(c.Roll), c.Front).Rotate(up)
+	}
+	return mgl32.LookAtV(c.Pos, c.Pos.Add(c.Front), up)
+}
+
+// Zoom 按滚轮的偏移缩放，向上滚放大。透视投影缩小视野，每格 1 度；
+// 正交投影缩小视野的半高，每格十分之一
+func (c *Camera) Zoom(offset float32) {
+	if 0 == offset {
+		return
+	}
+	if Orthographic == c.Projection {
+		c.OrthoSize *= float32(math.Exp(float64(-offset / 10)))
+		c.OrthoSize = mgl32.Clamp(c.OrthoSize, minOrthoSize, maxOrthoSize)
+		return
+	}
+	c.Fov = mgl32.Clamp(c.Fov-offset, minFov, maxFov)
+}
+
+// Resize 在帧缓冲大小变化后更新宽高比，最小化时大小为 0，保留原来的
+func (c *Camera) Resize(width, height int) {
+	if width > 0 && height > 0 {
+		c.Aspect = float32(width) / float32(height)
+	}
+}
+
+// ProjectionMatrix 按 Projection 返回投影矩阵
+func (c *Camera) ProjectionMatrix() mgl32.Mat4 {
+	switch c.Projection {
+	case Orthographic:
+		h := c.OrthoSize
+		w := h * c.Aspect
+		return mgl32.Ortho(-w, w, -h, h, nearPlane, farPlane)
+	case OffAxis:
+		// 近裁剪面上视野的半高、半宽，整个视锥平移 Shift 倍
+		h := nearPlane * float32(math.Tan(float64(mgl32.DegToRad(c.Fov/2))))
+		w := h * c.Aspect
+		return mgl32.Frustum(
+			(c.Shift[0]-1)*w, (c.Shift[0]+1)*w,
+			(c.Shift[1]-1)*h, (c.Shift[1]+1)*h,
+			nearPlane, farPlane,
+		)
+	}
+	return mgl32.Perspective(mgl32.DegToRad(c.Fov), c.Aspect, nearPlane, farPlane)
+}
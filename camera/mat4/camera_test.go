@@ -1,14 +1,213 @@
 package main
 
 import (
-    "testing"
-    "github.com/go-gl/mathgl/mgl32"
+	"math"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
 )
 
+func newTestCamera() *Camera {
+	return NewCamera(
+		mgl32.Vec3{0, 0, 3},
+		mgl32.Vec3{0, 0, -1},
+		mgl32.Vec3{0, 1, 0},
+		-90, 0, 0, 400, 300, 45, 2, 0.1,
+	)
+}
+
+func near(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-5
+}
+
 func TestNewCamera(t *testing.T) {
-    camera := NewCamera(
-        mgl32.Vec3{0, 0, 3},
-        mgl32.Vec3{0, 0, -1},
-        mgl32.Vec3{0, 1, 0},
-    )
+	camera := newTestCamera()
+	if camera.Yaw != -90 || camera.Fov != 45 || camera.MoveSpeed != 2 || camera.CursorSensitivity != 0.1 {
+		t.Errorf("fields not set: %+v", camera)
+	}
+}
+
+func TestUpdateFront(t *testing.T) {
+	camera := newTestCamera()
+	camera.Update(0, Input{})
+	if !near(camera.Front, mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("yaw -90: front %v", camera.Front)
+	}
+
+	// 向右移动光标 900 像素，转 90 度看向 +x
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{900, 0}})
+	if !near(camera.Front, mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("yaw 0: front %v", camera.Front)
+	}
+
+	// 光标向上移动，抬头
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{0, -300}})
+	if !near(camera.Front, mgl32.Vec3{0.8660254, 0.5, 0}) {
+		t.Errorf("pitch 30: front %v", camera.Front)
+	}
+}
+
+func TestPitchClamp(t *testing.T) {
+	camera := newTestCamera()
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{0, -10000}})
+	if camera.Pitch != maxPitch {
+		t.Errorf("pitch %v, want %v", camera.Pitch, maxPitch)
+	}
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{0, 20000}})
+	if camera.Pitch != -maxPitch {
+		t.Errorf("pitch %v, want %v", camera.Pitch, -maxPitch)
+	}
+	if camera.Front.Len() < 0.999 || camera.Right().Len() < 0.999 {
+		t.Errorf("front %v right %v not unit", camera.Front, camera.Right())
+	}
+}
+
+func TestMove(t *testing.T) {
+	camera := newTestCamera()
+	keys := map[glfw.Key]bool{glfw.KeyW: true}
+	camera.Update(0.5, Input{Keys: keys})
+	if !near(camera.Pos, mgl32.Vec3{0, 0, 2}) {
+		t.Errorf("forward: pos %v", camera.Pos)
+	}
+
+	keys = map[glfw.Key]bool{glfw.KeyD: true}
+	camera.Update(1, Input{Keys: keys})
+	if !near(camera.Pos, mgl32.Vec3{2, 0, 2}) {
+		t.Errorf("right: pos %v", camera.Pos)
+	}
+
+	// 斜着走的速度和直着走一样
+	keys = map[glfw.Key]bool{glfw.KeyS: true, glfw.KeyA: true}
+	before := camera.Pos
+	camera.Update(1, Input{Keys: keys})
+	if d := camera.Pos.Sub(before).Len(); mgl32.Abs(d-2) > 1e-5 {
+		t.Errorf("diagonal moved %v, want 2", d)
+	}
+
+	keys = map[glfw.Key]bool{glfw.KeyW: true, glfw.KeyS: true}
+	before = camera.Pos
+	camera.Update(1, Input{Keys: keys})
+	if camera.Pos != before {
+		t.Errorf("opposite keys moved to %v", camera.Pos)
+	}
+}
+
+func TestViewMatrix(t *testing.T) {
+	camera := newTestCamera()
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{-100, 50}})
+	want := mgl32.LookAtV(camera.Pos, camera.Pos.Add(camera.Front), mgl32.Vec3{0, 1, 0})
+	if !camera.ViewMatrix().ApproxEqualThreshold(want, 1e-5) {
+		t.Errorf("view\n%v\nwant\n%v", camera.ViewMatrix(), want)
+	}
+
+	// 摄像机的位置变换到原点，视线变换到 -z
+	view := camera.ViewMatrix()
+	if p := view.Mul4x1(camera.Pos.Vec4(1)).Vec3(); !near(p, mgl32.Vec3{}) {
+		t.Errorf("eye at %v", p)
+	}
+	if f := view.Mul4x1(camera.Front.Vec4(0)).Vec3(); !near(f, mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("front at %v", f)
+	}
+
+	// 滚转 90 度后，世界的 +x 在屏幕上朝上
+	camera = newTestCamera()
+	camera.Roll = 90
+	camera.Update(0, Input{})
+	if r := camera.ViewMatrix().Mul4x1(mgl32.Vec4{1, 0, 0, 0}).Vec3(); !near(r, mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("rolled right at %v", r)
+	}
+}
+
+func TestProjectionMatrix(t *testing.T) {
+	camera := newTestCamera()
+	camera.Resize(800, 400)
+	projection := camera.ProjectionMatrix()
+	want := mgl32.Perspective(mgl32.DegToRad(45), 2, nearPlane, farPlane)
+	if projection != want {
+		t.Errorf("projection\n%v\nwant\n%v", projection, want)
+	}
+	// 近裁剪面上的点深度为 -1，远裁剪面为 1
+	for _, c := range []struct{ z, depth float32 }{{-nearPlane, -1}, {-farPlane, 1}} {
+		p := projection.Mul4x1(mgl32.Vec4{0, 0, c.z, 1})
+		if d := p.Z() / p.W(); mgl32.Abs(d-c.depth) > 1e-4 {
+			t.Errorf("z %v: depth %v, want %v", c.z, d, c.depth)
+		}
+	}
+
+	// 最小化的窗口不改宽高比
+	camera.Resize(0, 0)
+	if 2 != camera.Aspect {
+		t.Errorf("aspect %v after minimizing", camera.Aspect)
+	}
+}
+
+func TestZoom(t *testing.T) {
+	camera := newTestCamera()
+	camera.Update(0, Input{Scroll: 5})
+	if 40 != camera.Fov {
+		t.Errorf("fov %v, want 40", camera.Fov)
+	}
+	camera.Zoom(100)
+	if minFov != camera.Fov {
+		t.Errorf("fov %v, want %v", camera.Fov, minFov)
+	}
+	camera.Zoom(-1000)
+	if maxFov != camera.Fov {
+		t.Errorf("fov %v, want %v", camera.Fov, maxFov)
+	}
+
+	camera.Projection = Orthographic
+	camera.Zoom(1)
+	camera.Zoom(-1)
+	if mgl32.Abs(camera.OrthoSize-2) > 1e-6 {
+		t.Errorf("ortho size %v after zooming in and out", camera.OrthoSize)
+	}
+	camera.Zoom(1)
+	if camera.OrthoSize >= 2 {
+		t.Errorf("zooming in grew the view to %v", camera.OrthoSize)
+	}
+	if maxFov != camera.Fov {
+		t.Errorf("orthographic zoom changed the fov")
+	}
+	camera.Zoom(1000)
+	if minOrthoSize != camera.OrthoSize {
+		t.Errorf("ortho size %v, want %v", camera.OrthoSize, minOrthoSize)
+	}
+}
+
+func TestOrthographic(t *testing.T) {
+	camera := newTestCamera()
+	camera.Projection = Orthographic
+	camera.Resize(300, 200)
+	projection := camera.ProjectionMatrix()
+	// 视野的角落投影到屏幕的角落，和距离无关
+	for _, z := range []float32{-1, -50} {
+		p := projection.Mul4x1(mgl32.Vec4{3, 2, z, 1})
+		if mgl32.Abs(p.X()/p.W()-1) > 1e-5 || mgl32.Abs(p.Y()/p.W()-1) > 1e-5 {
+			t.Errorf("z %v: corner at %v", z, p)
+		}
+	}
+}
+
+func TestOffAxis(t *testing.T) {
+	camera := newTestCamera()
+	camera.Resize(800, 400)
+	camera.Projection = OffAxis
+	if !camera.ProjectionMatrix().ApproxEqualThreshold(mgl32.Perspective(mgl32.DegToRad(45), 2, nearPlane, farPlane), 1e-5) {
+		t.Error("off-axis without a shift is not the perspective")
+	}
+
+	// 偏移半个视野，原来在左边一半中间的点落到屏幕中央
+	camera.Shift = mgl32.Vec2{-0.5, 0}
+	tan := float32(math.Tan(float64(mgl32.DegToRad(22.5))))
+	p := camera.ProjectionMatrix().Mul4x1(mgl32.Vec4{-tan * 2 * 0.5 * 10, 0, -10, 1})
+	if x := p.X() / p.W(); mgl32.Abs(x) > 1e-5 {
+		t.Errorf("shifted center at x %v", x)
+	}
+	p = camera.ProjectionMatrix().Mul4x1(mgl32.Vec4{0, 0, -10, 1})
+	if x := p.X() / p.W(); mgl32.Abs(x-0.5) > 1e-5 {
+		t.Errorf("view axis at x %v, want 0.5", x)
+	}
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 投影的近、远裁剪面
+const (
+	nearPlane = 0.1
+	farPlane  = 100
+)
+
+// 摄像机自身坐标系里的轴，Orientation 为单位四元数时与世界坐标轴重合
+var (
+	localFront = mgl32.Vec3{0, 0, -1}
+	localUp    = mgl32.Vec3{0, 1, 0}
+	localRight = mgl32.Vec3{1, 0, 0}
+)
+
+// Camera 是用四元数表示朝向的摄像机。每次转动都绕摄像机自己的轴叠加到
+// Orientation 上，不经过欧拉角，所以俯仰到 ±90 度也不会万向节锁，
+// 还能自由滚转
+type Camera struct {
+	Pos         mgl32.Vec3 // 摄像机的位置
+	Orientation mgl32.Quat // 摄像机到世界的旋转，单位四元数时看向 -z，上方为 +y
+	Fov         float32    // 视野，角度
+
+	MoveSpeed         float32 // 每秒移动的距离
+	CursorSensitivity float32 // 光标每移动一个像素转动的角度
+	RollSpeed         float32 // 每秒滚转的角度
+}
+
+// NewCamera 创建一个新的摄像机
+func NewCamera(pos mgl32.Vec3, orientation mgl32.Quat, fov, moveSpeed, cursorSensitivity, rollSpeed float32) *Camera {
+	return &Camera{
+		Pos:               pos,
+		Orientation:       orientation.Normalize(),
+		Fov:               fov,
+		MoveSpeed:         moveSpeed,
+		CursorSensitivity: cursorSensitivity,
+		RollSpeed:         rollSpeed,
+	}
+}
+
+// FromEuler 把 camera/mat4 里 Camera 的偏航、俯仰、滚转角（角度）换成朝向。
+// 和那里一样，偏航角 -90 度时看向 -z，滚转为正时上向量向右倒
+func FromEuler(yaw, pitch, roll float32) mgl32.Quat {
+	q := mgl32.QuatRotate(-mgl32.DegToRad(yaw+90), mgl32.Vec3{0, 1, 0})
+	q = q.Mul(mgl32.QuatRotate(mgl32.DegToRad(pitch), localRight))
+	return q.Mul(mgl32.QuatRotate(mgl32.DegToRad(roll), localFront))
+}
+
+// Euler 是 FromEuler 的逆，偏航角在 (-180, 180] 之间。俯仰到 ±90 度时
+// 偏航和滚转绕的是同一根轴，这时滚转记为 0
+func Euler(q mgl32.Quat) (yaw, pitch, roll float32) {
+	front := q.Rotate(localFront)
+	right := q.Rotate(localRight)
+	up := q.Rotate(localUp)
+
+	pitch = degrees(math.Asin(float64(mgl32.Clamp(front.Y(), -1, 1))))
+	if math.Abs(float64(front.Y())) > 1-1e-6 {
+		// 只剩右向量还能分辨偏航角
+		yaw = degrees(math.Atan2(float64(-right.X()), float64(right.Z())))
+		return yaw, pitch, 0
+	}
+	yaw = degrees(math.Atan2(float64(front.Z()), float64(front.X())))
+
+	// 没有滚转时的上向量，再量出实际上向量绕视线转过的角度
+	level := front.Cross(mgl32.Vec3{0, 1, 0}).Normalize().Cross(front)
+	roll = degrees(math.Atan2(float64(level.Cross(up).Dot(front)), float64(level.Dot(up))))
+	return yaw, pitch, roll
+}
+
+func degrees(rad float64) float32 {
+	return mgl32.RadToDeg(float32(rad))
+}
+
+// LookAt 返回在 pos 看向 target 且不滚转的朝向
+func LookAt(pos, target mgl32.Vec3) mgl32.Quat {
+	d := target.Sub(pos).Normalize()
+	yaw := degrees(math.Atan2(float64(d.Z()), float64(d.X())))
+	pitch := degrees(math.Asin(float64(mgl32.Clamp(d.Y(), -1, 1))))
+	return FromEuler(yaw, pitch, 0)
+}
+
+// Front 返回摄像机向前的向量
+func (c *Camera) Front() mgl32.Vec3 {
+	return c.Orientation.Rotate(localFront)
+}
+
+// Up 返回摄像机的上向量
+func (c *Camera) Up() mgl32.Vec3 {
+	return c.Orientation.Rotate(localUp)
+}
+
+// Right 返回摄像机的右向量
+func (c *Camera) Right() mgl32.Vec3 {
+	return c.Orientation.Rotate(localRight)
+}
+
+// Rotate 绕摄像机自己的上、右、前轴依次转动 yaw、pitch、roll 度。
+// yaw 为正向右转，pitch 为正抬头，roll 为正向右滚转
+func (c *Camera) Rotate(yaw, pitch, roll float32) {
+	q := mgl32.QuatRotate(-mgl32.DegToRad(yaw), localUp)
+	q = q.Mul(mgl32.QuatRotate(mgl32.DegToRad(pitch), localRight))
+	q = q.Mul(mgl32.QuatRotate(mgl32.DegToRad(roll), localFront))
+	// 每帧重新归一化，免得误差累积让矩阵带上缩放
+	c.Orientation = c.Orientation.Mul(q).Normalize()
+}
+
+// TurnTowards 用球面插值把朝向平滑地转向 target，rate 越大转得越快，
+// 与帧率无关
+func (c *Camera) TurnTowards(target mgl32.Quat, rate, dt float32) {
+	t := 1 - float32(math.Exp(float64(-rate*dt)))
+	c.Orientation = mgl32.QuatSlerp(c.Orientation, target, t).Normalize()
+}
+
+// moveKeys 是移动键在摄像机坐标系里的方向
+var moveKeys = []struct {
+	key glfw.Key
+	dir mgl32.Vec3
+}{
+	{glfw.KeyW, localFront},
+	{glfw.KeyS, localFront.Mul(-1)},
+	{glfw.KeyD, localRight},
+	{glfw.KeyA, localRight.Mul(-1)},
+	{glfw.KeySpace, localUp},
+	{glfw.KeyLeftControl, localUp.Mul(-1)},
+}
+
+// Update 用一帧的输入更新摄像机：光标转动视角，Q、E 滚转，WASD 沿视线
+// 前后、左右移动，空格和左 Ctrl 沿上向量升降。dt 是这一帧的秒数
+func (c *Camera) Update(dt float32, input Input) {
+	var roll float32
+	if input.Keys[glfw.KeyQ] {
+		roll -= c.RollSpeed * dt
+	}
+	if input.Keys[glfw.KeyE] {
+		roll += c.RollSpeed * dt
+	}
+	// 光标向下 y 增大，视线应该向下
+	c.Rotate(
+		float32(input.CursorChange[0])*c.CursorSensitivity,
+		-float32(input.CursorChange[1])*c.CursorSensitivity,
+		roll,
+	)
+
+	var move mgl32.Vec3
+	for _, k := range moveKeys {
+		if input.Keys[k.key] {
+			move = move.Add(k.dir)
+		}
+	}
+	// 斜着走不比直着走快
+	if l := move.Len(); l > 0 {
+		move = c.Orientation.Rotate(move.Mul(1 / l))
+		c.Pos = c.Pos.Add(move.Mul(c.MoveSpeed * dt))
+	}
+}
+
+// ViewMatrix 返回观察矩阵，即摄像机变换的逆
+func (c *Camera) ViewMatrix() mgl32.Mat4 {
+	return c.Orientation.Conjugate().Mat4().Mul4(mgl32.Translate3D(-c.Pos[0], -c.Pos[1], -c.Pos[2]))
+}
+
+// ProjectionMatrix 返回宽高比为 aspect 的透视投影矩阵
+func (c *Camera) ProjectionMatrix(aspect float32) mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(c.Fov), aspect, nearPlane, farPlane)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func near(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-5
+}
+
+// eulerFront 是 camera/mat4 里 Camera 由偏航、俯仰角算 Front 的公式
+func eulerFront(yaw, pitch float32) mgl32.Vec3 {
+	y := float64(mgl32.DegToRad(yaw))
+	p := float64(mgl32.DegToRad(pitch))
+	return mgl32.Vec3{
+		float32(math.Cos(y) * math.Cos(p)),
+		float32(math.Sin(p)),
+		float32(math.Sin(y) * math.Cos(p)),
+	}
+}
+
+func newTestCamera() *Camera {
+	return NewCamera(mgl32.Vec3{0, 0, 3}, mgl32.QuatIdent(), 45, 2, 0.1, 90)
+}
+
+func TestFromEuler(t *testing.T) {
+	for _, e := range [][3]float32{{-90, 0, 0}, {0, 0, 0}, {30, 45, 0}, {-120, -60, 20}, {170, 10, -135}} {
+		q := FromEuler(e[0], e[1], e[2])
+		if f := q.Rotate(localFront); !near(f, eulerFront(e[0], e[1])) {
+			t.Errorf("%v: front %v, want %v", e, f, eulerFront(e[0], e[1]))
+		}
+		yaw, pitch, roll := Euler(q)
+		if mgl32.Abs(yaw-e[0]) > 1e-3 || mgl32.Abs(pitch-e[1]) > 1e-3 || mgl32.Abs(roll-e[2]) > 1e-3 {
+			t.Errorf("%v: back to %v %v %v", e, yaw, pitch, roll)
+		}
+	}
+	if !FromEuler(-90, 0, 0).OrientationEqualThreshold(mgl32.QuatIdent(), 1e-6) {
+		t.Error("yaw -90 is not the identity")
+	}
+
+	// 滚转 90 度，上向量倒向右边
+	if up := FromEuler(-90, 0, 90).Rotate(localUp); !near(up, mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("rolled up %v", up)
+	}
+
+	// 正上方只剩偏航角有意义
+	yaw, pitch, roll := Euler(FromEuler(30, 90, 0))
+	if mgl32.Abs(yaw-30) > 1e-3 || mgl32.Abs(pitch-90) > 1e-3 || 0 != roll {
+		t.Errorf("straight up: %v %v %v", yaw, pitch, roll)
+	}
+}
+
+func TestNoGimbalLock(t *testing.T) {
+	for _, pitch := range []float32{90, -90} {
+		camera := newTestCamera()
+		camera.Rotate(0, pitch, 0)
+		if want := (mgl32.Vec3{0, pitch / 90, 0}); !near(camera.Front(), want) {
+			t.Fatalf("pitch %v: front %v", pitch, camera.Front())
+		}
+
+		// 欧拉角在这里会把偏航和滚转锁到同一根轴上，四元数还能分开
+		yawed, rolled := *camera, *camera
+		yawed.Rotate(30, 0, 0)
+		rolled.Rotate(0, 0, 30)
+		if near(yawed.Front(), rolled.Front()) {
+			t.Errorf("pitch %v: yaw and roll turn the same way", pitch)
+		}
+		if !near(rolled.Front(), camera.Front()) {
+			t.Errorf("pitch %v: roll moved the front to %v", pitch, rolled.Front())
+		}
+		if d := yawed.Front().Dot(camera.Front()); mgl32.Abs(d-0.8660254) > 1e-5 {
+			t.Errorf("pitch %v: yaw turned %v degrees", pitch, mgl32.RadToDeg(float32(math.Acos(float64(d)))))
+		}
+
+		// 继续俯仰不会卡住，翻过头顶后倒着看向后方
+		camera.Rotate(0, pitch, 0)
+		if !near(camera.Front(), mgl32.Vec3{0, 0, 1}) || !near(camera.Up(), mgl32.Vec3{0, -1, 0}) {
+			t.Errorf("pitch %v twice: front %v up %v", pitch, camera.Front(), camera.Up())
+		}
+	}
+}
+
+func TestRotateStaysUnit(t *testing.T) {
+	camera := newTestCamera()
+	for i := 0; i < 10000; i++ {
+		camera.Rotate(1.3, 0.7, 0.3)
+	}
+	if l := camera.Orientation.Len(); mgl32.Abs(l-1) > 1e-5 {
+		t.Errorf("orientation length %v", l)
+	}
+	if d := camera.Front().Dot(camera.Up()); mgl32.Abs(d) > 1e-5 {
+		t.Errorf("front and up not orthogonal: %v", d)
+	}
+}
+
+func TestTurnTowards(t *testing.T) {
+	camera := newTestCamera()
+	target := FromEuler(0, 30, 0)
+	start := camera.Orientation
+	camera.TurnTowards(target, 2, 0.1)
+	// 插值沿最短的大圆弧，一步只走一部分
+	want := mgl32.QuatSlerp(start, target, 1-float32(math.Exp(-0.2)))
+	if !camera.Orientation.OrientationEqualThreshold(want, 1e-5) {
+		t.Errorf("one step: %v, want %v", camera.Orientation, want)
+	}
+	for i := 0; i < 200; i++ {
+		camera.TurnTowards(target, 2, 0.1)
+	}
+	if !camera.Orientation.OrientationEqualThreshold(target, 1e-4) {
+		t.Errorf("settled at %v, want %v", camera.Orientation, target)
+	}
+
+	// -q 和 q 是同一个朝向，不能绕远路
+	camera.Orientation = start
+	camera.TurnTowards(target.Scale(-1), 2, 0.1)
+	if !camera.Orientation.OrientationEqualThreshold(want, 1e-5) {
+		t.Errorf("negated target: %v, want %v", camera.Orientation, want)
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	eye := mgl32.Vec3{3, 3, 3}
+	q := LookAt(eye, mgl32.Vec3{})
+	if f := q.Rotate(localFront); !near(f, eye.Mul(-1).Normalize()) {
+		t.Errorf("front %v", f)
+	}
+	if r := q.Rotate(localRight); mgl32.Abs(r.Y()) > 1e-6 {
+		t.Errorf("rolled: right %v", r)
+	}
+	camera := NewCamera(eye, q, 45, 1, 1, 1)
+	want := mgl32.LookAtV(eye, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	for i, v := range camera.ViewMatrix() {
+		if mgl32.Abs(v-want[i]) > 1e-5 {
+			t.Fatalf("view\n%v\nwant\n%v", camera.ViewMatrix(), want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	camera := newTestCamera()
+	// 向右移动光标 900 像素，右转 90 度
+	camera.Update(0, Input{CursorChange: mgl64.Vec2{900, 0}})
+	if !near(camera.Front(), mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("front %v", camera.Front())
+	}
+
+	camera.Update(0.5, Input{Keys: map[glfw.Key]bool{glfw.KeyW: true, glfw.KeySpace: true}})
+	if d := camera.Pos.Sub(mgl32.Vec3{0, 0, 3}); !near(d, mgl32.Vec3{0.70710677, 0.70710677, 0}) {
+		t.Errorf("moved %v", d)
+	}
+
+	// Q 向左滚转，看向 +x 时左边是 -z
+	camera.Update(1, Input{Keys: map[glfw.Key]bool{glfw.KeyQ: true}})
+	if !near(camera.Up(), mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("rolled up %v", camera.Up())
+	}
+}
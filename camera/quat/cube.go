@@ -2,21 +2,17 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Renders a textured spinning cube using GLFW 3 and OpenGL 4.1 core forward-compatible profile.
+// Renders a textured spinning cube using GLFW 3 and OpenGL 3.3 core forward-compatible profile.
 package main // import "github.com/go-gl/example/gl41core-cube"
 
 import (
-	"fmt"
 	"go/build"
-	"image"
-	"image/draw"
-	_ "image/png"
 	"log"
 	"os"
 	"runtime"
-	"strings"
 
-	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/alexniver/opengl-dev-go/gfx"
+	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -30,78 +26,53 @@ func init() {
 }
 
 func main() {
-	if err := glfw.Init(); err != nil {
-		log.Fatalln("failed to initialize glfw:", err)
-	}
-	defer glfw.Terminate()
-
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Cube", nil, nil)
+	window, err := gfx.InitGlfw(windowWidth, windowHeight, "Cube")
 	if err != nil {
-		panic(err)
+		log.Fatalln(err)
 	}
-	window.MakeContextCurrent()
+	defer glfw.Terminate()
 
-	// Initialize Glow
-	if err := gl.Init(); err != nil {
-		panic(err)
+	if err := gfx.InitOpenGL(); err != nil {
+		log.Fatalln(err)
 	}
 
-	version := gl.GoStr(gl.GetString(gl.VERSION))
-	fmt.Println("OpenGL version", version)
-
 	// Configure the vertex and fragment shaders
-	program, err := newProgram(vertexShader, fragmentShader)
+	program, err := gfx.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
 
-	gl.UseProgram(program)
+	program.Use()
 
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 10.0)
-	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
+	// start at {3, 3, 3} looking at the cube
+	eye := mgl32.Vec3{3, 3, 3}
+	camera := NewCamera(eye, LookAt(eye, mgl32.Vec3{}), 45, 2.5, 0.1, 90)
 
-	camera := mgl32.LookAtV(mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
-	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
-	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
+	projection := camera.ProjectionMatrix(float32(windowWidth) / windowHeight)
+	program.SetMat4("projection", projection)
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
-	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
-
-	textureUniform := gl.GetUniformLocation(program, gl.Str("tex\x00"))
-	gl.Uniform1i(textureUniform, 0)
+	program.SetMat4("model", model)
 
-	gl.BindFragDataLocation(program, 0, gl.Str("outputColor\x00"))
+	program.SetSampler("tex", 0)
 
 	// Load the texture
-	texture, err := newTexture("square.png")
+	texture, err := gfx.NewTexture("square.png")
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	// Configure the vertex data
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
+	vbo := gfx.MakeVbo(cubeVertices)
+	vao := gfx.MakeVao(vbo)
 
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4, gl.Ptr(cubeVertices), gl.STATIC_DRAW)
-
-	vertAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vert\x00")))
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
-
-	texCoordAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vertTexCoord\x00")))
-	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
+	vertexCount, err := cubeLayout.Validate(cubeVertices)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := cubeLayout.Bind(program); err != nil {
+		log.Fatalln(err)
+	}
 
 	// Configure global settings
 	gl.Enable(gl.DEPTH_TEST)
@@ -111,6 +82,17 @@ func main() {
 	angle := 0.0
 	previousTime := glfw.GetTime()
 
+	var paths pathControl
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		keyCallback(w, key, scancode, action, mods)
+		if action == glfw.Press {
+			paths.key(key)
+		}
+	})
+	window.SetCursorPosCallback(cursorPosCallback)
+	window.SetScrollCallback(scrollCallback)
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
 	for !window.ShouldClose() {
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
@@ -120,18 +102,33 @@ func main() {
 		previousTime = time
 
 		angle += elapsed
+
+		input := pollInput()
+		if !paths.playing() {
+			camera.Update(float32(elapsed), input)
+			// hold R to turn back to the cube, upright
+			if IsKeyListPressed(glfw.KeyR) {
+				camera.TurnTowards(LookAt(camera.Pos, mgl32.Vec3{}), 4, float32(elapsed))
+			}
+		}
+		paths.update(float32(elapsed), camera)
+		// the cube turns with the path while it plays, so frames repeat
+		if paths.playing() {
+			angle = float64(paths.player.Time)
+		}
 		model = mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0, 1, 0})
 
 		// Render
-		gl.UseProgram(program)
-		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
+		program.Use()
+		program.SetMat4("model", model)
+		program.SetMat4("camera", camera.ViewMatrix())
 
 		gl.BindVertexArray(vao)
 
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, texture)
 
-		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(vertexCount))
 
 		// Maintenance
 		window.SwapBuffers()
@@ -139,102 +136,6 @@ func main() {
 	}
 }
 
-func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func newTexture(file string) (uint32, error) {
-	imgFile, err := os.Open(file)
-	if err != nil {
-		return 0, fmt.Errorf("texture %q not found on disk: %v", file, err)
-	}
-	img, _, err := image.Decode(imgFile)
-	if err != nil {
-		return 0, err
-	}
-
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("unsupported stride")
-	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(rgba.Rect.Size().X),
-		int32(rgba.Rect.Size().Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix))
-
-	return texture, nil
-}
-
 var vertexShader = `
 #version 330
 
@@ -267,6 +168,11 @@ void main() {
 }
 ` + "\x00"
 
+var cubeLayout = gfx.MustVertexLayout(
+	gfx.VertexAttrib{Name: "vert", Size: 3},
+	gfx.VertexAttrib{Name: "vertTexCoord", Size: 2},
+)
+
 var cubeVertices = []float32{
 	//  X, Y, Z, U, V
 	// Bottom
@@ -320,7 +226,7 @@ var cubeVertices = []float32{
 
 // Set the working directory to the root of Go package, so that its assets can be accessed.
 func init() {
-	dir, err := importPathToDir("github.com/alexniver/opengl-dev-go/cube")
+	dir, err := importPathToDir("github.com/alexniver/opengl-dev-go/camera/quat")
 	if err != nil {
 		log.Fatalln("Unable to find Go package in your GOPATH, it's needed to load assets:", err)
 	}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+var keyPressedMap = make(map[glfw.Key]bool) // 键盘按下map
+var cursorFirst = true                      // 光标是否是第一次进入屏幕，默认是true
+var cursorPos mgl64.Vec2                    // 光标位置
+var cursorPosLast mgl64.Vec2                // 光标上次监听事件的位置
+var bufferedCursorChange mgl64.Vec2         // 光标累计变化总量
+
+// Input 是一帧的输入状态
+type Input struct {
+	Keys         map[glfw.Key]bool // 按下的键
+	CursorChange mgl64.Vec2        // 上一帧以来光标移动的总量
+}
+
+// pollInput 取出回调累积的输入，并清空光标变化量留给下一帧
+func pollInput() Input {
+	input := Input{Keys: keyPressedMap, CursorChange: bufferedCursorChange}
+	bufferedCursorChange = mgl64.Vec2{}
+	return input
+}
+
+func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	// timing for key events occurs differently from what the program loop requires
+	// so just track what key actions occur and then access them in the program loop
+	switch action {
+	case glfw.Press:
+		keyPressedMap[key] = true
+	case glfw.Release:
+		keyPressedMap[key] = false
+	}
+	if key == glfw.KeyEscape && action == glfw.Press {
+		window.SetShouldClose(true)
+	}
+}
+
+func cursorPosCallback(window *glfw.Window, xpos, ypos float64) {
+	if cursorFirst {
+		cursorPosLast[0] = xpos
+		cursorPosLast[1] = ypos
+		cursorFirst = false
+	}
+
+	bufferedCursorChange[0] += xpos - cursorPosLast[0]
+	bufferedCursorChange[1] += ypos - cursorPosLast[1]
+
+	cursorPosLast[0] = xpos
+	cursorPosLast[1] = ypos
+}
+
+func scrollCallback(w *glfw.Window, xoff float64, yoff float64) {
+
+}
+
+// IsKeyListPressed 返回是否按下了keyList中的所有键
+func IsKeyListPressed(keyList ...glfw.Key) bool {
+	for _, key := range keyList {
+		if !keyPressedMap[key] {
+			return false
+		}
+	}
+	return true
+}
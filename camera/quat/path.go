@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+
+	"github.com/alexniver/opengl-dev-go/gfx/campath"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// pathFile 是录制和回放的摄像机路径文件
+const pathFile = "camera.path"
+
+// pathControl 录制摄像机的运动，或者回放录好的路径：
+// F5 开始、停止录制，F6 开始、停止回放，F7 切换按帧回放，
+// 回放时 P 暂停，[ ] 前后跳一秒，, . 前后走一帧，Home 回到开头
+type pathControl struct {
+	recorder *campath.Recorder
+	player   *campath.Player
+	fixed    bool // 按帧回放，每帧固定前进 1/60 秒
+}
+
+// key 处理一次按键
+func (pc *pathControl) key(key glfw.Key) {
+	switch key {
+	case glfw.KeyF5:
+		if nil != pc.recorder {
+			pc.save()
+			return
+		}
+		pc.player = nil
+		pc.recorder = campath.NewRecorder()
+		log.Println("recording", pathFile)
+	case glfw.KeyF6:
+		if nil != pc.player {
+			pc.player = nil
+			return
+		}
+		pc.play()
+	case glfw.KeyF7:
+		pc.fixed = !pc.fixed
+		if nil != pc.player {
+			pc.player.Fixed = pc.fixed
+		}
+	}
+	if nil == pc.player {
+		return
+	}
+	switch key {
+	case glfw.KeyP:
+		pc.player.Paused = !pc.player.Paused
+	case glfw.KeyLeftBracket:
+		pc.player.Scrub(-1)
+	case glfw.KeyRightBracket:
+		pc.player.Scrub(1)
+	case glfw.KeyComma:
+		pc.player.Step(-1)
+	case glfw.KeyPeriod:
+		pc.player.Step(1)
+	case glfw.KeyHome:
+		pc.player.Seek(0)
+	}
+}
+
+func (pc *pathControl) save() {
+	if err := campath.EncodeFile(pathFile, pc.recorder.Path); nil != err {
+		log.Println(err)
+	} else {
+		log.Printf("recorded %d frames, %.1fs", len(pc.recorder.Path.Keys), pc.recorder.Path.Duration())
+	}
+	pc.recorder = nil
+}
+
+func (pc *pathControl) play() {
+	path, err := campath.DecodeFile(pathFile)
+	if nil != err {
+		log.Println(err)
+		return
+	}
+	pc.recorder = nil
+	pc.player = campath.NewPlayer(path)
+	pc.player.Fixed = pc.fixed
+	log.Printf("playing %s, %.1fs", pathFile, path.Duration())
+}
+
+// playing 返回是否在回放
+func (pc *pathControl) playing() bool {
+	return nil != pc.player
+}
+
+// update 在录制时记下摄像机这一帧的位置和朝向，在回放时把摄像机放到路径上。
+// 回放到头后停止
+func (pc *pathControl) update(dt float32, camera *Camera) {
+	if nil != pc.recorder {
+		pc.recorder.Record(dt, camera.Pos, camera.Orientation)
+	}
+	if nil == pc.player {
+		return
+	}
+	pc.player.Update(dt)
+	camera.Pos, camera.Orientation = pc.player.Camera()
+	if pc.player.Done() && !pc.player.Paused {
+		pc.player = nil
+	}
+}
@@ -2,21 +2,23 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Renders a textured spinning cube using GLFW 3 and OpenGL 4.1 core forward-compatible profile.
+// Renders a textured cube in front of a skybox using GLFW 3 and OpenGL 3.3
+// core forward-compatible profile, inspected with the orbit camera: left drag
+// turns, middle drag pans, the wheel zooms, Tab switches between turntable
+// and arcball and F frames the cube again.
 package main // import "github.com/go-gl/example/gl41core-cube"
 
 import (
-	"fmt"
 	"go/build"
-	"image"
-	"image/draw"
-	_ "image/png"
 	"log"
+	"math"
 	"os"
 	"runtime"
-	"strings"
 
-	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/alexniver/opengl-dev-go/gfx"
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/alexniver/opengl-dev-go/gfx/orbit"
+	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -30,85 +32,70 @@ func init() {
 }
 
 func main() {
-	if err := glfw.Init(); err != nil {
-		log.Fatalln("failed to initialize glfw:", err)
-	}
-	defer glfw.Terminate()
-
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Cube", nil, nil)
+	window, err := gfx.InitGlfw(windowWidth, windowHeight, "Cube")
 	if err != nil {
-		panic(err)
+		log.Fatalln(err)
 	}
-	window.MakeContextCurrent()
+	defer glfw.Terminate()
 
-	// Initialize Glow
-	if err := gl.Init(); err != nil {
-		panic(err)
+	if err := gfx.InitOpenGL(); err != nil {
+		log.Fatalln(err)
 	}
 
-	version := gl.GoStr(gl.GetString(gl.VERSION))
-	fmt.Println("OpenGL version", version)
-
 	// Configure the vertex and fragment shaders
-	program, err := newProgram(vertexShader, fragmentShader)
+	program, err := gfx.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
 
-	gl.UseProgram(program)
-
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 10.0)
-	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
-
-	camera := mgl32.LookAtV(mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
-	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
-	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
+	program.Use()
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
-	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
-
-	textureUniform := gl.GetUniformLocation(program, gl.Str("tex\x00"))
-	gl.Uniform1i(textureUniform, 0)
+	program.SetMat4("model", model)
 
-	gl.BindFragDataLocation(program, 0, gl.Str("outputColor\x00"))
+	program.SetSampler("tex", 0)
 
 	// Load the texture
-	texture, err := newTexture("square.png")
+	texture, err := gfx.NewTexture("square.png")
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	// Configure the vertex data
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4, gl.Ptr(cubeVertices), gl.STATIC_DRAW)
-
-	vertAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vert\x00")))
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
+	// Load the sky, the demo still runs without one
+	var skybox *gfx.Skybox
+	if sky, err := gfx.NewCubemapFromLayout("skybox.png", gfx.TextureOptions{}); err != nil {
+		log.Println("no skybox:", err)
+	} else if skybox, err = gfx.NewSkybox(sky); err != nil {
+		log.Fatalln(err)
+	}
 
-	texCoordAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vertTexCoord\x00")))
-	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
+	// Configure the vertex data, welding the 36 vertices into an indexed mesh
+	cubeMesh, err := mesh.FromVertices(cubeVertices,
+		mesh.Attribute{Name: "position", Size: 3},
+		mesh.Attribute{Name: "uv", Size: 2, Offset: 3},
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	cubeMesh.Weld(0)
+	cube := gfx.NewGPUMesh(cubeMesh)
+	defer cube.Delete()
+
+	// Look at the cube from the {1, 1, 1} side: left drag turns it, middle
+	// drag pans, the wheel zooms, Tab switches to the arcball and F frames it
+	// again
+	width, height := window.GetFramebufferSize()
+	controller := orbit.NewController(mgl32.Vec3{}, 1, width, height)
+	controller.Rotation = mgl32.QuatRotate(mgl32.DegToRad(45), mgl32.Vec3{0, 1, 0}).
+		Mul(mgl32.QuatRotate(-float32(math.Atan(1/math.Sqrt2)), mgl32.Vec3{1, 0, 0}))
+	controller.Frame(cubeMesh.Bounds())
+	controlOrbit(window, controller, cubeMesh.Bounds())
 
 	// Configure global settings
 	gl.Enable(gl.DEPTH_TEST)
 	gl.DepthFunc(gl.LESS)
 	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
 
-	angle := 0.0
 	previousTime := glfw.GetTime()
 
 	for !window.ShouldClose() {
@@ -119,19 +106,24 @@ func main() {
 		elapsed := time - previousTime
 		previousTime = time
 
-		angle += elapsed
-		model = mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0, 1, 0})
+		controller.Update(float32(elapsed))
+		camera := controller.ViewMatrix()
+		projection := controller.ProjectionMatrix()
 
 		// Render
-		gl.UseProgram(program)
-		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
-
-		gl.BindVertexArray(vao)
+		program.Use()
+		program.SetMat4("camera", camera)
+		program.SetMat4("projection", projection)
 
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, texture)
 
-		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
+		cube.Draw()
+
+		// the sky goes last so it only fills what the scene left empty
+		if skybox != nil {
+			skybox.Draw(camera, projection)
+		}
 
 		// Maintenance
 		window.SwapBuffers()
@@ -139,100 +131,48 @@ func main() {
 	}
 }
 
-func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func newTexture(file string) (uint32, error) {
-	imgFile, err := os.Open(file)
-	if err != nil {
-		return 0, fmt.Errorf("texture %q not found on disk: %v", file, err)
-	}
-	img, _, err := image.Decode(imgFile)
-	if err != nil {
-		return 0, err
-	}
-
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("unsupported stride")
-	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(rgba.Rect.Size().X),
-		int32(rgba.Rect.Size().Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix))
-
-	return texture, nil
+// controlOrbit drives controller from the mouse and keyboard of window.
+func controlOrbit(window *glfw.Window, controller *orbit.Controller, bounds mesh.Bounds) {
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if glfw.Release == action {
+			controller.End()
+			return
+		}
+		x, y := w.GetCursorPos()
+		switch button {
+		case glfw.MouseButtonLeft:
+			controller.BeginRotate(x, y)
+		case glfw.MouseButtonMiddle:
+			controller.BeginPan(x, y)
+		}
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		controller.Move(x, y)
+	})
+	window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		controller.Scroll(yoff)
+	})
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+		controller.SetViewport(width, height)
+	})
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if glfw.Press != action {
+			return
+		}
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeyTab:
+			if orbit.Arcball == controller.Mode() {
+				controller.SetMode(orbit.Turntable)
+			} else {
+				controller.SetMode(orbit.Arcball)
+			}
+		case glfw.KeyF:
+			controller.Frame(bounds)
+		}
+	})
 }
 
 var vertexShader = `
@@ -242,8 +182,8 @@ uniform mat4 projection;
 uniform mat4 camera;
 uniform mat4 model;
 
-in vec3 vert;
-in vec2 vertTexCoord;
+layout(location = 0) in vec3 vert;
+layout(location = 2) in vec2 vertTexCoord;
 
 out vec2 fragTexCoord;
 
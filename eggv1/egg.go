@@ -0,0 +1,177 @@
+// Copyright 2014 The go-gl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Renders a lit, textured spinning egg using GLFW 3 and OpenGL 3.3 core forward-compatible profile.
+package main // import "github.com/go-gl/example/gl41core-cube"
+
+import (
+	"go/build"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/alexniver/opengl-dev-go/gfx"
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const windowWidth = 800
+const windowHeight = 600
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+func main() {
+	window, err := gfx.InitGlfw(windowWidth, windowHeight, "Egg")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer glfw.Terminate()
+
+	if err := gfx.InitOpenGL(); err != nil {
+		log.Fatalln(err)
+	}
+
+	// Configure the vertex and fragment shaders
+	program, err := gfx.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		panic(err)
+	}
+
+	program.Use()
+
+	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 10.0)
+	program.SetMat4("projection", projection)
+
+	eye := mgl32.Vec3{3, 3, 3}
+	camera := mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	program.SetMat4("camera", camera)
+
+	model := mgl32.Ident4()
+	program.SetMat4("model", model)
+
+	program.SetSampler("tex", 0)
+	program.SetVec3("lightDirection", mgl32.Vec3{1, 2, 1.5}.Normalize())
+
+	// Load the texture
+	texture, err := gfx.NewTexture("square.png")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Configure the vertex data, an egg with its wide end down
+	eggMesh := mesh.Egg(eggLength, eggBreadth, eggAsymmetry, 64, 48)
+	eggMesh.Optimize()
+	egg := gfx.NewGPUMeshLODs(eggMesh, eggMesh.GenerateLODs(6, 0.5))
+	defer egg.Delete()
+	// the egg stays put, so the least detail that is off by at most a pixel
+	// is picked once
+	level := egg.SelectLOD(eye.Len()-eggLength/2, projection, windowHeight, 1)
+
+	// Configure global settings
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LESS)
+	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
+
+	angle := 0.0
+	previousTime := glfw.GetTime()
+
+	for !window.ShouldClose() {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// Update
+		time := glfw.GetTime()
+		elapsed := time - previousTime
+		previousTime = time
+
+		angle += elapsed
+		model = mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0, 1, 0})
+
+		// Render
+		program.Use()
+		program.SetMat4("model", model)
+
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+
+		egg.DrawLOD(level)
+
+		// Maintenance
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
+
+// The egg is sized to fill about as much of the view as the cube did.
+const (
+	eggLength    = 2.4
+	eggBreadth   = 1.7
+	eggAsymmetry = 0.12
+)
+
+var vertexShader = `
+#version 330
+
+uniform mat4 projection;
+uniform mat4 camera;
+uniform mat4 model;
+
+layout(location = 0) in vec3 vert;
+layout(location = 1) in vec3 vertNormal;
+layout(location = 2) in vec2 vertTexCoord;
+
+out vec3 fragNormal;
+out vec2 fragTexCoord;
+
+void main() {
+    fragTexCoord = vertTexCoord;
+    fragNormal = mat3(transpose(inverse(model))) * vertNormal;
+    gl_Position = projection * camera * model * vec4(vert, 1);
+}
+` + "\x00"
+
+var fragmentShader = `
+#version 330
+
+uniform sampler2D tex;
+uniform vec3 lightDirection;
+
+in vec3 fragNormal;
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+void main() {
+    float diffuse = max(dot(normalize(fragNormal), lightDirection), 0.0);
+    vec4 color = texture(tex, fragTexCoord);
+    outputColor = vec4(color.rgb * (0.25 + 0.75 * diffuse), color.a);
+}
+` + "\x00"
+
+// Set the working directory to the root of Go package, so that its assets can be accessed.
+func init() {
+	dir, err := importPathToDir("github.com/alexniver/opengl-dev-go/eggv1")
+	if err != nil {
+		log.Fatalln("Unable to find Go package in your GOPATH, it's needed to load assets:", err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		log.Panicln("os.Chdir:", err)
+	}
+}
+
+// importPathToDir resolves the absolute path from importPath.
+// There doesn't need to be a valid Go package inside that import path,
+// but the directory must exist.
+func importPathToDir(importPath string) (string, error) {
+	p, err := build.Import(importPath, "", build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+	return p.Dir, nil
+}
@@ -0,0 +1,36 @@
+package gfx
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// MakeVbo uploads vertices into a new ARRAY_BUFFER and leaves it bound.
+func MakeVbo(vertices []float32) uint32 {
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	if len(vertices) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	}
+	return vbo
+}
+
+// MakeVao creates a vertex array, binds it and binds vbo to ARRAY_BUFFER.
+// Attribute pointers are left to the caller.
+func MakeVao(vbo uint32) uint32 {
+	var vao uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	return vao
+}
+
+// MakeEbo uploads indices into a new ELEMENT_ARRAY_BUFFER. Bind the vao first,
+// the element buffer binding is part of the vertex array state.
+func MakeEbo(indices []uint32) uint32 {
+	var ebo uint32
+	gl.GenBuffers(1, &ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+	if len(indices) > 0 {
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
+	}
+	return ebo
+}
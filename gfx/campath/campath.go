@@ -0,0 +1,261 @@
+// Package campath records camera motion and plays it back, for demos and
+// for captures that have to show the same frames every run.
+//
+// A Path is a list of keys, each a position and an orientation at a time.
+// Sampling it between keys interpolates positions along a Catmull-Rom
+// spline and orientations with squad, so a handful of authored keys give a
+// smooth flight and a key recorded every frame replays the motion as it
+// was. Paths are stored as text, one key per line:
+//
+//	# time x y z qw qx qy qz
+//	0 0 0 5 1 0 0 0
+//
+// Sampling is plain arithmetic on the keys, with no clock or window behind
+// it, so a path plays back the same in a test as on screen.
+package campath
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Key is the camera at one moment of a path.
+type Key struct {
+	Time        float32 // seconds
+	Position    mgl32.Vec3
+	Orientation mgl32.Quat
+}
+
+// Path is a camera path, its keys in time order.
+type Path struct {
+	Keys []Key
+}
+
+// Add inserts a key, after any key at the same time. Its orientation must
+// be a unit quaternion.
+func (p *Path) Add(k Key) {
+	i := sort.Search(len(p.Keys), func(i int) bool { return p.Keys[i].Time > k.Time })
+	p.Keys = append(p.Keys, Key{})
+	copy(p.Keys[i+1:], p.Keys[i:])
+	p.Keys[i] = k
+}
+
+// Duration returns the time of the last key.
+func (p *Path) Duration() float32 {
+	if 0 == len(p.Keys) {
+		return 0
+	}
+	return p.Keys[len(p.Keys)-1].Time
+}
+
+// Sample returns the camera at time t, holding still before the first key
+// and after the last. An empty path gives the origin looking down -z.
+func (p *Path) Sample(t float32) (mgl32.Vec3, mgl32.Quat) {
+	n := len(p.Keys)
+	switch {
+	case 0 == n:
+		return mgl32.Vec3{}, mgl32.QuatIdent()
+	case t <= p.Keys[0].Time:
+		return p.Keys[0].Position, p.Keys[0].Orientation
+	case t >= p.Keys[n-1].Time:
+		return p.Keys[n-1].Position, p.Keys[n-1].Orientation
+	}
+	// t lies in the segment from key i to key i+1
+	i := sort.Search(n, func(i int) bool { return p.Keys[i].Time > t }) - 1
+	a, b := p.Keys[i], p.Keys[i+1]
+	h := b.Time - a.Time
+	if h <= 0 {
+		return b.Position, b.Orientation
+	}
+	u := (t - a.Time) / h
+	return p.position(i, u, h), p.orientation(i, u)
+}
+
+// position is the cubic Hermite curve from key i to i+1 with Catmull-Rom
+// tangents, scaled for keys that are not evenly spaced in time.
+func (p *Path) position(i int, u, h float32) mgl32.Vec3 {
+	p0, p1 := p.Keys[i].Position, p.Keys[i+1].Position
+	m0, m1 := p.tangent(i).Mul(h), p.tangent(i+1).Mul(h)
+	u2, u3 := u*u, u*u*u
+	return p0.Mul(2*u3 - 3*u2 + 1).
+		Add(m0.Mul(u3 - 2*u2 + u)).
+		Add(p1.Mul(-2*u3 + 3*u2)).
+		Add(m1.Mul(u3 - u2))
+}
+
+// tangent returns the velocity at key i, from its neighbors, or one sided
+// at the ends.
+func (p *Path) tangent(i int) mgl32.Vec3 {
+	prev, next := i-1, i+1
+	if prev < 0 {
+		prev = i
+	}
+	if next >= len(p.Keys) {
+		next = i
+	}
+	dt := p.Keys[next].Time - p.Keys[prev].Time
+	if dt <= 0 {
+		return mgl32.Vec3{}
+	}
+	return p.Keys[next].Position.Sub(p.Keys[prev].Position).Mul(1 / dt)
+}
+
+// orientation is squad from key i to i+1: a slerp between the keys bent
+// toward inner control points so that the angular velocity carries on
+// smoothly through the keys.
+func (p *Path) orientation(i int, u float32) mgl32.Quat {
+	q1 := p.Keys[i].Orientation
+	q2 := hemisphere(p.Keys[i+1].Orientation, q1)
+	// the end keys are their own control points, turning on steadily like
+	// the one sided tangents of the positions
+	s1, s2 := q1, q2
+	if i > 0 {
+		s1 = inner(hemisphere(p.Keys[i-1].Orientation, q1), q1, q2)
+	}
+	if i+2 < len(p.Keys) {
+		s2 = inner(q1, q2, hemisphere(p.Keys[i+2].Orientation, q2))
+	}
+	return slerp(slerp(q1, q2, u), slerp(s1, s2, u), 2*u*(1-u)).Normalize()
+}
+
+// hemisphere returns q or -q, the same rotation, whichever is nearer to
+// ref, so the interpolation takes the short way round.
+func hemisphere(q, ref mgl32.Quat) mgl32.Quat {
+	if q.Dot(ref) < 0 {
+		return q.Scale(-1)
+	}
+	return q
+}
+
+// inner returns the squad control point of q between prev and next.
+func inner(prev, q, next mgl32.Quat) mgl32.Quat {
+	inv := q.Conjugate()
+	a, b := quatLog(inv.Mul(next)), quatLog(inv.Mul(prev))
+	return q.Mul(quatExp(a.Add(b).Mul(-0.25))).Normalize()
+}
+
+// slerp interpolates without picking the shorter way as mgl32.QuatSlerp
+// does, which squad relies on for its control points.
+func slerp(a, b mgl32.Quat, t float32) mgl32.Quat {
+	dot := float64(mgl32.Clamp(a.Dot(b), -1, 1))
+	if dot > 0.9995 {
+		return mgl32.QuatNlerp(a, b, t)
+	}
+	theta := math.Acos(dot)
+	s := math.Sin(theta)
+	wa := float32(math.Sin((1-float64(t))*theta) / s)
+	wb := float32(math.Sin(float64(t)*theta) / s)
+	return a.Scale(wa).Add(b.Scale(wb))
+}
+
+// quatLog returns the logarithm of unit quaternion q, a pure quaternion
+// given by its vector part.
+func quatLog(q mgl32.Quat) mgl32.Vec3 {
+	s := float64(q.V.Len())
+	if s < 1e-9 {
+		return mgl32.Vec3{}
+	}
+	angle := math.Atan2(s, float64(q.W))
+	return q.V.Mul(float32(angle / s))
+}
+
+// quatExp is the inverse of quatLog.
+func quatExp(v mgl32.Vec3) mgl32.Quat {
+	angle := float64(v.Len())
+	if angle < 1e-9 {
+		return mgl32.QuatIdent()
+	}
+	return mgl32.Quat{W: float32(math.Cos(angle)), V: v.Mul(float32(math.Sin(angle) / angle))}
+}
+
+// Decode reads a path written by Encode.
+func Decode(r io.Reader) (*Path, error) {
+	p := &Path{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if "" == text || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if 8 != len(fields) {
+			return nil, fmt.Errorf("campath:%d: want 8 numbers, got %d", line, len(fields))
+		}
+		var v [8]float32
+		for i, f := range fields {
+			n, err := strconv.ParseFloat(f, 32)
+			if nil != err {
+				return nil, fmt.Errorf("campath:%d: bad number %q", line, f)
+			}
+			v[i] = float32(n)
+		}
+		k := Key{
+			Time:        v[0],
+			Position:    mgl32.Vec3{v[1], v[2], v[3]},
+			Orientation: mgl32.Quat{W: v[4], V: mgl32.Vec3{v[5], v[6], v[7]}},
+		}
+		// hand written orientations need not be exact
+		if l := k.Orientation.Len(); l < 1e-6 {
+			return nil, fmt.Errorf("campath:%d: zero orientation", line)
+		} else if mgl32.Abs(l-1) > 1e-4 {
+			k.Orientation = k.Orientation.Normalize()
+		}
+		if n := len(p.Keys); n > 0 && k.Time < p.Keys[n-1].Time {
+			return nil, fmt.Errorf("campath:%d: time %v before %v", line, k.Time, p.Keys[n-1].Time)
+		}
+		p.Add(k)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, fmt.Errorf("campath: %v", err)
+	}
+	return p, nil
+}
+
+// DecodeFile reads a path from file.
+func DecodeFile(file string) (*Path, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Encode writes p as text, with every number exact so that a decoded path
+// plays back the same frames.
+func Encode(w io.Writer, p *Path) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# time x y z qw qx qy qz")
+	for _, k := range p.Keys {
+		o := k.Orientation
+		for i, v := range []float32{k.Time, k.Position[0], k.Position[1], k.Position[2], o.W, o.V[0], o.V[1], o.V[2]} {
+			if i > 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// EncodeFile writes p to file.
+func EncodeFile(file string, p *Path) error {
+	f, err := os.Create(file)
+	if nil != err {
+		return err
+	}
+	if err := Encode(f, p); nil != err {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,224 @@
+package campath
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func near(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-4
+}
+
+func turn(degrees float32) mgl32.Quat {
+	return mgl32.QuatRotate(mgl32.DegToRad(degrees), mgl32.Vec3{0, 1, 0})
+}
+
+func TestSampleKeys(t *testing.T) {
+	p := &Path{}
+	// added out of order
+	for _, k := range []Key{
+		{2, mgl32.Vec3{4, 1, 0}, turn(60)},
+		{0, mgl32.Vec3{0, 0, 0}, turn(0)},
+		{3.5, mgl32.Vec3{-2, 0, 3}, turn(-20)},
+		{1, mgl32.Vec3{1, 2, 0}, turn(30)},
+	} {
+		p.Add(k)
+	}
+	if 3.5 != p.Duration() {
+		t.Fatalf("duration %v", p.Duration())
+	}
+	for _, k := range p.Keys {
+		pos, q := p.Sample(k.Time)
+		if !near(pos, k.Position) || !q.OrientationEqualThreshold(k.Orientation, 1e-5) {
+			t.Errorf("at %v: %v %v, want %v %v", k.Time, pos, q, k.Position, k.Orientation)
+		}
+	}
+	// holds still outside the keys
+	if pos, _ := p.Sample(-1); pos != p.Keys[0].Position {
+		t.Errorf("before the start at %v", pos)
+	}
+	if pos, _ := p.Sample(9); pos != p.Keys[3].Position {
+		t.Errorf("after the end at %v", pos)
+	}
+
+	// no kink at the keys: the velocity is the same on both sides
+	const h = 1e-3
+	for _, k := range p.Keys[1:3] {
+		before, _ := p.Sample(k.Time - h)
+		after, _ := p.Sample(k.Time + h)
+		if d := k.Position.Sub(before).Sub(after.Sub(k.Position)).Len(); d > 1e-4 {
+			t.Errorf("at %v: velocity jumps by %v", k.Time, d/h)
+		}
+	}
+}
+
+func TestSampleSteady(t *testing.T) {
+	// evenly spaced keys along a line turning evenly are flown evenly
+	p := &Path{}
+	for i := 0; i < 5; i++ {
+		p.Add(Key{float32(i), mgl32.Vec3{float32(2 * i), 1, 0}, turn(float32(30 * i))})
+	}
+	for _, tm := range []float32{0.25, 1.5, 2.9, 3.7} {
+		pos, q := p.Sample(tm)
+		if !near(pos, mgl32.Vec3{2 * tm, 1, 0}) {
+			t.Errorf("at %v: position %v", tm, pos)
+		}
+		if !q.OrientationEqualThreshold(turn(30*tm), 1e-4) {
+			t.Errorf("at %v: orientation %v, want %v", tm, q, turn(30*tm))
+		}
+	}
+
+	// q and -q are the same rotation, a key stored negated changes nothing
+	p.Keys[2].Orientation = p.Keys[2].Orientation.Scale(-1)
+	for _, tm := range []float32{1.5, 2.5} {
+		if _, q := p.Sample(tm); !q.OrientationEqualThreshold(turn(30*tm), 1e-4) {
+			t.Errorf("negated key at %v: orientation %v", tm, q)
+		}
+	}
+}
+
+func TestSampleSquad(t *testing.T) {
+	// unevenly turning keys: squad stays a unit rotation and moves smoothly
+	p := &Path{}
+	axes := []mgl32.Vec3{{0, 1, 0}, {1, 0, 0}, {0, 0, 1}, {1, 1, 0}}
+	q := mgl32.QuatIdent()
+	for i, axis := range axes {
+		p.Add(Key{Time: float32(i), Orientation: q})
+		q = q.Mul(mgl32.QuatRotate(1, axis.Normalize()))
+	}
+	_, prev := p.Sample(0)
+	for tm := float32(0.01); tm <= 3; tm += 0.01 {
+		_, q := p.Sample(tm)
+		if mgl32.Abs(q.Len()-1) > 1e-5 {
+			t.Fatalf("at %v: length %v", tm, q.Len())
+		}
+		if a := 2 * math.Acos(math.Min(1, math.Abs(float64(q.Dot(prev))))); a > 0.05 {
+			t.Fatalf("at %v: jumps %v radians", tm, a)
+		}
+		prev = q
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	r := NewRecorder()
+	for i := 0; i < 10; i++ {
+		q := mgl32.QuatRotate(float32(i)/7, mgl32.Vec3{1, 2, 3}.Normalize())
+		r.Record(1.0/60, mgl32.Vec3{float32(i) / 3, 0.1, -float32(i)}, q)
+	}
+	p := r.Path
+	if 0 != p.Keys[0].Time || 10 != len(p.Keys) || mgl32.Abs(p.Duration()-0.15) > 1e-6 {
+		t.Fatalf("recorded %d keys over %v", len(p.Keys), p.Duration())
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, p); nil != err {
+		t.Fatal(err)
+	}
+	d, err := Decode(&buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(d.Keys) != len(p.Keys) {
+		t.Fatalf("%d keys, want %d", len(d.Keys), len(p.Keys))
+	}
+	for i := range d.Keys {
+		if d.Keys[i] != p.Keys[i] {
+			t.Errorf("key %d: %v, want %v", i, d.Keys[i], p.Keys[i])
+		}
+	}
+
+	// hand written orientations are normalized
+	d, err = Decode(strings.NewReader("# a path\n\n0 0 0 5 2 0 0 0\n1 0 0 4 0 0 3 0\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if d.Keys[0].Orientation != mgl32.QuatIdent() || d.Keys[1].Orientation.V != (mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("keys %v", d.Keys)
+	}
+
+	for _, src := range []string{
+		"0 0 0 5 1 0 0\n",
+		"0 0 0 5 1 0 0 zero\n",
+		"0 0 0 5 0 0 0 0\n",
+		"1 0 0 5 1 0 0 0\n0 0 0 5 1 0 0 0\n",
+	} {
+		if _, err := Decode(strings.NewReader(src)); nil == err {
+			t.Errorf("accepted %q", src)
+		}
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	p := &Path{}
+	p.Add(Key{0, mgl32.Vec3{0, 0, 0}, mgl32.QuatIdent()})
+	p.Add(Key{2, mgl32.Vec3{4, 0, 0}, mgl32.QuatIdent()})
+	player := NewPlayer(p)
+
+	player.Update(0.5)
+	player.Speed = 2
+	player.Update(0.25)
+	if 1 != player.Time {
+		t.Errorf("time %v, want 1", player.Time)
+	}
+	if pos, _ := player.Camera(); !near(pos, mgl32.Vec3{2, 0, 0}) {
+		t.Errorf("camera at %v", pos)
+	}
+	player.Update(10)
+	if 2 != player.Time || !player.Done() {
+		t.Errorf("time %v, done %v past the end", player.Time, player.Done())
+	}
+
+	player.Scrub(-0.5)
+	player.Step(3)
+	if !player.Paused || 93 != player.Frame() {
+		t.Errorf("stepped to frame %v, paused %v", player.Frame(), player.Paused)
+	}
+	player.Update(1)
+	if 93 != player.Frame() {
+		t.Errorf("paused player moved to frame %v", player.Frame())
+	}
+
+	player.Loop = true
+	player.Seek(5)
+	if 1 != player.Time {
+		t.Errorf("looped to %v, want 1", player.Time)
+	}
+	player.Seek(-0.5)
+	if 1.5 != player.Time {
+		t.Errorf("looped back to %v, want 1.5", player.Time)
+	}
+}
+
+func TestPlayerFixed(t *testing.T) {
+	p := &Path{}
+	p.Add(Key{0, mgl32.Vec3{}, mgl32.QuatIdent()})
+	p.Add(Key{10, mgl32.Vec3{10, 0, 0}, mgl32.QuatIdent()})
+
+	// the same frames whatever the frame times
+	a, b := NewPlayer(p), NewPlayer(p)
+	a.Fixed, b.Fixed = true, true
+	a.FrameRate, b.FrameRate = 30, 30
+	for i := 1; i <= 300; i++ {
+		a.Update(0.001)
+		b.Update(float32(i%7) * 0.1)
+		if a.Time != b.Time || float32(i)/30 != a.Time {
+			t.Fatalf("frame %d: times %v and %v", i, a.Time, b.Time)
+		}
+		if i != a.Frame() {
+			t.Fatalf("frame %d reported as %d", i, a.Frame())
+		}
+	}
+	if !a.Done() {
+		t.Error("not done at the end")
+	}
+
+	a.Speed = -1
+	a.Update(1)
+	if 299 != a.Frame() {
+		t.Errorf("backward to frame %d", a.Frame())
+	}
+}
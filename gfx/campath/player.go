@@ -0,0 +1,120 @@
+package campath
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Recorder adds a key to a path for every frame it is given.
+type Recorder struct {
+	Path *Path
+	time float32
+}
+
+// NewRecorder starts recording into an empty path.
+func NewRecorder() *Recorder {
+	return &Recorder{Path: &Path{}}
+}
+
+// Record adds the camera of a frame that lasted dt seconds; the first
+// frame is at time 0.
+func (r *Recorder) Record(dt float32, position mgl32.Vec3, orientation mgl32.Quat) {
+	if 0 != len(r.Path.Keys) {
+		r.time += dt
+	}
+	r.Path.Add(Key{Time: r.time, Position: position, Orientation: orientation})
+}
+
+// Player plays a path back.
+//
+// In real time it follows the frame times it is given, scaled by Speed.
+// With Fixed set it ignores them and moves exactly one frame of FrameRate
+// per Update instead, so a capture shows the same frames however long
+// rendering them takes.
+type Player struct {
+	Path      *Path
+	Time      float32 // seconds into the path
+	Speed     float32 // 1 plays forward in real time, negative backward
+	Paused    bool
+	Loop      bool
+	Fixed     bool
+	FrameRate float32 // frames per second of Fixed playback and Step
+}
+
+// NewPlayer returns a player at the start of p, playing in real time.
+func NewPlayer(p *Path) *Player {
+	return &Player{Path: p, Speed: 1, FrameRate: 60}
+}
+
+// Frame returns the frame Time falls on.
+func (p *Player) Frame() int {
+	return int(math.Floor(float64(p.Time*p.FrameRate) + 0.5))
+}
+
+// Update advances playback by a frame that took dt seconds.
+func (p *Player) Update(dt float32) {
+	if p.Paused {
+		return
+	}
+	if p.Fixed {
+		// counting frames rather than adding up times keeps frame n at
+		// exactly n / FrameRate
+		step := 1
+		if p.Speed < 0 {
+			step = -1
+		}
+		p.seekFrame(p.Frame() + step)
+		return
+	}
+	p.Seek(p.Time + dt*p.Speed)
+}
+
+// Step pauses playback and moves it by frames, back for negative ones.
+func (p *Player) Step(frames int) {
+	p.Paused = true
+	p.seekFrame(p.Frame() + frames)
+}
+
+func (p *Player) seekFrame(frame int) {
+	p.Seek(float32(frame) / p.FrameRate)
+}
+
+// Scrub moves playback by seconds, back for negative ones.
+func (p *Player) Scrub(seconds float32) {
+	p.Seek(p.Time + seconds)
+}
+
+// Seek moves playback to time t, wrapping around if Loop is set.
+func (p *Player) Seek(t float32) {
+	d := p.Path.Duration()
+	switch {
+	case p.Loop && d > 0:
+		t = float32(math.Mod(float64(t), float64(d)))
+		if t < 0 {
+			t += d
+		}
+	case t < 0:
+		t = 0
+	case t > d:
+		t = d
+	}
+	p.Time = t
+}
+
+// Done reports whether playback has run to the end of the path, or to the
+// start going backward.
+func (p *Player) Done() bool {
+	if p.Loop {
+		return false
+	}
+	if p.Speed < 0 {
+		return p.Time <= 0
+	}
+	return p.Time >= p.Path.Duration()
+}
+
+// Camera returns the camera at the current time.
+func (p *Player) Camera() (mgl32.Vec3, mgl32.Quat) {
+	return p.Path.Sample(p.Time)
+}
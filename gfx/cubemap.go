@@ -0,0 +1,294 @@
+package gfx
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/alexniver/opengl-dev-go/gfx/hdr"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Cubemap faces in the order OpenGL numbers them, TEXTURE_CUBE_MAP_POSITIVE_X
+// plus the index.
+const (
+	FacePositiveX = iota
+	FaceNegativeX
+	FacePositiveY
+	FaceNegativeY
+	FacePositiveZ
+	FaceNegativeZ
+)
+
+// NewCubemap loads six face images, ordered +X, -X, +Y, -Y, +Z, -Z, into a
+// new TEXTURE_CUBE_MAP. Faces are uploaded top row first, the way skybox
+// images are usually authored, so opts.FlipV is ignored.
+func NewCubemap(faces [6]string, opts TextureOptions) (uint32, error) {
+	var images [6]*image.RGBA
+	for i, file := range faces {
+		rgba, err := loadRGBA(file)
+		if nil != err {
+			return 0, err
+		}
+		images[i] = rgba
+	}
+	return NewCubemapFromImages(images, opts)
+}
+
+// NewCubemapFromLayout loads a single image holding all six faces as a
+// horizontal (4x3) or vertical (3x4) cross, or a horizontal (6x1) or
+// vertical (1x6) strip in +X, -X, +Y, -Y, +Z, -Z order. The layout is picked
+// from the aspect ratio.
+func NewCubemapFromLayout(file string, opts TextureOptions) (uint32, error) {
+	rgba, err := loadRGBA(file)
+	if nil != err {
+		return 0, err
+	}
+	faces, err := splitCubeLayout(rgba)
+	if nil != err {
+		return 0, fmt.Errorf("cubemap %q: %v", file, err)
+	}
+	return NewCubemapFromImages(faces, opts)
+}
+
+// NewCubemapFromEquirect converts an equirectangular (longitude/latitude)
+// panorama into faceSize x faceSize cube faces on the CPU. Radiance .hdr and
+// OpenEXR .exr panoramas produce an RGB16F cubemap, anything else RGBA8.
+func NewCubemapFromEquirect(file string, faceSize int, opts TextureOptions) (uint32, error) {
+	if isHDRFile(file) {
+		img, err := hdr.DecodeFile(file)
+		if nil != err {
+			return 0, err
+		}
+		return NewHDRCubemapFromImages(equirectToCubeHDR(img, faceSize), opts), nil
+	}
+	rgba, err := loadRGBA(file)
+	if nil != err {
+		return 0, err
+	}
+	return NewCubemapFromImages(equirectToCube(rgba, faceSize), opts)
+}
+
+// NewCubemapFromImages uploads six square faces of equal size.
+func NewCubemapFromImages(faces [6]*image.RGBA, opts TextureOptions) (uint32, error) {
+	size := faces[0].Rect.Size()
+	for i, face := range faces {
+		if s := face.Rect.Size(); s.X != s.Y || s != size {
+			return 0, fmt.Errorf("cubemap face %d is %dx%d, want %dx%d squares", i, s.X, s.Y, size.X, size.X)
+		}
+	}
+	opts = opts.withDefaults()
+
+	texture := newCubemapTexture()
+	for i, face := range faces {
+		gl.TexImage2D(
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
+			0,
+			opts.internalFormat(),
+			int32(size.X),
+			int32(size.Y),
+			0,
+			gl.RGBA,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(face.Pix),
+		)
+	}
+	opts.apply(gl.TEXTURE_CUBE_MAP)
+	return texture, nil
+}
+
+// NewHDRCubemapFromImages uploads six float faces as RGB16F.
+func NewHDRCubemapFromImages(faces [6]*hdr.Image, opts TextureOptions) uint32 {
+	opts = opts.withDefaults()
+
+	texture := newCubemapTexture()
+	for i, face := range faces {
+		gl.TexImage2D(
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
+			0,
+			gl.RGB16F,
+			int32(face.Width),
+			int32(face.Height),
+			0,
+			gl.RGB,
+			gl.FLOAT,
+			gl.Ptr(face.Pix),
+		)
+	}
+	opts.apply(gl.TEXTURE_CUBE_MAP)
+	return texture
+}
+
+func newCubemapTexture() uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	return texture
+}
+
+// cubeLayouts gives the cell of every face, in face order, for each layout
+// keyed by its size in cells. The -Z face of a vertical cross is stored
+// upside down.
+var cubeLayouts = map[image.Point][6]image.Point{
+	{4, 3}: {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}},
+	{3, 4}: {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}},
+	{6, 1}: {{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}},
+	{1, 6}: {{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}},
+}
+
+// splitCubeLayout cuts a cross or strip image into its six faces.
+func splitCubeLayout(img *image.RGBA) ([6]*image.RGBA, error) {
+	var faces [6]*image.RGBA
+	size := img.Rect.Size()
+	var cells image.Point
+	var layout [6]image.Point
+	found := false
+	for c, l := range cubeLayouts {
+		if size.X*c.Y == size.Y*c.X && 0 == size.X%c.X {
+			cells, layout, found = c, l, true
+			break
+		}
+	}
+	if !found {
+		return faces, fmt.Errorf("%dx%d is not a 4x3 or 3x4 cross or a 6x1 or 1x6 strip", size.X, size.Y)
+	}
+
+	edge := size.X / cells.X
+	for i, cell := range layout {
+		face := image.NewRGBA(image.Rect(0, 0, edge, edge))
+		from := img.Rect.Min.Add(cell.Mul(edge))
+		draw.Draw(face, face.Rect, img, from, draw.Src)
+		if (image.Point{3, 4}) == cells && FaceNegativeZ == i {
+			rotate180(face)
+		}
+		faces[i] = face
+	}
+	return faces, nil
+}
+
+func rotate180(img *image.RGBA) {
+	pix := img.Pix
+	for i, j := 0, len(pix)-4; i < j; i, j = i+4, j-4 {
+		for k := 0; k < 4; k++ {
+			pix[i+k], pix[j+k] = pix[j+k], pix[i+k]
+		}
+	}
+}
+
+// cubeDirection returns the direction through texel (x, y) of a face, row 0
+// being the top row, following the OpenGL cubemap face orientation.
+func cubeDirection(face, x, y, size int) mgl32.Vec3 {
+	s := 2*(float32(x)+0.5)/float32(size) - 1
+	t := 2*(float32(y)+0.5)/float32(size) - 1
+	var dir mgl32.Vec3
+	switch face {
+	case FacePositiveX:
+		dir = mgl32.Vec3{1, -t, -s}
+	case FaceNegativeX:
+		dir = mgl32.Vec3{-1, -t, s}
+	case FacePositiveY:
+		dir = mgl32.Vec3{s, 1, t}
+	case FaceNegativeY:
+		dir = mgl32.Vec3{s, -1, -t}
+	case FacePositiveZ:
+		dir = mgl32.Vec3{s, -t, 1}
+	case FaceNegativeZ:
+		dir = mgl32.Vec3{-s, -t, -1}
+	}
+	return dir.Normalize()
+}
+
+// equirectUV maps a direction to panorama coordinates in [0, 1], u growing
+// eastwards from -Z at the center and v from the north pole down.
+func equirectUV(dir mgl32.Vec3) (float64, float64) {
+	u := 0.5 + math.Atan2(float64(dir[0]), -float64(dir[2]))/(2*math.Pi)
+	v := 0.5 - math.Asin(float64(mgl32.Clamp(dir[1], -1, 1)))/math.Pi
+	return u, v
+}
+
+// bilinear samples a width x height grid of channels-sized texels at (u, v)
+// in [0, 1], wrapping horizontally and clamping vertically.
+func bilinear(width, height int, u, v float64, texel func(x, y int) []float64, out []float64) {
+	fx := u*float64(width) - 0.5
+	fy := v*float64(height) - 0.5
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	ax, ay := fx-float64(x0), fy-float64(y0)
+
+	wrap := func(x int) int { return ((x % width) + width) % width }
+	clamp := func(y int) int {
+		if y < 0 {
+			return 0
+		}
+		if y >= height {
+			return height - 1
+		}
+		return y
+	}
+
+	for i := range out {
+		out[i] = 0
+	}
+	for _, c := range [4]struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - ax) * (1 - ay)},
+		{x0 + 1, y0, ax * (1 - ay)},
+		{x0, y0 + 1, (1 - ax) * ay},
+		{x0 + 1, y0 + 1, ax * ay},
+	} {
+		t := texel(wrap(c.x), clamp(c.y))
+		for i := range out {
+			out[i] += c.w * t[i]
+		}
+	}
+}
+
+func equirectToCube(pano *image.RGBA, size int) [6]*image.RGBA {
+	var faces [6]*image.RGBA
+	width, height := pano.Rect.Dx(), pano.Rect.Dy()
+	buf := make([]float64, 4)
+	texel := func(x, y int) []float64 {
+		p := pano.Pix[y*pano.Stride+4*x:]
+		return []float64{float64(p[0]), float64(p[1]), float64(p[2]), float64(p[3])}
+	}
+	for f := range faces {
+		face := image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				u, v := equirectUV(cubeDirection(f, x, y, size))
+				bilinear(width, height, u, v, texel, buf)
+				p := face.Pix[y*face.Stride+4*x:]
+				for i := 0; i < 4; i++ {
+					p[i] = uint8(math.Min(255, buf[i]+0.5))
+				}
+			}
+		}
+		faces[f] = face
+	}
+	return faces
+}
+
+func equirectToCubeHDR(pano *hdr.Image, size int) [6]*hdr.Image {
+	var faces [6]*hdr.Image
+	buf := make([]float64, 3)
+	texel := func(x, y int) []float64 {
+		r, g, b := pano.At(x, y)
+		return []float64{float64(r), float64(g), float64(b)}
+	}
+	for f := range faces {
+		face := hdr.NewImage(size, size)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				u, v := equirectUV(cubeDirection(f, x, y, size))
+				bilinear(pano.Width, pano.Height, u, v, texel, buf)
+				face.Set(x, y, float32(buf[0]), float32(buf[1]), float32(buf[2]))
+			}
+		}
+		faces[f] = face
+	}
+	return faces
+}
@@ -0,0 +1,143 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/hdr"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// paintCells fills every edge x edge cell of img with a color naming its cell.
+func paintCells(img *image.RGBA, edge int) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x / edge), uint8(y / edge), 0, 255})
+		}
+	}
+}
+
+func TestSplitCubeLayout(t *testing.T) {
+	for cells, layout := range cubeLayouts {
+		img := image.NewRGBA(image.Rect(0, 0, 8*cells.X, 8*cells.Y))
+		paintCells(img, 8)
+		faces, err := splitCubeLayout(img)
+		if nil != err {
+			t.Fatalf("%v: %v", cells, err)
+		}
+		for i, face := range faces {
+			if face.Rect.Size() != (image.Point{8, 8}) {
+				t.Fatalf("%v: face %d is %v", cells, i, face.Rect)
+			}
+			c := face.RGBAAt(4, 4)
+			if got := (image.Point{int(c.R), int(c.G)}); got != layout[i] {
+				t.Errorf("%v: face %d came from cell %v, want %v", cells, i, got, layout[i])
+			}
+		}
+	}
+
+	if _, err := splitCubeLayout(image.NewRGBA(image.Rect(0, 0, 10, 10))); nil == err {
+		t.Error("a square image split without error")
+	}
+}
+
+func TestSplitVerticalCrossRotatesNegativeZ(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 6, 8))
+	// mark the top left texel of the -Z cell, it has to end up bottom right
+	img.SetRGBA(2, 6, color.RGBA{255, 0, 0, 255})
+	faces, err := splitCubeLayout(img)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := faces[FaceNegativeZ].RGBAAt(1, 1); got.R != 255 {
+		t.Errorf("-Z face was not rotated, bottom right is %v", got)
+	}
+}
+
+func TestCubeDirection(t *testing.T) {
+	centers := map[int]mgl32.Vec3{
+		FacePositiveX: {1, 0, 0}, FaceNegativeX: {-1, 0, 0},
+		FacePositiveY: {0, 1, 0}, FaceNegativeY: {0, -1, 0},
+		FacePositiveZ: {0, 0, 1}, FaceNegativeZ: {0, 0, -1},
+	}
+	for face, want := range centers {
+		// a 1x1 face only has its center texel
+		if got := cubeDirection(face, 0, 0, 1); !got.ApproxEqual(want) {
+			t.Errorf("face %d center = %v, want %v", face, got, want)
+		}
+	}
+	// the top row of every side face looks up
+	for _, face := range []int{FacePositiveX, FaceNegativeX, FacePositiveZ, FaceNegativeZ} {
+		if dir := cubeDirection(face, 1, 0, 4); dir[1] <= 0 {
+			t.Errorf("face %d top row points down: %v", face, dir)
+		}
+	}
+	// +Y's top row borders -Z
+	if dir := cubeDirection(FacePositiveY, 1, 0, 4); dir[2] >= 0 {
+		t.Errorf("+Y top row points to %v, want -Z", dir)
+	}
+}
+
+func TestEquirectUV(t *testing.T) {
+	cases := []struct {
+		dir  mgl32.Vec3
+		u, v float64
+	}{
+		{mgl32.Vec3{0, 0, -1}, 0.5, 0.5},
+		{mgl32.Vec3{1, 0, 0}, 0.75, 0.5},
+		{mgl32.Vec3{-1, 0, 0}, 0.25, 0.5},
+		// u is arbitrary at the poles
+		{mgl32.Vec3{0, 1, 0}, -1, 0},
+		{mgl32.Vec3{0, -1, 0}, -1, 1},
+	}
+	for _, c := range cases {
+		u, v := equirectUV(c.dir)
+		if (c.u >= 0 && math.Abs(u-c.u) > 1e-6) || math.Abs(v-c.v) > 1e-6 {
+			t.Errorf("equirectUV(%v) = %g, %g, want %g, %g", c.dir, u, v, c.u, c.v)
+		}
+	}
+}
+
+func TestEquirectToCube(t *testing.T) {
+	// upper half white, lower half black: +Y must come out white, -Y black
+	pano := hdr.NewImage(64, 32)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 64; x++ {
+			pano.Set(x, y, 4, 4, 4)
+		}
+	}
+	faces := equirectToCubeHDR(pano, 8)
+	if r, _, _ := faces[FacePositiveY].At(4, 4); r != 4 {
+		t.Errorf("+Y center = %g, want 4", r)
+	}
+	if r, _, _ := faces[FaceNegativeY].At(4, 4); r != 0 {
+		t.Errorf("-Y center = %g, want 0", r)
+	}
+	if r, _, _ := faces[FacePositiveX].At(4, 1); r != 4 {
+		t.Errorf("+X near the top = %g, want 4", r)
+	}
+
+	ldr := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for i := range ldr.Pix {
+		ldr.Pix[i] = 200
+	}
+	for _, face := range equirectToCube(ldr, 4) {
+		if got := face.RGBAAt(2, 2); got != (color.RGBA{200, 200, 200, 200}) {
+			t.Fatalf("uniform panorama sampled as %v", got)
+		}
+	}
+}
+
+func TestRotationOnly(t *testing.T) {
+	view := mgl32.LookAtV(mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	r := RotationOnly(view)
+	if got := r.Col(3); got != (mgl32.Vec4{0, 0, 0, 1}) {
+		t.Errorf("translation column = %v", got)
+	}
+	if !r.Mat3().ApproxEqual(view.Mat3()) {
+		t.Error("rotation changed")
+	}
+}
@@ -0,0 +1,49 @@
+// Package gfx collects the glfw and OpenGL helpers that the lessons used to
+// copy from one main.go to the next: window and context creation, buffer
+// objects, shader programs and textures.
+//
+// Everything here must be called from the thread that owns the GL context,
+// so programs should keep runtime.LockOSThread in their init as before.
+package gfx
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// InitGlfw initializes glfw and opens a window with a current OpenGL 3.3 core
+// context. Call glfw.Terminate when done.
+func InitGlfw(width, height int, title string) (*glfw.Window, error) {
+	if err := glfw.Init(); nil != err {
+		return nil, fmt.Errorf("failed to initialize glfw: %v", err)
+	}
+
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(width, height, title, nil, nil)
+	if nil != err {
+		glfw.Terminate()
+		return nil, fmt.Errorf("failed to create window: %v", err)
+	}
+	window.MakeContextCurrent()
+
+	return window, nil
+}
+
+// InitOpenGL loads the OpenGL function pointers for the current context and
+// logs the driver version.
+func InitOpenGL() error {
+	if err := gl.Init(); nil != err {
+		return fmt.Errorf("failed to initialize opengl: %v", err)
+	}
+	version := gl.GoStr(gl.GetString(gl.VERSION))
+	log.Println("Opengl version", version)
+	return nil
+}
@@ -0,0 +1,218 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/alexniver/opengl-dev-go/gfx/gltf"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Texture units GLTFScene.Draw binds the material textures to.
+const (
+	UnitBaseColor = iota
+	UnitMetallicRoughness
+	UnitNormal
+	UnitOcclusion
+	UnitEmissive
+)
+
+// GLTFScene is a glTF model with its primitives and textures uploaded.
+type GLTFScene struct {
+	Model *gltf.Model
+
+	meshes   map[*gltf.Primitive]*GPUMesh
+	textures map[gltfTextureKey]uint32
+	white    uint32 // stands in for missing color and data textures
+	flat     uint32 // stands in for a missing normal map
+}
+
+type gltfTextureKey struct {
+	texture *gltf.Texture
+	srgb    bool
+}
+
+// LoadGLTF reads a .gltf or .glb file and uploads it.
+func LoadGLTF(file string) (*GLTFScene, error) {
+	model, err := gltf.DecodeFile(file)
+	if nil != err {
+		return nil, err
+	}
+	return NewGLTFScene(model)
+}
+
+// NewGLTFScene uploads every primitive of model and every texture its
+// materials use. Color textures (base color and emissive) are stored as
+// sRGB; external images go through NewTextureWithOptions.
+func NewGLTFScene(model *gltf.Model) (*GLTFScene, error) {
+	s := &GLTFScene{
+		Model:    model,
+		meshes:   map[*gltf.Primitive]*GPUMesh{},
+		textures: map[gltfTextureKey]uint32{},
+	}
+	s.white = solidTexture(color.NRGBA{255, 255, 255, 255})
+	s.flat = solidTexture(color.NRGBA{128, 128, 255, 255})
+
+	for _, m := range model.Meshes {
+		for _, p := range m.Primitives {
+			s.meshes[p] = NewGPUMesh(p.Mesh)
+		}
+	}
+	for _, m := range model.Materials {
+		refs := []struct {
+			ref  *gltf.TextureRef
+			srgb bool
+		}{
+			{m.BaseColorTexture, true},
+			{m.MetallicRoughnessTexture, false},
+			{m.NormalTexture, false},
+			{m.OcclusionTexture, false},
+			{m.EmissiveTexture, true},
+		}
+		for _, r := range refs {
+			if nil == r.ref || nil == r.ref.Texture.Image {
+				continue
+			}
+			key := gltfTextureKey{r.ref.Texture, r.srgb}
+			if _, ok := s.textures[key]; ok {
+				continue
+			}
+			texture, err := newGLTFTexture(r.ref.Texture, r.srgb)
+			if nil != err {
+				s.Delete()
+				return nil, err
+			}
+			s.textures[key] = texture
+		}
+	}
+	gl.BindVertexArray(0)
+	return s, nil
+}
+
+func newGLTFTexture(t *gltf.Texture, srgb bool) (uint32, error) {
+	opts := TextureOptions{
+		MinFilter: t.Sampler.MinFilter,
+		MagFilter: t.Sampler.MagFilter,
+		WrapS:     t.Sampler.WrapS,
+		WrapT:     t.Sampler.WrapT,
+		SRGB:      srgb,
+	}
+	switch opts.MinFilter {
+	case 0:
+		opts.MinFilter = gl.LINEAR_MIPMAP_LINEAR
+		opts.GenerateMipmaps = true
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		opts.GenerateMipmaps = true
+	}
+
+	img := t.Image
+	if nil == img.Data {
+		return NewTextureWithOptions(img.File, opts)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+	if nil != err {
+		return 0, fmt.Errorf("gltf image %q: %v", img.Name, err)
+	}
+	return NewTextureFromImage(decoded, opts), nil
+}
+
+func solidTexture(c color.NRGBA) uint32 {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return NewTextureFromImage(img, TextureOptions{})
+}
+
+// Mesh returns the uploaded primitive.
+func (s *GLTFScene) Mesh(p *gltf.Primitive) *GPUMesh {
+	return s.meshes[p]
+}
+
+// Texture returns the uploaded texture ref points at, 0 if there is none.
+// srgb has to match how NewGLTFScene stored it: true for base color and
+// emissive textures.
+func (s *GLTFScene) Texture(ref *gltf.TextureRef, srgb bool) uint32 {
+	if nil == ref {
+		return 0
+	}
+	return s.textures[gltfTextureKey{ref.Texture, srgb}]
+}
+
+// Draw draws the default scene with the program in use, transformed by
+// parent. For every primitive it sets whichever of these uniforms the
+// program has:
+//
+//	mat4 model
+//	vec4 baseColorFactor
+//	float metallicFactor, roughnessFactor
+//	vec3 emissiveFactor
+//	sampler2D baseColorTexture, metallicRoughnessTexture, normalTexture,
+//	          occlusionTexture, emissiveTexture
+//
+// The samplers use the Unit* texture units; missing textures are replaced
+// by white, or a flat normal map. Camera uniforms are left to the caller.
+func (s *GLTFScene) Draw(program *Program, parent mgl32.Mat4) {
+	if nil == s.Model.Scene {
+		return
+	}
+	s.Model.Scene.Walk(func(n *gltf.Node, world mgl32.Mat4) {
+		if nil == n.Mesh {
+			return
+		}
+		program.SetMat4("model", parent.Mul4(world))
+		for _, p := range n.Mesh.Primitives {
+			s.bindMaterial(program, p.Material)
+			s.meshes[p].Draw()
+		}
+	})
+}
+
+// bindMaterial sets the material uniforms. Errors only mean the program
+// does not use that input.
+func (s *GLTFScene) bindMaterial(program *Program, m *gltf.Material) {
+	program.SetVec4("baseColorFactor", m.BaseColorFactor)
+	program.SetFloat("metallicFactor", m.MetallicFactor)
+	program.SetFloat("roughnessFactor", m.RoughnessFactor)
+	program.SetVec3("emissiveFactor", m.EmissiveFactor)
+
+	textures := []struct {
+		name     string
+		unit     int32
+		ref      *gltf.TextureRef
+		srgb     bool
+		fallback uint32
+	}{
+		{"baseColorTexture", UnitBaseColor, m.BaseColorTexture, true, s.white},
+		{"metallicRoughnessTexture", UnitMetallicRoughness, m.MetallicRoughnessTexture, false, s.white},
+		{"normalTexture", UnitNormal, m.NormalTexture, false, s.flat},
+		{"occlusionTexture", UnitOcclusion, m.OcclusionTexture, false, s.white},
+		{"emissiveTexture", UnitEmissive, m.EmissiveTexture, true, s.white},
+	}
+	for _, t := range textures {
+		if nil != program.SetSampler(t.name, t.unit) {
+			continue
+		}
+		texture := s.Texture(t.ref, t.srgb)
+		if 0 == texture {
+			texture = t.fallback
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(t.unit))
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// Delete releases every buffer and texture of the scene.
+func (s *GLTFScene) Delete() {
+	for _, m := range s.meshes {
+		m.Delete()
+	}
+	for _, t := range s.textures {
+		gl.DeleteTextures(1, &t)
+	}
+	gl.DeleteTextures(1, &s.white)
+	gl.DeleteTextures(1, &s.flat)
+	s.meshes, s.textures = nil, nil
+}
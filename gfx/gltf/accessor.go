@@ -0,0 +1,250 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Accessor component types.
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+var componentSizes = map[int]int{
+	componentByte:          1,
+	componentUnsignedByte:  1,
+	componentShort:         2,
+	componentUnsignedShort: 2,
+	componentUnsignedInt:   4,
+	componentFloat:         4,
+}
+
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+// view is a strided run of elements in a buffer.
+type view struct {
+	data       []byte
+	stride     int
+	ctype      int
+	csize      int
+	comps      int
+	count      int
+	normalized bool
+}
+
+func (v *view) float(i, c int) float32 {
+	b := v.data[i*v.stride+c*v.csize:]
+	switch v.ctype {
+	case componentFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case componentByte:
+		f := float32(int8(b[0]))
+		if v.normalized {
+			return float32(math.Max(float64(f)/127, -1))
+		}
+		return f
+	case componentUnsignedByte:
+		if v.normalized {
+			return float32(b[0]) / 255
+		}
+		return float32(b[0])
+	case componentShort:
+		f := float32(int16(binary.LittleEndian.Uint16(b)))
+		if v.normalized {
+			return float32(math.Max(float64(f)/32767, -1))
+		}
+		return f
+	case componentUnsignedShort:
+		f := float32(binary.LittleEndian.Uint16(b))
+		if v.normalized {
+			return f / 65535
+		}
+		return f
+	case componentUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (v *view) uint(i, c int) uint32 {
+	b := v.data[i*v.stride+c*v.csize:]
+	switch v.ctype {
+	case componentUnsignedByte, componentByte:
+		return uint32(b[0])
+	case componentUnsignedShort, componentShort:
+		return uint32(binary.LittleEndian.Uint16(b))
+	case componentUnsignedInt:
+		return binary.LittleEndian.Uint32(b)
+	case componentFloat:
+		return uint32(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	return 0
+}
+
+// bufferView returns the bytes of buffer view index starting at offset,
+// and its stride.
+func (d *decoder) bufferView(index, offset int) ([]byte, int, error) {
+	if index < 0 || index >= len(d.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d does not exist", index)
+	}
+	bv := d.doc.BufferViews[index]
+	if bv.Buffer < 0 || bv.Buffer >= len(d.buffers) {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d: buffer %d does not exist", index, bv.Buffer)
+	}
+	buf := d.buffers[bv.Buffer]
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteOffset+bv.ByteLength > len(buf) {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d overruns buffer %d", index, bv.Buffer)
+	}
+	data := buf[bv.ByteOffset : bv.ByteOffset+bv.ByteLength]
+	if offset < 0 || offset > len(data) {
+		return nil, 0, fmt.Errorf("gltf: offset %d outside buffer view %d", offset, index)
+	}
+	return data[offset:], bv.ByteStride, nil
+}
+
+// maxElements caps the numbers one accessor may expand to, so that a corrupt
+// count fails to load instead of exhausting memory.
+const maxElements = 1 << 28
+
+// checkCount rejects counts that are negative or too large to allocate.
+func checkCount(index int, a docAccessor, comps int) error {
+	if a.Count < 0 || a.Count > maxElements/comps {
+		return fmt.Errorf("gltf: accessor %d has bad count %d", index, a.Count)
+	}
+	if s := a.Sparse; nil != s && (s.Count < 0 || s.Count > a.Count) {
+		return fmt.Errorf("gltf: accessor %d has bad sparse count %d", index, s.Count)
+	}
+	return nil
+}
+
+// newView checks that count elements of comps components fit in data.
+func newView(data []byte, stride, ctype, comps, count int, normalized bool) (*view, error) {
+	csize, ok := componentSizes[ctype]
+	if !ok {
+		return nil, fmt.Errorf("gltf: unknown component type %d", ctype)
+	}
+	if 0 == stride {
+		stride = csize * comps
+	} else if stride < 4 || stride > 252 || 0 != stride%4 || stride < csize*comps {
+		return nil, fmt.Errorf("gltf: bad stride %d for elements of %d bytes", stride, csize*comps)
+	}
+	if count > 0 && (count-1)*stride+csize*comps > len(data) {
+		return nil, fmt.Errorf("gltf: %d elements of %d bytes with stride %d do not fit in %d bytes", count, csize*comps, stride, len(data))
+	}
+	return &view{data: data, stride: stride, ctype: ctype, csize: csize, comps: comps, count: count, normalized: normalized}, nil
+}
+
+// accessor returns the elements of accessor index as floats, comps per
+// element, with normalized integers mapped to [0, 1] or [-1, 1] and sparse
+// substitutions applied.
+func (d *decoder) accessor(index int) ([]float32, int, error) {
+	if index < 0 || index >= len(d.doc.Accessors) {
+		return nil, 0, fmt.Errorf("gltf: accessor %d does not exist", index)
+	}
+	a := d.doc.Accessors[index]
+	comps, ok := typeComponents[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has unknown type %q", index, a.Type)
+	}
+	if err := checkCount(index, a, comps); nil != err {
+		return nil, 0, err
+	}
+
+	out := make([]float32, a.Count*comps)
+	if nil != a.BufferView {
+		data, stride, err := d.bufferView(*a.BufferView, a.ByteOffset)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d: %v", index, err)
+		}
+		v, err := newView(data, stride, a.ComponentType, comps, a.Count, a.Normalized)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d: %v", index, err)
+		}
+		for i := 0; i < a.Count; i++ {
+			for c := 0; c < comps; c++ {
+				out[i*comps+c] = v.float(i, c)
+			}
+		}
+	}
+	// without a buffer view the accessor is all zeros until sparse fills it
+
+	if s := a.Sparse; nil != s {
+		idata, _, err := d.bufferView(s.Indices.BufferView, s.Indices.ByteOffset)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d sparse indices: %v", index, err)
+		}
+		indices, err := newView(idata, 0, s.Indices.ComponentType, 1, s.Count, false)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d sparse indices: %v", index, err)
+		}
+		vdata, _, err := d.bufferView(s.Values.BufferView, s.Values.ByteOffset)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d sparse values: %v", index, err)
+		}
+		values, err := newView(vdata, 0, a.ComponentType, comps, s.Count, a.Normalized)
+		if nil != err {
+			return nil, 0, fmt.Errorf("gltf: accessor %d sparse values: %v", index, err)
+		}
+		for i := 0; i < s.Count; i++ {
+			target := int(indices.uint(i, 0))
+			if target >= a.Count {
+				return nil, 0, fmt.Errorf("gltf: accessor %d sparse index %d out of range", index, target)
+			}
+			for c := 0; c < comps; c++ {
+				out[target*comps+c] = values.float(i, c)
+			}
+		}
+	}
+	return out, comps, nil
+}
+
+// indices reads an index accessor. Going through float32 would lose
+// precision above 2^24, so it reads the integers directly.
+func (d *decoder) indices(index int) ([]uint32, error) {
+	if index < 0 || index >= len(d.doc.Accessors) {
+		return nil, fmt.Errorf("gltf: accessor %d does not exist", index)
+	}
+	a := d.doc.Accessors[index]
+	if "SCALAR" != a.Type || nil != a.Sparse || nil == a.BufferView {
+		// rare enough that the float path is fine
+		f, _, err := d.accessor(index)
+		if nil != err {
+			return nil, err
+		}
+		out := make([]uint32, len(f))
+		for i, v := range f {
+			out[i] = uint32(v)
+		}
+		return out, nil
+	}
+	if err := checkCount(index, a, 1); nil != err {
+		return nil, err
+	}
+	data, stride, err := d.bufferView(*a.BufferView, a.ByteOffset)
+	if nil != err {
+		return nil, fmt.Errorf("gltf: accessor %d: %v", index, err)
+	}
+	v, err := newView(data, stride, a.ComponentType, 1, a.Count, false)
+	if nil != err {
+		return nil, fmt.Errorf("gltf: accessor %d: %v", index, err)
+	}
+	out := make([]uint32, a.Count)
+	for i := range out {
+		out[i] = v.uint(i, 0)
+	}
+	return out, nil
+}
@@ -0,0 +1,246 @@
+// Package gltf reads glTF 2.0 scenes, both .gltf with embedded or external
+// buffers and binary .glb, into meshes, PBR metallic-roughness materials
+// and a node hierarchy.
+//
+// Decode stops at a Model: images stay as file names or encoded bytes and
+// buffers become meshes, all left for gfx.NewGLTFScene to upload.
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Model is a decoded glTF asset. Everything the file defines is listed,
+// whether a scene uses it or not.
+type Model struct {
+	Scenes []*Scene
+	Scene  *Scene // the default scene, the first one if the file names none
+
+	Nodes     []*Node
+	Meshes    []*Mesh
+	Materials []*Material
+	Textures  []*Texture
+	Images    []*Image
+	Cameras   []*Camera
+}
+
+// Decoder reads glTF files. The zero value reads from disk.
+//
+// External buffers and images are resolved relative to the glTF file;
+// models that do not come from a file resolve them relative to Dir.
+type Decoder struct {
+	Dir string
+
+	// ReadFile replaces ioutil.ReadFile, mostly for tests.
+	ReadFile func(file string) ([]byte, error)
+}
+
+// ErrNotGLTF is returned for input that is neither glTF JSON nor GLB.
+var ErrNotGLTF = errors.New("gltf: not a glTF or GLB file")
+
+// Decode reads a .gltf or .glb model from r, external files from the
+// working directory.
+func Decode(r io.Reader) (*Model, error) {
+	return new(Decoder).Decode(r)
+}
+
+// DecodeFile reads a .gltf or .glb file and the files it references.
+func DecodeFile(file string) (*Model, error) {
+	return new(Decoder).DecodeFile(file)
+}
+
+// Decode reads a .gltf or .glb model from r.
+func (d *Decoder) Decode(r io.Reader) (*Model, error) {
+	src, err := ioutil.ReadAll(r)
+	if nil != err {
+		return nil, err
+	}
+	return d.decode(d.Dir, src)
+}
+
+// DecodeFile reads a .gltf or .glb file and the files it references.
+func (d *Decoder) DecodeFile(file string) (*Model, error) {
+	src, err := d.readFile(file)
+	if nil != err {
+		return nil, err
+	}
+	m, err := d.decode(filepath.Dir(file), src)
+	if nil != err {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return m, nil
+}
+
+func (d *Decoder) readFile(file string) ([]byte, error) {
+	if nil != d.ReadFile {
+		return d.ReadFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// GLB framing.
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// splitGLB returns the JSON and BIN chunks of a GLB file.
+func splitGLB(src []byte) (jsonChunk, bin []byte, err error) {
+	if len(src) < 12 {
+		return nil, nil, ErrNotGLTF
+	}
+	if version := binary.LittleEndian.Uint32(src[4:]); 2 != version {
+		return nil, nil, fmt.Errorf("gltf: GLB version %d, want 2", version)
+	}
+	length := int(binary.LittleEndian.Uint32(src[8:]))
+	if length > len(src) {
+		return nil, nil, fmt.Errorf("gltf: GLB is %d bytes, header says %d", len(src), length)
+	}
+	for rest := src[12:length]; len(rest) >= 8; {
+		size := int(binary.LittleEndian.Uint32(rest))
+		kind := binary.LittleEndian.Uint32(rest[4:])
+		if size > len(rest)-8 {
+			return nil, nil, errors.New("gltf: GLB chunk overruns the file")
+		}
+		chunk := rest[8 : 8+size]
+		switch kind {
+		case glbChunkJSON:
+			if nil == jsonChunk {
+				jsonChunk = chunk
+			}
+		case glbChunkBIN:
+			if nil == bin {
+				bin = chunk
+			}
+		}
+		rest = rest[8+size:]
+	}
+	if nil == jsonChunk {
+		return nil, nil, errors.New("gltf: GLB without a JSON chunk")
+	}
+	return jsonChunk, bin, nil
+}
+
+// supportedExtensions lists the extensions a file may require. Quantized
+// attributes need nothing special, the accessors read every component type.
+var supportedExtensions = map[string]bool{
+	"KHR_mesh_quantization": true,
+}
+
+// decoder holds the state of one Decode call.
+type decoder struct {
+	*Decoder
+	dir     string
+	doc     document
+	bin     []byte
+	buffers [][]byte
+	model   *Model
+}
+
+func (d *Decoder) decode(dir string, src []byte) (*Model, error) {
+	dec := &decoder{Decoder: d, dir: dir}
+	jsonChunk := src
+	if len(src) >= 4 && glbMagic == binary.LittleEndian.Uint32(src) {
+		var err error
+		if jsonChunk, dec.bin, err = splitGLB(src); nil != err {
+			return nil, err
+		}
+	} else if trimmed := bytes.TrimSpace(src); 0 == len(trimmed) || '{' != trimmed[0] {
+		return nil, ErrNotGLTF
+	}
+	if err := json.Unmarshal(jsonChunk, &dec.doc); nil != err {
+		return nil, fmt.Errorf("gltf: %v", err)
+	}
+	if !strings.HasPrefix(dec.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: asset version %q, want 2.x", dec.doc.Asset.Version)
+	}
+	for _, ext := range dec.doc.ExtensionsRequired {
+		if !supportedExtensions[ext] {
+			return nil, fmt.Errorf("gltf: required extension %s is not supported", ext)
+		}
+	}
+
+	steps := []func() error{
+		dec.loadBuffers,
+		dec.loadImages,
+		dec.loadTextures,
+		dec.loadMaterials,
+		dec.loadMeshes,
+		dec.loadCameras,
+		dec.loadNodes,
+		dec.loadScenes,
+	}
+	dec.model = &Model{}
+	for _, step := range steps {
+		if err := step(); nil != err {
+			return nil, err
+		}
+	}
+	return dec.model, nil
+}
+
+// load reads a data: URI or a file relative to the model.
+func (d *decoder) load(uri string) ([]byte, error) {
+	if isDataURI(uri) {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, errors.New("gltf: only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	return d.readFile(d.path(uri))
+}
+
+// path turns a relative URI into a file path next to the model.
+func (d *decoder) path(uri string) string {
+	if unescaped, err := url.PathUnescape(uri); nil == err {
+		uri = unescaped
+	}
+	file := filepath.FromSlash(uri)
+	if "" == d.dir || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(d.dir, file)
+}
+
+func (d *decoder) loadBuffers() error {
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+	for i, b := range d.doc.Buffers {
+		var data []byte
+		if "" == b.URI {
+			if 0 != i || nil == d.bin {
+				return fmt.Errorf("gltf: buffer %d has no uri", i)
+			}
+			data = d.bin
+		} else {
+			var err error
+			if data, err = d.load(b.URI); nil != err {
+				return fmt.Errorf("gltf: buffer %d: %v", i, err)
+			}
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("gltf: buffer %d is %d bytes, want %d", i, len(data), b.ByteLength)
+		}
+		d.buffers[i] = data[:b.ByteLength]
+	}
+	return nil
+}
+
+// index checks i against a list of n and names it for errors.
+func index(kind string, i, n int) error {
+	if i < 0 || i >= n {
+		return fmt.Errorf("gltf: %s %d does not exist", kind, i)
+	}
+	return nil
+}
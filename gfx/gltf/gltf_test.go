@@ -0,0 +1,289 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// buffer builds a little-endian binary buffer.
+type buffer struct{ bytes.Buffer }
+
+func (b *buffer) floats(f ...float32) *buffer {
+	for _, v := range f {
+		binary.Write(&b.Buffer, binary.LittleEndian, v)
+	}
+	return b
+}
+
+func (b *buffer) uint16s(u ...uint16) *buffer {
+	for _, v := range u {
+		binary.Write(&b.Buffer, binary.LittleEndian, v)
+	}
+	return b
+}
+
+// triangleBuffer holds, at these offsets:
+//
+//	0  three VEC3 positions
+//	36 three VEC2 uvs
+//	60 three uint16 indices, padded to 68
+//	68 one uint16 sparse index, padded to 72
+//	72 one VEC3 sparse value
+func triangleBuffer() []byte {
+	b := new(buffer)
+	b.floats(0, 0, 0, 1, 0, 0, 0, 1, 0)
+	b.floats(0, 1, 1, 1, 0, 0)
+	b.uint16s(0, 1, 2, 0)
+	b.uint16s(2, 0)
+	b.floats(0, 2, 0)
+	return b.Bytes()
+}
+
+const triangleJSON = `{
+	"asset": {"version": "2.0"},
+	"scene": 0,
+	"scenes": [{"name": "main", "nodes": [0]}],
+	"nodes": [
+		{"name": "root", "translation": [1, 0, 0], "children": [1, 2]},
+		{"name": "tri", "mesh": 0, "scale": [2, 2, 2]},
+		{"name": "eye", "camera": 0, "rotation": [0, 0.7071068, 0, 0.7071068]}
+	],
+	"meshes": [{"name": "triangle", "primitives": [
+		{"attributes": {"POSITION": 0, "TEXCOORD_0": 1}, "indices": 2, "material": 0},
+		{"attributes": {"POSITION": 3}, "mode": 5},
+		{"attributes": {"POSITION": 0}, "mode": 1}
+	]}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 0, "byteOffset": 36, "componentType": 5126, "count": 3, "type": "VEC2"},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3",
+		 "sparse": {"count": 1,
+			"indices": {"bufferView": 2, "componentType": 5123},
+			"values": {"bufferView": 3}}}
+	],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 60},
+		{"buffer": 0, "byteOffset": 60, "byteLength": 6},
+		{"buffer": 0, "byteOffset": 68, "byteLength": 2},
+		{"buffer": 0, "byteOffset": 72, "byteLength": 12}
+	],
+	"buffers": [{%s"byteLength": 84}],
+	"materials": [{
+		"name": "brick",
+		"pbrMetallicRoughness": {
+			"baseColorFactor": [1, 0.5, 0.5, 1],
+			"baseColorTexture": {"index": 0},
+			"metallicFactor": 0
+		},
+		"normalTexture": {"index": 1, "scale": 0.5},
+		"alphaMode": "MASK"
+	}],
+	"textures": [{"source": 0, "sampler": 0}, {"source": 1}],
+	"samplers": [{"magFilter": 9728, "wrapS": 33071}],
+	"images": [{"uri": "textures/brick%%20color.png"}, {"uri": "data:image/png;base64,AAEC"}],
+	"cameras": [{"type": "perspective", "perspective": {"yfov": 1, "znear": 0.1}}]
+}`
+
+// memFiles serves files from a map instead of the disk.
+func memFiles(files map[string][]byte) func(string) ([]byte, error) {
+	return func(file string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(file)]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %v", file, os.ErrNotExist)
+		}
+		return src, nil
+	}
+}
+
+func checkTriangle(t *testing.T, model *Model) {
+	if nil == model.Scene || "main" != model.Scene.Name || 1 != len(model.Scene.Nodes) {
+		t.Fatalf("scene %+v", model.Scene)
+	}
+	if 1 != len(model.Meshes) || 2 != len(model.Meshes[0].Primitives) {
+		t.Fatalf("want one mesh with two triangle primitives, got %+v", model.Meshes)
+	}
+
+	prim := model.Meshes[0].Primitives[0]
+	m := prim.Mesh
+	if 3 != m.VertexCount() || 1 != m.TriangleCount() {
+		t.Fatalf("%d vertices %d triangles", m.VertexCount(), m.TriangleCount())
+	}
+	if m.Positions[1] != (mgl32.Vec3{1, 0, 0}) || m.UVs[0] != (mgl32.Vec2{0, 1}) {
+		t.Errorf("positions %v uvs %v", m.Positions, m.UVs)
+	}
+	if nil != m.Normals {
+		t.Errorf("normals %v, the file has none", m.Normals)
+	}
+
+	sparse := model.Meshes[0].Primitives[1].Mesh
+	if sparse.Positions[2] != (mgl32.Vec3{0, 2, 0}) || sparse.Positions[1] != (mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("sparse positions %v", sparse.Positions)
+	}
+	if 1 != sparse.TriangleCount() {
+		t.Errorf("strip of 3 gives %d triangles", sparse.TriangleCount())
+	}
+
+	mat := prim.Material
+	if "brick" != mat.Name || mat.BaseColorFactor != (mgl32.Vec4{1, 0.5, 0.5, 1}) ||
+		0 != mat.MetallicFactor || 1 != mat.RoughnessFactor || "MASK" != mat.AlphaMode || 0.5 != mat.AlphaCutoff {
+		t.Errorf("material %+v", mat)
+	}
+	base := mat.BaseColorTexture.Texture
+	if want := filepath.Join("assets", "textures", "brick color.png"); base.Image.File != want {
+		t.Errorf("base color image %q, want %q", base.Image.File, want)
+	}
+	if (Sampler{MagFilter: 9728, WrapS: 33071, WrapT: glRepeat}) != base.Sampler {
+		t.Errorf("sampler %+v", base.Sampler)
+	}
+	normal := mat.NormalTexture
+	if 0.5 != normal.Scale || !bytes.Equal(normal.Texture.Image.Data, []byte{0, 1, 2}) {
+		t.Errorf("normal texture %+v, image %+v", normal, normal.Texture.Image)
+	}
+	if nil != mat.OcclusionTexture {
+		t.Error("occlusion texture set")
+	}
+	if DefaultMaterial != model.Meshes[0].Primitives[1].Material {
+		t.Error("primitive without material should use DefaultMaterial")
+	}
+
+	root := model.Scene.Nodes[0]
+	tri, eye := model.Nodes[1], model.Nodes[2]
+	if tri.Parent != root || eye.Parent != root || 2 != len(root.Children) {
+		t.Fatal("hierarchy not linked")
+	}
+	p := tri.WorldMatrix().Mul4x1(mgl32.Vec4{1, 0, 0, 1})
+	if p.Vec3() != (mgl32.Vec3{3, 0, 0}) {
+		t.Errorf("world position %v, want (3, 0, 0)", p)
+	}
+	visited := 0
+	model.Scene.Walk(func(n *Node, world mgl32.Mat4) {
+		visited++
+		if !world.ApproxEqualThreshold(n.WorldMatrix(), 1e-6) {
+			t.Errorf("walk matrix of %s differs from WorldMatrix", n.Name)
+		}
+	})
+	if 3 != visited {
+		t.Errorf("walked %d nodes, want 3", visited)
+	}
+
+	cam := eye.Camera
+	if nil == cam || cam.Orthographic || 1 != cam.YFov {
+		t.Fatalf("camera %+v", cam)
+	}
+	// an infinite projection maps points far away to the far plane
+	far := cam.Projection(1).Mul4x1(mgl32.Vec4{0, 0, -1e6, 1})
+	if z := far.Z() / far.W(); math.Abs(float64(z-1)) > 1e-4 {
+		t.Errorf("far depth %v, want 1", z)
+	}
+}
+
+func TestDecodeEmbedded(t *testing.T) {
+	uri := `"uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(triangleBuffer()) + `", `
+	d := &Decoder{Dir: "assets"}
+	model, err := d.Decode(strings.NewReader(fmt.Sprintf(triangleJSON, uri)))
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkTriangle(t, model)
+}
+
+func TestDecodeExternal(t *testing.T) {
+	d := &Decoder{ReadFile: memFiles(map[string][]byte{
+		"assets/triangle.gltf": []byte(fmt.Sprintf(triangleJSON, `"uri": "triangle.bin", `)),
+		"assets/triangle.bin":  triangleBuffer(),
+	})}
+	model, err := d.DecodeFile("assets/triangle.gltf")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkTriangle(t, model)
+}
+
+func TestDecodeGLB(t *testing.T) {
+	doc := []byte(fmt.Sprintf(triangleJSON, ""))
+	for 0 != len(doc)%4 {
+		doc = append(doc, ' ')
+	}
+	bin := triangleBuffer()
+
+	var glb bytes.Buffer
+	put := func(v uint32) { binary.Write(&glb, binary.LittleEndian, v) }
+	put(glbMagic)
+	put(2)
+	put(uint32(12 + 8 + len(doc) + 8 + len(bin)))
+	put(uint32(len(doc)))
+	put(glbChunkJSON)
+	glb.Write(doc)
+	put(uint32(len(bin)))
+	put(glbChunkBIN)
+	glb.Write(bin)
+
+	d := &Decoder{ReadFile: memFiles(map[string][]byte{"assets/triangle.glb": glb.Bytes()})}
+	model, err := d.DecodeFile("assets/triangle.glb")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkTriangle(t, model)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := map[string]string{
+		"not json":      "solid cube",
+		"version":       `{"asset": {"version": "1.0"}}`,
+		"extension":     `{"asset": {"version": "2.0"}, "extensionsRequired": ["KHR_draco_mesh_compression"]}`,
+		"short buffer":  `{"asset": {"version": "2.0"}, "buffers": [{"uri": "data:application/octet-stream;base64,AAAA", "byteLength": 8}]}`,
+		"bad accessor":  `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 4}}]}]}`,
+		"no position":   `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {}}]}]}`,
+		"node cycle":    `{"asset": {"version": "2.0"}, "nodes": [{"children": [1]}, {"children": [0]}]}`,
+		"shared child":  `{"asset": {"version": "2.0"}, "nodes": [{"children": [2]}, {"children": [2]}, {}]}`,
+		"missing scene": `{"asset": {"version": "2.0"}, "scene": 1, "scenes": [{}]}`,
+		"negative count": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+			"accessors": [{"componentType": 5126, "count": -1, "type": "VEC3"}]}`,
+		"huge count": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+			"accessors": [{"componentType": 5126, "count": 4000000000, "type": "VEC3"}]}`,
+		"negative index count": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}],
+			"accessors": [{"componentType": 5126, "count": 3, "type": "VEC3"},
+				{"bufferView": 0, "componentType": 5123, "count": -2, "type": "SCALAR"}],
+			"bufferViews": [{"buffer": 0, "byteLength": 4}],
+			"buffers": [{"uri": "data:application/octet-stream;base64,AAAAAA==", "byteLength": 4}]}`,
+		"normal count": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0, "NORMAL": 1}}]}],
+			"accessors": [{"componentType": 5126, "count": 3, "type": "VEC3"},
+				{"componentType": 5126, "count": 5, "type": "VEC3"}]}`,
+		"negative stride": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+			"accessors": [{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3"}],
+			"bufferViews": [{"buffer": 0, "byteLength": 16, "byteStride": -4}],
+			"buffers": [{"uri": "data:application/octet-stream;base64,AAAAAAAAAAAAAAAAAAAAAA==", "byteLength": 16}]}`,
+		"short stride": `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+			"accessors": [{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3"}],
+			"bufferViews": [{"buffer": 0, "byteLength": 16, "byteStride": 4}],
+			"buffers": [{"uri": "data:application/octet-stream;base64,AAAAAAAAAAAAAAAAAAAAAA==", "byteLength": 16}]}`,
+	}
+	for name, src := range cases {
+		if _, err := Decode(strings.NewReader(src)); nil == err {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestStripToList(t *testing.T) {
+	got := stripToList([]uint32{0, 1, 2, 3, 4})
+	want := []uint32{0, 1, 2, 2, 1, 3, 2, 3, 4}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("strip gives %v, want %v", got, want)
+	}
+	got = fanToList([]uint32{0, 1, 2, 3})
+	want = []uint32{0, 1, 2, 0, 2, 3}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("fan gives %v, want %v", got, want)
+	}
+}
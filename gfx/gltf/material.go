@@ -0,0 +1,190 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Material is a PBR metallic-roughness material. Texture references are nil
+// when the material does not use the texture.
+type Material struct {
+	Name string
+
+	BaseColorFactor          mgl32.Vec4
+	BaseColorTexture         *TextureRef
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture *TextureRef // roughness in G, metalness in B
+
+	NormalTexture    *TextureRef // Scale is the normal scale
+	OcclusionTexture *TextureRef // Scale is the occlusion strength
+	EmissiveTexture  *TextureRef
+	EmissiveFactor   mgl32.Vec3
+
+	AlphaMode   string // "OPAQUE", "MASK" or "BLEND"
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// DefaultMaterial is what primitives without a material are drawn with.
+var DefaultMaterial = &Material{
+	Name:            "default",
+	BaseColorFactor: mgl32.Vec4{1, 1, 1, 1},
+	MetallicFactor:  1,
+	RoughnessFactor: 1,
+	AlphaMode:       "OPAQUE",
+	AlphaCutoff:     0.5,
+}
+
+// TextureRef is a material's use of a texture.
+type TextureRef struct {
+	Texture  *Texture
+	TexCoord int // which TEXCOORD_n set, only 0 is loaded
+	Scale    float32
+}
+
+// Texture pairs an image with how it is sampled.
+type Texture struct {
+	Image   *Image
+	Sampler Sampler
+}
+
+// Sampler holds GL filter and wrap enums, glTF uses the same numbers. Zero
+// filters mean the file left the choice to the renderer.
+type Sampler struct {
+	MagFilter, MinFilter int32
+	WrapS, WrapT         int32
+}
+
+const glRepeat = 10497
+
+// Image is a texture image. Images inside the file, as a data URI or in a
+// buffer view, carry their encoded bytes in Data; external images only have
+// their path in File, ready for gfx.NewTexture.
+type Image struct {
+	Name     string
+	File     string
+	MimeType string
+	Data     []byte
+}
+
+func (d *decoder) loadImages() error {
+	for i, img := range d.doc.Images {
+		out := &Image{Name: img.Name, MimeType: img.MimeType}
+		switch {
+		case nil != img.BufferView:
+			data, _, err := d.bufferView(*img.BufferView, 0)
+			if nil != err {
+				return fmt.Errorf("gltf: image %d: %v", i, err)
+			}
+			out.Data = data
+		case "" == img.URI:
+			return fmt.Errorf("gltf: image %d has neither uri nor bufferView", i)
+		case isDataURI(img.URI):
+			data, err := d.load(img.URI)
+			if nil != err {
+				return fmt.Errorf("gltf: image %d: %v", i, err)
+			}
+			out.Data = data
+		default:
+			out.File = d.path(img.URI)
+		}
+		d.model.Images = append(d.model.Images, out)
+	}
+	return nil
+}
+
+func (d *decoder) loadTextures() error {
+	for i, t := range d.doc.Textures {
+		out := &Texture{Sampler: Sampler{WrapS: glRepeat, WrapT: glRepeat}}
+		if nil != t.Source {
+			if err := index("image", *t.Source, len(d.model.Images)); nil != err {
+				return fmt.Errorf("gltf: texture %d: %v", i, err)
+			}
+			out.Image = d.model.Images[*t.Source]
+		}
+		if nil != t.Sampler {
+			if err := index("sampler", *t.Sampler, len(d.doc.Samplers)); nil != err {
+				return fmt.Errorf("gltf: texture %d: %v", i, err)
+			}
+			s := d.doc.Samplers[*t.Sampler]
+			out.Sampler.MagFilter, out.Sampler.MinFilter = s.MagFilter, s.MinFilter
+			if 0 != s.WrapS {
+				out.Sampler.WrapS = s.WrapS
+			}
+			if 0 != s.WrapT {
+				out.Sampler.WrapT = s.WrapT
+			}
+		}
+		d.model.Textures = append(d.model.Textures, out)
+	}
+	return nil
+}
+
+func (d *decoder) textureRef(info *docTextureInfo, scale *float32) (*TextureRef, error) {
+	if nil == info {
+		return nil, nil
+	}
+	if err := index("texture", info.Index, len(d.model.Textures)); nil != err {
+		return nil, err
+	}
+	ref := &TextureRef{Texture: d.model.Textures[info.Index], TexCoord: info.TexCoord, Scale: 1}
+	if nil != scale {
+		ref.Scale = *scale
+	}
+	return ref, nil
+}
+
+func (d *decoder) loadMaterials() error {
+	for i, m := range d.doc.Materials {
+		out := *DefaultMaterial
+		out.Name = m.Name
+		out.EmissiveFactor = m.EmissiveFactor
+		out.DoubleSided = m.DoubleSided
+		if "" != m.AlphaMode {
+			out.AlphaMode = m.AlphaMode
+		}
+		if nil != m.AlphaCutoff {
+			out.AlphaCutoff = *m.AlphaCutoff
+		}
+
+		var err error
+		if pbr := m.PBRMetallicRoughness; nil != pbr {
+			if nil != pbr.BaseColorFactor {
+				out.BaseColorFactor = *pbr.BaseColorFactor
+			}
+			if nil != pbr.MetallicFactor {
+				out.MetallicFactor = *pbr.MetallicFactor
+			}
+			if nil != pbr.RoughnessFactor {
+				out.RoughnessFactor = *pbr.RoughnessFactor
+			}
+			if out.BaseColorTexture, err = d.textureRef(pbr.BaseColorTexture, nil); nil != err {
+				return fmt.Errorf("gltf: material %d: %v", i, err)
+			}
+			if out.MetallicRoughnessTexture, err = d.textureRef(pbr.MetallicRoughnessTexture, nil); nil != err {
+				return fmt.Errorf("gltf: material %d: %v", i, err)
+			}
+		}
+		if nil != m.NormalTexture {
+			if out.NormalTexture, err = d.textureRef(m.NormalTexture, m.NormalTexture.Scale); nil != err {
+				return fmt.Errorf("gltf: material %d: %v", i, err)
+			}
+		}
+		if nil != m.OcclusionTexture {
+			if out.OcclusionTexture, err = d.textureRef(m.OcclusionTexture, m.OcclusionTexture.Strength); nil != err {
+				return fmt.Errorf("gltf: material %d: %v", i, err)
+			}
+		}
+		if out.EmissiveTexture, err = d.textureRef(m.EmissiveTexture, nil); nil != err {
+			return fmt.Errorf("gltf: material %d: %v", i, err)
+		}
+		d.model.Materials = append(d.model.Materials, &out)
+	}
+	return nil
+}
+
+func isDataURI(uri string) bool {
+	return len(uri) > 5 && "data:" == uri[:5]
+}
@@ -0,0 +1,181 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mesh is a glTF mesh, a list of primitives drawn with the same transform.
+type Mesh struct {
+	Name       string
+	Primitives []*Primitive
+}
+
+// Primitive is a triangle list with one material. Strips and fans are
+// converted to lists; points and lines are dropped.
+//
+// TEXCOORD_0 is kept as is, glTF puts the origin at the top left of the
+// image which is where it lands when images are uploaded without flipping.
+type Primitive struct {
+	Mesh     *mesh.Mesh
+	Material *Material // DefaultMaterial when the file gives none
+}
+
+// Primitive modes.
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+func (d *decoder) loadMeshes() error {
+	for i, m := range d.doc.Meshes {
+		out := &Mesh{Name: m.Name}
+		for j, p := range m.Primitives {
+			prim, err := d.primitive(p)
+			if nil != err {
+				return fmt.Errorf("gltf: mesh %d primitive %d: %v", i, j, err)
+			}
+			if nil != prim {
+				out.Primitives = append(out.Primitives, prim)
+			}
+		}
+		d.model.Meshes = append(d.model.Meshes, out)
+	}
+	return nil
+}
+
+func (d *decoder) primitive(p docPrimitive) (*Primitive, error) {
+	mode := modeTriangles
+	if nil != p.Mode {
+		mode = *p.Mode
+	}
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		return nil, nil
+	}
+
+	position, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, fmt.Errorf("no POSITION attribute")
+	}
+	m := &mesh.Mesh{}
+	err := d.vectors(position, 3, -1, func(i int, v []float32) {
+		m.Positions = append(m.Positions, mgl32.Vec3{v[0], v[1], v[2]})
+	})
+	if nil != err {
+		return nil, err
+	}
+	n := len(m.Positions)
+
+	if a, ok := p.Attributes["NORMAL"]; ok {
+		m.Normals = make([]mgl32.Vec3, n)
+		if err := d.vectors(a, 3, n, func(i int, v []float32) {
+			m.Normals[i] = mgl32.Vec3{v[0], v[1], v[2]}
+		}); nil != err {
+			return nil, err
+		}
+	}
+	if a, ok := p.Attributes["TEXCOORD_0"]; ok {
+		m.UVs = make([]mgl32.Vec2, n)
+		if err := d.vectors(a, 2, n, func(i int, v []float32) {
+			m.UVs[i] = mgl32.Vec2{v[0], v[1]}
+		}); nil != err {
+			return nil, err
+		}
+	}
+	if a, ok := p.Attributes["TANGENT"]; ok {
+		m.Tangents = make([]mgl32.Vec4, n)
+		if err := d.vectors(a, 4, n, func(i int, v []float32) {
+			m.Tangents[i] = mgl32.Vec4{v[0], v[1], v[2], v[3]}
+		}); nil != err {
+			return nil, err
+		}
+	}
+	if a, ok := p.Attributes["COLOR_0"]; ok {
+		m.Colors = make([]mgl32.Vec4, n)
+		if err := d.vectors(a, 0, n, func(i int, v []float32) {
+			c := mgl32.Vec4{1, 1, 1, 1}
+			copy(c[:], v)
+			m.Colors[i] = c
+		}); nil != err {
+			return nil, err
+		}
+	}
+
+	var indices []uint32
+	if nil != p.Indices {
+		if indices, err = d.indices(*p.Indices); nil != err {
+			return nil, err
+		}
+	} else {
+		indices = make([]uint32, n)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	switch mode {
+	case modeTriangleStrip:
+		indices = stripToList(indices)
+	case modeTriangleFan:
+		indices = fanToList(indices)
+	default:
+		indices = indices[:len(indices)/3*3]
+	}
+	m.Indices = indices
+	if err := m.Validate(); nil != err {
+		return nil, err
+	}
+
+	prim := &Primitive{Mesh: m, Material: DefaultMaterial}
+	if nil != p.Material {
+		if err := index("material", *p.Material, len(d.model.Materials)); nil != err {
+			return nil, err
+		}
+		prim.Material = d.model.Materials[*p.Material]
+	}
+	return prim, nil
+}
+
+// vectors reads accessor a and calls fn for every element. comps is the
+// component count the attribute must have, 0 for any, and count the number
+// of elements, one per position, or -1 for any.
+func (d *decoder) vectors(a, comps, count int, fn func(i int, v []float32)) error {
+	data, have, err := d.accessor(a)
+	if nil != err {
+		return err
+	}
+	if 0 != comps && have != comps {
+		return fmt.Errorf("accessor %d has %d components, want %d", a, have, comps)
+	}
+	if count >= 0 && len(data)/have != count {
+		return fmt.Errorf("accessor %d has %d elements for %d positions", a, len(data)/have, count)
+	}
+	for i := 0; i+have <= len(data); i += have {
+		fn(i/have, data[i:i+have])
+	}
+	return nil
+}
+
+// stripToList turns a triangle strip into a list, flipping every other
+// triangle so they all keep the winding of the first.
+func stripToList(strip []uint32) []uint32 {
+	var out []uint32
+	for i := 0; i+2 < len(strip); i++ {
+		if 0 == i%2 {
+			out = append(out, strip[i], strip[i+1], strip[i+2])
+		} else {
+			out = append(out, strip[i+1], strip[i], strip[i+2])
+		}
+	}
+	return out
+}
+
+func fanToList(fan []uint32) []uint32 {
+	var out []uint32
+	for i := 1; i+1 < len(fan); i++ {
+		out = append(out, fan[0], fan[i], fan[i+1])
+	}
+	return out
+}
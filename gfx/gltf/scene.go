@@ -0,0 +1,206 @@
+package gltf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Scene is a set of root nodes.
+type Scene struct {
+	Name  string
+	Nodes []*Node
+}
+
+// Node is a transform in the hierarchy, optionally carrying a mesh or a
+// camera.
+type Node struct {
+	Name     string
+	Parent   *Node
+	Children []*Node
+
+	// Matrix is the local transform when the file gave one as a matrix,
+	// otherwise it is nil and Translation, Rotation and Scale apply.
+	Matrix      *mgl32.Mat4
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+
+	Mesh   *Mesh
+	Camera *Camera
+}
+
+// LocalMatrix returns the transform from the node's space to its parent's.
+func (n *Node) LocalMatrix() mgl32.Mat4 {
+	if nil != n.Matrix {
+		return *n.Matrix
+	}
+	t := mgl32.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	s := mgl32.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2])
+	return t.Mul4(n.Rotation.Mat4()).Mul4(s)
+}
+
+// WorldMatrix returns the transform from the node's space to the scene's.
+func (n *Node) WorldMatrix() mgl32.Mat4 {
+	m := n.LocalMatrix()
+	for p := n.Parent; nil != p; p = p.Parent {
+		m = p.LocalMatrix().Mul4(m)
+	}
+	return m
+}
+
+// Walk calls fn for every node of s depth first, parents before children,
+// with the node's world matrix.
+func (s *Scene) Walk(fn func(n *Node, world mgl32.Mat4)) {
+	var walk func(n *Node, parent mgl32.Mat4)
+	walk = func(n *Node, parent mgl32.Mat4) {
+		world := parent.Mul4(n.LocalMatrix())
+		fn(n, world)
+		for _, c := range n.Children {
+			walk(c, world)
+		}
+	}
+	for _, n := range s.Nodes {
+		walk(n, mgl32.Ident4())
+	}
+}
+
+// Camera is a perspective or orthographic camera. It looks down its node's
+// -Z axis, so its view matrix is the inverse of the node's world matrix.
+type Camera struct {
+	Name         string
+	Orthographic bool
+
+	YFov        float32 // radians, perspective only
+	AspectRatio float32 // 0 to follow the viewport, perspective only
+	XMag, YMag  float32 // half extents, orthographic only
+	ZNear, ZFar float32 // ZFar 0 is an infinite perspective
+}
+
+// Projection returns the projection matrix for a viewport of the given
+// aspect ratio, which only matters when the camera does not fix one.
+func (c *Camera) Projection(aspect float32) mgl32.Mat4 {
+	if c.Orthographic {
+		return mgl32.Ortho(-c.XMag, c.XMag, -c.YMag, c.YMag, c.ZNear, c.ZFar)
+	}
+	if 0 != c.AspectRatio {
+		aspect = c.AspectRatio
+	}
+	if 0 != c.ZFar {
+		return mgl32.Perspective(c.YFov, aspect, c.ZNear, c.ZFar)
+	}
+	f := float32(1 / math.Tan(float64(c.YFov)/2))
+	return mgl32.Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -2 * c.ZNear, 0,
+	}
+}
+
+func (d *decoder) loadCameras() error {
+	for i, c := range d.doc.Cameras {
+		out := &Camera{Name: c.Name}
+		switch {
+		case "perspective" == c.Type && nil != c.Perspective:
+			p := c.Perspective
+			out.YFov, out.AspectRatio, out.ZNear, out.ZFar = p.YFov, p.AspectRatio, p.ZNear, p.ZFar
+		case "orthographic" == c.Type && nil != c.Orthographic:
+			o := c.Orthographic
+			out.Orthographic = true
+			out.XMag, out.YMag, out.ZNear, out.ZFar = o.XMag, o.YMag, o.ZNear, o.ZFar
+		default:
+			return fmt.Errorf("gltf: camera %d: bad type %q", i, c.Type)
+		}
+		d.model.Cameras = append(d.model.Cameras, out)
+	}
+	return nil
+}
+
+func (d *decoder) loadNodes() error {
+	nodes := make([]*Node, len(d.doc.Nodes))
+	for i := range nodes {
+		nodes[i] = &Node{}
+	}
+	for i, n := range d.doc.Nodes {
+		out := nodes[i]
+		out.Name = n.Name
+		out.Rotation = mgl32.QuatIdent()
+		out.Scale = mgl32.Vec3{1, 1, 1}
+		if nil != n.Matrix {
+			m := mgl32.Mat4(*n.Matrix)
+			out.Matrix = &m
+		}
+		if nil != n.Translation {
+			out.Translation = *n.Translation
+		}
+		if nil != n.Rotation {
+			r := *n.Rotation
+			out.Rotation = mgl32.Quat{W: r[3], V: mgl32.Vec3{r[0], r[1], r[2]}}
+		}
+		if nil != n.Scale {
+			out.Scale = *n.Scale
+		}
+		if nil != n.Mesh {
+			if err := index("mesh", *n.Mesh, len(d.model.Meshes)); nil != err {
+				return fmt.Errorf("gltf: node %d: %v", i, err)
+			}
+			out.Mesh = d.model.Meshes[*n.Mesh]
+		}
+		if nil != n.Camera {
+			if err := index("camera", *n.Camera, len(d.model.Cameras)); nil != err {
+				return fmt.Errorf("gltf: node %d: %v", i, err)
+			}
+			out.Camera = d.model.Cameras[*n.Camera]
+		}
+		for _, c := range n.Children {
+			if err := index("node", c, len(nodes)); nil != err {
+				return fmt.Errorf("gltf: node %d: %v", i, err)
+			}
+			child := nodes[c]
+			if nil != child.Parent {
+				return fmt.Errorf("gltf: node %d: child %d already has a parent", i, c)
+			}
+			child.Parent = out
+			out.Children = append(out.Children, child)
+		}
+	}
+	// a parent chain that loops never reaches a root
+	for i, n := range nodes {
+		steps := 0
+		for p := n.Parent; nil != p; p = p.Parent {
+			if steps++; steps > len(nodes) {
+				return fmt.Errorf("gltf: node %d is part of a cycle", i)
+			}
+		}
+	}
+	d.model.Nodes = nodes
+	return nil
+}
+
+func (d *decoder) loadScenes() error {
+	for i, s := range d.doc.Scenes {
+		out := &Scene{Name: s.Name}
+		for _, n := range s.Nodes {
+			if err := index("node", n, len(d.model.Nodes)); nil != err {
+				return fmt.Errorf("gltf: scene %d: %v", i, err)
+			}
+			root := d.model.Nodes[n]
+			if nil != root.Parent {
+				return fmt.Errorf("gltf: scene %d: node %d is not a root", i, n)
+			}
+			out.Nodes = append(out.Nodes, root)
+		}
+		d.model.Scenes = append(d.model.Scenes, out)
+	}
+	if nil != d.doc.Scene {
+		if err := index("scene", *d.doc.Scene, len(d.model.Scenes)); nil != err {
+			return err
+		}
+		d.model.Scene = d.model.Scenes[*d.doc.Scene]
+	} else if len(d.model.Scenes) > 0 {
+		d.model.Scene = d.model.Scenes[0]
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package gfx
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/gputex"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// isGPUTextureFile reports whether file is a container NewGPUTexture reads,
+// going by its extension.
+func isGPUTextureFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".ktx", ".ktx2", ".dds":
+		return true
+	}
+	return false
+}
+
+// NewGPUTexture loads a KTX, KTX2 or DDS file and uploads it with all its mip
+// levels, array layers and cube faces. It returns the texture and the target
+// it is bound to: TEXTURE_2D, TEXTURE_2D_ARRAY, TEXTURE_3D or
+// TEXTURE_CUBE_MAP.
+//
+// Block compressed data cannot be flipped, so opts.FlipV is ignored; these
+// containers are expected to be authored with OpenGL's orientation.
+func NewGPUTexture(file string, opts TextureOptions) (uint32, uint32, error) {
+	t, err := gputex.DecodeFile(file)
+	if nil != err {
+		return 0, 0, err
+	}
+	texture, target, err := UploadGPUTexture(t, opts)
+	if nil != err {
+		return 0, 0, fmt.Errorf("%s: %v", file, err)
+	}
+	return texture, target, nil
+}
+
+// UploadGPUTexture uploads a decoded container, see NewGPUTexture.
+func UploadGPUTexture(t *gputex.Texture, opts TextureOptions) (uint32, uint32, error) {
+	target, err := gpuTextureTarget(t)
+	if nil != err {
+		return 0, 0, err
+	}
+	format := t.Format
+	if opts.SRGB {
+		format = format.SRGB()
+	}
+	if t.Levels > 1 {
+		// the file brings its own mip chain
+		opts.GenerateMipmaps = false
+		if 0 == opts.MinFilter {
+			opts.MinFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	} else if format.Compressed {
+		opts.GenerateMipmaps = false
+	}
+	opts = opts.withDefaults()
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(target, texture)
+
+	// the decoder hands out tightly packed rows
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	defer gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	for level := 0; level < t.Levels; level++ {
+		w, h, d := t.LevelSize(level)
+		switch target {
+		case gl.TEXTURE_2D:
+			uploadImage2D(gl.TEXTURE_2D, level, format, w, h, t.Image(level, 0, 0))
+		case gl.TEXTURE_CUBE_MAP:
+			for face := 0; face < 6; face++ {
+				uploadImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), level, format, w, h, t.Image(level, 0, face))
+			}
+		case gl.TEXTURE_2D_ARRAY:
+			var data []byte
+			for layer := 0; layer < t.Layers; layer++ {
+				data = append(data, t.Image(level, layer, 0)...)
+			}
+			uploadImage3D(target, level, format, w, h, t.Layers, data)
+		case gl.TEXTURE_3D:
+			uploadImage3D(target, level, format, w, h, d, t.Image(level, 0, 0))
+		}
+	}
+	gl.TexParameteri(target, gl.TEXTURE_MAX_LEVEL, int32(t.Levels-1))
+	opts.apply(target)
+
+	if code := gl.GetError(); gl.NO_ERROR != code {
+		gl.DeleteTextures(1, &texture)
+		return 0, 0, fmt.Errorf("uploading %s texture failed with GL error 0x%x, is the format supported by the driver?", format, code)
+	}
+	return texture, target, nil
+}
+
+func gpuTextureTarget(t *gputex.Texture) (uint32, error) {
+	switch {
+	case t.IsCubemap() && t.IsArray():
+		return 0, errors.New("cubemap arrays need OpenGL 4.0")
+	case t.IsCubemap():
+		return gl.TEXTURE_CUBE_MAP, nil
+	case t.IsArray() && t.Depth > 1:
+		return 0, errors.New("arrays of 3D textures do not exist")
+	case t.IsArray():
+		return gl.TEXTURE_2D_ARRAY, nil
+	case t.Depth > 1:
+		return gl.TEXTURE_3D, nil
+	}
+	return gl.TEXTURE_2D, nil
+}
+
+func uploadImage2D(target uint32, level int, f gputex.Format, width, height int, data []byte) {
+	if f.Compressed {
+		gl.CompressedTexImage2D(target, int32(level), f.InternalFormat, int32(width), int32(height), 0, int32(len(data)), gl.Ptr(data))
+		return
+	}
+	gl.TexImage2D(target, int32(level), int32(f.InternalFormat), int32(width), int32(height), 0, f.PixelFormat, f.PixelType, gl.Ptr(data))
+}
+
+func uploadImage3D(target uint32, level int, f gputex.Format, width, height, depth int, data []byte) {
+	if f.Compressed {
+		gl.CompressedTexImage3D(target, int32(level), f.InternalFormat, int32(width), int32(height), int32(depth), 0, int32(len(data)), gl.Ptr(data))
+		return
+	}
+	gl.TexImage3D(target, int32(level), int32(f.InternalFormat), int32(width), int32(height), int32(depth), 0, f.PixelFormat, f.PixelType, gl.Ptr(data))
+}
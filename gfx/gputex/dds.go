@@ -0,0 +1,238 @@
+package gputex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const ddsMagic = "DDS "
+
+type ddsPixelFormat struct {
+	Size        uint32
+	Flags       uint32
+	FourCC      [4]byte
+	RGBBitCount uint32
+	RBitMask    uint32
+	GBitMask    uint32
+	BBitMask    uint32
+	ABitMask    uint32
+}
+
+type ddsHeader struct {
+	Size              uint32
+	Flags             uint32
+	Height            uint32
+	Width             uint32
+	PitchOrLinearSize uint32
+	Depth             uint32
+	MipMapCount       uint32
+	Reserved1         [11]uint32
+	PixelFormat       ddsPixelFormat
+	Caps              uint32
+	Caps2             uint32
+	Caps3             uint32
+	Caps4             uint32
+	Reserved2         uint32
+}
+
+type ddsHeaderDX10 struct {
+	DXGIFormat        uint32
+	ResourceDimension uint32
+	MiscFlag          uint32
+	ArraySize         uint32
+	MiscFlags2        uint32
+}
+
+const (
+	ddsdMipMapCount = 0x20000
+	ddsdDepth       = 0x800000
+
+	ddpfAlphaPixels = 0x1
+	ddpfFourCC      = 0x4
+	ddpfRGB         = 0x40
+	ddpfLuminance   = 0x20000
+
+	ddscaps2Cubemap = 0x200
+	ddscaps2Volume  = 0x200000
+
+	dx10MiscTextureCube = 0x4
+	dx10Texture3D       = 4
+)
+
+// dxgiFormats maps the DXGI_FORMAT values of DX10 headers to GL internal
+// formats; B8G8R8A8 variants are handled separately.
+var dxgiFormats = map[uint32]uint32{
+	2:  glRGBA32F,
+	6:  glRGB32F,
+	10: glRGBA16F,
+	16: glRG32F,
+	28: glRGBA8,
+	29: glSRGB8Alpha8,
+	34: glRG16F,
+	41: glR32F,
+	49: glRG8,
+	54: glR16F,
+	61: glR8,
+	71: glCompressedRGBAS3TCDXT1,
+	72: glCompressedSRGBAlphaS3TCDXT1,
+	74: glCompressedRGBAS3TCDXT3,
+	75: glCompressedSRGBAlphaS3TCDXT3,
+	77: glCompressedRGBAS3TCDXT5,
+	78: glCompressedSRGBAlphaS3TCDXT5,
+	80: glCompressedRedRGTC1,
+	81: glCompressedSignedRedRGTC1,
+	83: glCompressedRGRGTC2,
+	84: glCompressedSignedRGRGTC2,
+	95: glCompressedRGBBPTCUnsignedFloat,
+	96: glCompressedRGBBPTCSignedFloat,
+	98: glCompressedRGBABPTCUnorm,
+	99: glCompressedSRGBAlphaBPTCUnorm,
+}
+
+var dxgiBGRAFormats = map[uint32]Format{
+	87: bgraFormats[glRGBA8],
+	91: bgraFormats[glSRGB8Alpha8],
+}
+
+// fourCCFormats covers the legacy headers written without a DX10 extension.
+var fourCCFormats = map[string]uint32{
+	"DXT1": glCompressedRGBAS3TCDXT1,
+	"DXT2": glCompressedRGBAS3TCDXT3,
+	"DXT3": glCompressedRGBAS3TCDXT3,
+	"DXT4": glCompressedRGBAS3TCDXT5,
+	"DXT5": glCompressedRGBAS3TCDXT5,
+	"ATI1": glCompressedRedRGTC1,
+	"BC4U": glCompressedRedRGTC1,
+	"BC4S": glCompressedSignedRedRGTC1,
+	"ATI2": glCompressedRGRGTC2,
+	"BC5U": glCompressedRGRGTC2,
+	"BC5S": glCompressedSignedRGRGTC2,
+	// D3DFMT values stored in the FourCC field
+	"o\x00\x00\x00": glR16F,    // 111 R16F
+	"p\x00\x00\x00": glRG16F,   // 112 G16R16F
+	"q\x00\x00\x00": glRGBA16F, // 113 A16B16G16R16F
+	"r\x00\x00\x00": glR32F,    // 114 R32F
+	"s\x00\x00\x00": glRG32F,   // 115 G32R32F
+	"t\x00\x00\x00": glRGBA32F, // 116 A32B32G32R32F
+}
+
+// DecodeDDS reads a DirectDraw Surface, with or without the DX10 header
+// extension. Cubemaps must contain all six faces.
+func DecodeDDS(r io.Reader) (*Texture, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); nil != err {
+		return nil, fmt.Errorf("dds: %v", err)
+	}
+	if ddsMagic != string(magic[:]) {
+		return nil, errors.New("dds: bad magic")
+	}
+	var h ddsHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); nil != err {
+		return nil, fmt.Errorf("dds: header: %v", err)
+	}
+	if 124 != h.Size || 32 != h.PixelFormat.Size {
+		return nil, errors.New("dds: bad header size")
+	}
+
+	t := &Texture{
+		Width:  int(h.Width),
+		Height: int(h.Height),
+		Depth:  1,
+		Levels: 1,
+		Faces:  1,
+	}
+	if 0 != h.Flags&ddsdMipMapCount && h.MipMapCount > 0 {
+		t.Levels = int(h.MipMapCount)
+	}
+	if 0 != h.Flags&ddsdDepth && 0 != h.Caps2&ddscaps2Volume {
+		t.Depth = atLeastOne(h.Depth)
+	}
+	if 0 != h.Caps2&ddscaps2Cubemap {
+		if 0xFC00 != h.Caps2&0xFC00 {
+			return nil, errors.New("dds: cubemaps with missing faces are not supported")
+		}
+		t.Faces = 6
+	}
+
+	var err error
+	if 0 != h.PixelFormat.Flags&ddpfFourCC && "DX10" == string(h.PixelFormat.FourCC[:]) {
+		var dx10 ddsHeaderDX10
+		if err := binary.Read(r, binary.LittleEndian, &dx10); nil != err {
+			return nil, fmt.Errorf("dds: dx10 header: %v", err)
+		}
+		if t.Format, err = dxgiFormat(dx10.DXGIFormat); nil != err {
+			return nil, err
+		}
+		if 0 != dx10.MiscFlag&dx10MiscTextureCube {
+			t.Faces = 6
+		}
+		if dx10Texture3D == dx10.ResourceDimension {
+			t.Depth = atLeastOne(h.Depth)
+		}
+		if dx10.ArraySize > 1 {
+			t.Layers = int(dx10.ArraySize)
+		}
+	} else if t.Format, err = legacyDDSFormat(h.PixelFormat); nil != err {
+		return nil, err
+	}
+	if err := t.validate(); nil != err {
+		return nil, fmt.Errorf("dds: %v", err)
+	}
+
+	// DDS stores every mip chain of a layer/face before the next one
+	images := t.layerCount() * t.Faces
+	t.Images = make([][][]byte, t.Levels)
+	for level := range t.Images {
+		t.Images[level] = make([][]byte, images)
+	}
+	for i := 0; i < images; i++ {
+		for level := 0; level < t.Levels; level++ {
+			w, hh, d := t.LevelSize(level)
+			data, err := readFull(r, t.Format.ImageSize(w, hh, d))
+			if nil != err {
+				return nil, fmt.Errorf("dds: image %d level %d: %v", i, level, err)
+			}
+			t.Images[level][i] = data
+		}
+	}
+	return t, nil
+}
+
+func dxgiFormat(dxgi uint32) (Format, error) {
+	if f, ok := dxgiBGRAFormats[dxgi]; ok {
+		return f, nil
+	}
+	if internal, ok := dxgiFormats[dxgi]; ok {
+		return formats[internal], nil
+	}
+	return Format{}, fmt.Errorf("dds: unsupported DXGI format %d", dxgi)
+}
+
+func legacyDDSFormat(pf ddsPixelFormat) (Format, error) {
+	if 0 != pf.Flags&ddpfFourCC {
+		if internal, ok := fourCCFormats[string(pf.FourCC[:])]; ok {
+			return formats[internal], nil
+		}
+		return Format{}, fmt.Errorf("dds: unsupported FourCC %q", pf.FourCC[:])
+	}
+
+	hasAlpha := 0 != pf.Flags&ddpfAlphaPixels
+	switch {
+	case 0 != pf.Flags&ddpfRGB && 32 == pf.RGBBitCount && 0x000000ff == pf.RBitMask:
+		return formats[glRGBA8], nil
+	case 0 != pf.Flags&ddpfRGB && 32 == pf.RGBBitCount && 0x00ff0000 == pf.RBitMask:
+		// BGRX data still has 4 bytes per pixel, the alpha is just unused
+		return bgraFormats[glRGBA8], nil
+	case 0 != pf.Flags&ddpfRGB && 24 == pf.RGBBitCount && 0x000000ff == pf.RBitMask && !hasAlpha:
+		return formats[glRGB8], nil
+	case 0 != pf.Flags&ddpfRGB && 24 == pf.RGBBitCount && 0x00ff0000 == pf.RBitMask && !hasAlpha:
+		return bgraFormats[glRGB8], nil
+	case 0 != pf.Flags&ddpfLuminance && 8 == pf.RGBBitCount:
+		return formats[glR8], nil
+	case 0 != pf.Flags&ddpfLuminance && 16 == pf.RGBBitCount && hasAlpha:
+		return formats[glRG8], nil
+	}
+	return Format{}, fmt.Errorf("dds: unsupported pixel format (flags 0x%x, %d bits)", pf.Flags, pf.RGBBitCount)
+}
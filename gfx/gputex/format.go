@@ -0,0 +1,197 @@
+package gputex
+
+import "fmt"
+
+// OpenGL enums, spelled out so the package does not need a GL binding.
+const (
+	glUnsignedByte = 0x1401
+	glHalfFloat    = 0x140B
+	glFloat        = 0x1406
+
+	glRed  = 0x1903
+	glRG   = 0x8227
+	glRGB  = 0x1907
+	glRGBA = 0x1908
+	glBGR  = 0x80E0
+	glBGRA = 0x80E1
+
+	glR8          = 0x8229
+	glRG8         = 0x822B
+	glRGB8        = 0x8051
+	glRGBA8       = 0x8058
+	glSRGB8       = 0x8C41
+	glSRGB8Alpha8 = 0x8C43
+	glR16F        = 0x822D
+	glRG16F       = 0x822F
+	glRGB16F      = 0x881B
+	glRGBA16F     = 0x881A
+	glR32F        = 0x822E
+	glRG32F       = 0x8230
+	glRGB32F      = 0x8815
+	glRGBA32F     = 0x8814
+
+	glCompressedRGBS3TCDXT1       = 0x83F0
+	glCompressedRGBAS3TCDXT1      = 0x83F1
+	glCompressedRGBAS3TCDXT3      = 0x83F2
+	glCompressedRGBAS3TCDXT5      = 0x83F3
+	glCompressedSRGBS3TCDXT1      = 0x8C4C
+	glCompressedSRGBAlphaS3TCDXT1 = 0x8C4D
+	glCompressedSRGBAlphaS3TCDXT3 = 0x8C4E
+	glCompressedSRGBAlphaS3TCDXT5 = 0x8C4F
+
+	glCompressedRedRGTC1       = 0x8DBB
+	glCompressedSignedRedRGTC1 = 0x8DBC
+	glCompressedRGRGTC2        = 0x8DBD
+	glCompressedSignedRGRGTC2  = 0x8DBE
+
+	glCompressedRGBABPTCUnorm        = 0x8E8C
+	glCompressedSRGBAlphaBPTCUnorm   = 0x8E8D
+	glCompressedRGBBPTCSignedFloat   = 0x8E8E
+	glCompressedRGBBPTCUnsignedFloat = 0x8E8F
+
+	glETC1RGB8                        = 0x8D64
+	glCompressedR11EAC                = 0x9270
+	glCompressedSignedR11EAC          = 0x9271
+	glCompressedRG11EAC               = 0x9272
+	glCompressedSignedRG11EAC         = 0x9273
+	glCompressedRGB8ETC2              = 0x9274
+	glCompressedSRGB8ETC2             = 0x9275
+	glCompressedRGB8PunchthroughETC2  = 0x9276
+	glCompressedSRGB8PunchthroughETC2 = 0x9277
+	glCompressedRGBA8ETC2EAC          = 0x9278
+	glCompressedSRGB8Alpha8ETC2EAC    = 0x9279
+)
+
+// Format describes how texels are stored and how OpenGL should be told about
+// them. Compressed formats are stored in BlockWidth x BlockHeight blocks of
+// BlockBytes each; uncompressed ones use 1x1 "blocks" of one pixel.
+type Format struct {
+	Name       string
+	Compressed bool
+
+	BlockWidth, BlockHeight int
+	BlockBytes              int
+
+	InternalFormat uint32 // argument to glTexImage*/glCompressedTexImage*
+	PixelFormat    uint32 // uncompressed only, e.g. GL_RGBA
+	PixelType      uint32 // uncompressed only, e.g. GL_UNSIGNED_BYTE
+}
+
+func (f Format) String() string {
+	return f.Name
+}
+
+// ImageSize returns the byte size of a tightly packed width x height x depth
+// image in this format.
+func (f Format) ImageSize(width, height, depth int) int {
+	bw := (width + f.BlockWidth - 1) / f.BlockWidth
+	bh := (height + f.BlockHeight - 1) / f.BlockHeight
+	return bw * bh * depth * f.BlockBytes
+}
+
+// SRGB returns the sRGB flavour of f, or f itself if there is none. BGRA
+// and BGR data keep their pixel format.
+func (f Format) SRGB() Format {
+	srgb, ok := srgbFormats[f.InternalFormat]
+	if !ok {
+		return f
+	}
+	if glBGRA == f.PixelFormat || glBGR == f.PixelFormat {
+		return bgraFormats[srgb]
+	}
+	return formats[srgb]
+}
+
+func compressed(name string, internalFormat uint32, blockBytes int) Format {
+	return Format{Name: name, Compressed: true, BlockWidth: 4, BlockHeight: 4, BlockBytes: blockBytes, InternalFormat: internalFormat}
+}
+
+func uncompressed(name string, internalFormat, pixelFormat, pixelType uint32, pixelBytes int) Format {
+	return Format{Name: name, BlockWidth: 1, BlockHeight: 1, BlockBytes: pixelBytes,
+		InternalFormat: internalFormat, PixelFormat: pixelFormat, PixelType: pixelType}
+}
+
+// formats is keyed by GL internal format. BGRA data uses the same internal
+// formats as RGBA and is listed separately in bgraFormats.
+var formats = map[uint32]Format{}
+
+var bgraFormats = map[uint32]Format{
+	glRGBA8:       uncompressed("BGRA8", glRGBA8, glBGRA, glUnsignedByte, 4),
+	glSRGB8Alpha8: uncompressed("SRGB8_ALPHA8 (BGRA)", glSRGB8Alpha8, glBGRA, glUnsignedByte, 4),
+	glRGB8:        uncompressed("BGR8", glRGB8, glBGR, glUnsignedByte, 3),
+	glSRGB8:       uncompressed("SRGB8 (BGR)", glSRGB8, glBGR, glUnsignedByte, 3),
+}
+
+var srgbFormats = map[uint32]uint32{
+	glRGB8:                           glSRGB8,
+	glRGBA8:                          glSRGB8Alpha8,
+	glCompressedRGBS3TCDXT1:          glCompressedSRGBS3TCDXT1,
+	glCompressedRGBAS3TCDXT1:         glCompressedSRGBAlphaS3TCDXT1,
+	glCompressedRGBAS3TCDXT3:         glCompressedSRGBAlphaS3TCDXT3,
+	glCompressedRGBAS3TCDXT5:         glCompressedSRGBAlphaS3TCDXT5,
+	glCompressedRGBABPTCUnorm:        glCompressedSRGBAlphaBPTCUnorm,
+	glCompressedRGB8ETC2:             glCompressedSRGB8ETC2,
+	glETC1RGB8:                       glCompressedSRGB8ETC2,
+	glCompressedRGB8PunchthroughETC2: glCompressedSRGB8PunchthroughETC2,
+	glCompressedRGBA8ETC2EAC:         glCompressedSRGB8Alpha8ETC2EAC,
+}
+
+func init() {
+	for _, f := range []Format{
+		uncompressed("R8", glR8, glRed, glUnsignedByte, 1),
+		uncompressed("RG8", glRG8, glRG, glUnsignedByte, 2),
+		uncompressed("RGB8", glRGB8, glRGB, glUnsignedByte, 3),
+		uncompressed("RGBA8", glRGBA8, glRGBA, glUnsignedByte, 4),
+		uncompressed("SRGB8", glSRGB8, glRGB, glUnsignedByte, 3),
+		uncompressed("SRGB8_ALPHA8", glSRGB8Alpha8, glRGBA, glUnsignedByte, 4),
+		uncompressed("R16F", glR16F, glRed, glHalfFloat, 2),
+		uncompressed("RG16F", glRG16F, glRG, glHalfFloat, 4),
+		uncompressed("RGB16F", glRGB16F, glRGB, glHalfFloat, 6),
+		uncompressed("RGBA16F", glRGBA16F, glRGBA, glHalfFloat, 8),
+		uncompressed("R32F", glR32F, glRed, glFloat, 4),
+		uncompressed("RG32F", glRG32F, glRG, glFloat, 8),
+		uncompressed("RGB32F", glRGB32F, glRGB, glFloat, 12),
+		uncompressed("RGBA32F", glRGBA32F, glRGBA, glFloat, 16),
+
+		compressed("BC1_RGB", glCompressedRGBS3TCDXT1, 8),
+		compressed("BC1_RGBA", glCompressedRGBAS3TCDXT1, 8),
+		compressed("BC2", glCompressedRGBAS3TCDXT3, 16),
+		compressed("BC3", glCompressedRGBAS3TCDXT5, 16),
+		compressed("BC1_RGB_SRGB", glCompressedSRGBS3TCDXT1, 8),
+		compressed("BC1_RGBA_SRGB", glCompressedSRGBAlphaS3TCDXT1, 8),
+		compressed("BC2_SRGB", glCompressedSRGBAlphaS3TCDXT3, 16),
+		compressed("BC3_SRGB", glCompressedSRGBAlphaS3TCDXT5, 16),
+		compressed("BC4_UNORM", glCompressedRedRGTC1, 8),
+		compressed("BC4_SNORM", glCompressedSignedRedRGTC1, 8),
+		compressed("BC5_UNORM", glCompressedRGRGTC2, 16),
+		compressed("BC5_SNORM", glCompressedSignedRGRGTC2, 16),
+		compressed("BC6H_SFLOAT", glCompressedRGBBPTCSignedFloat, 16),
+		compressed("BC6H_UFLOAT", glCompressedRGBBPTCUnsignedFloat, 16),
+		compressed("BC7", glCompressedRGBABPTCUnorm, 16),
+		compressed("BC7_SRGB", glCompressedSRGBAlphaBPTCUnorm, 16),
+
+		compressed("ETC2_RGB8", glCompressedRGB8ETC2, 8),
+		compressed("ETC2_SRGB8", glCompressedSRGB8ETC2, 8),
+		compressed("ETC2_RGB8A1", glCompressedRGB8PunchthroughETC2, 8),
+		compressed("ETC2_SRGB8A1", glCompressedSRGB8PunchthroughETC2, 8),
+		compressed("ETC2_RGBA8", glCompressedRGBA8ETC2EAC, 16),
+		compressed("ETC2_SRGB8_ALPHA8", glCompressedSRGB8Alpha8ETC2EAC, 16),
+		compressed("EAC_R11", glCompressedR11EAC, 8),
+		compressed("EAC_R11_SNORM", glCompressedSignedR11EAC, 8),
+		compressed("EAC_RG11", glCompressedRG11EAC, 16),
+		compressed("EAC_RG11_SNORM", glCompressedSignedRG11EAC, 16),
+	} {
+		formats[f.InternalFormat] = f
+	}
+	// ETC1 data is valid ETC2, upload it as such on core profiles
+	formats[glETC1RGB8] = formats[glCompressedRGB8ETC2]
+}
+
+// formatFor looks up a GL internal format.
+func formatFor(internalFormat uint32) (Format, error) {
+	f, ok := formats[internalFormat]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported internal format 0x%x", internalFormat)
+	}
+	return f, nil
+}
@@ -0,0 +1,135 @@
+// Package gputex decodes GPU-native texture containers (KTX, KTX2 and DDS)
+// into mip chains of raw, possibly block compressed, texel data.
+//
+// It does not touch OpenGL, so it can be used and tested without a context;
+// gfx.NewGPUTexture does the upload.
+package gputex
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Texture is a decoded container. Data for every mip level, array layer and
+// cube face is stored tightly packed (no row padding).
+type Texture struct {
+	Format Format
+
+	Width, Height, Depth int // of level 0, Depth is 1 for 2D textures
+	Levels               int // mip levels stored in the file, at least 1
+	Layers               int // array layers, 0 when the texture is not an array
+	Faces                int // 6 for cubemaps, 1 otherwise
+
+	// Images holds one entry per level, each with Layers*Faces entries
+	// ordered layer-major: index layer*Faces + face. Non-array textures use
+	// layer 0.
+	Images [][][]byte
+}
+
+// IsCubemap reports whether t has six faces.
+func (t *Texture) IsCubemap() bool {
+	return 6 == t.Faces
+}
+
+// IsArray reports whether t is an array texture.
+func (t *Texture) IsArray() bool {
+	return t.Layers > 0
+}
+
+// LevelSize returns the dimensions of mip level.
+func (t *Texture) LevelSize(level int) (width, height, depth int) {
+	return mipDim(t.Width, level), mipDim(t.Height, level), mipDim(t.Depth, level)
+}
+
+// Image returns the data of one face of one layer of a mip level.
+func (t *Texture) Image(level, layer, face int) []byte {
+	return t.Images[level][layer*t.Faces+face]
+}
+
+// layerCount is Layers with non-arrays counted as one layer.
+func (t *Texture) layerCount() int {
+	if t.Layers < 1 {
+		return 1
+	}
+	return t.Layers
+}
+
+// validate checks the header fields shared by all containers.
+func (t *Texture) validate() error {
+	switch {
+	case t.Width < 1 || t.Height < 1 || t.Depth < 1:
+		return fmt.Errorf("invalid size %dx%dx%d", t.Width, t.Height, t.Depth)
+	case 1 != t.Faces && 6 != t.Faces:
+		return fmt.Errorf("invalid face count %d", t.Faces)
+	case t.Levels < 1 || t.Levels > 32:
+		return fmt.Errorf("invalid mip level count %d", t.Levels)
+	case t.Layers < 0 || t.Layers > 1<<16:
+		return fmt.Errorf("invalid array layer count %d", t.Layers)
+	case t.IsCubemap() && (t.Width != t.Height || t.Depth > 1):
+		return fmt.Errorf("cubemap faces must be square, got %dx%dx%d", t.Width, t.Height, t.Depth)
+	}
+	return nil
+}
+
+// ErrUnknownContainer is returned by Decode for data that is not KTX, KTX2
+// or DDS.
+var ErrUnknownContainer = errors.New("gputex: not a KTX, KTX2 or DDS file")
+
+// Decode reads a KTX, KTX2 or DDS container, telling them apart by their
+// magic bytes.
+func Decode(r io.Reader) (*Texture, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(12)
+	if nil != err && len(magic) < 4 {
+		return nil, ErrUnknownContainer
+	}
+	switch {
+	case bytes.HasPrefix(magic, ktx1Identifier[:]):
+		return DecodeKTX(br)
+	case bytes.HasPrefix(magic, ktx2Identifier[:]):
+		return DecodeKTX2(br)
+	case bytes.HasPrefix(magic, []byte(ddsMagic)):
+		return DecodeDDS(br)
+	}
+	return nil, ErrUnknownContainer
+}
+
+// DecodeFile is Decode for a file on disk.
+func DecodeFile(file string) (*Texture, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := Decode(f)
+	if nil != err {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return t, nil
+}
+
+func mipDim(size, level int) int {
+	size >>= uint(level)
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// readFull reads exactly n bytes, refusing absurd sizes from corrupt headers
+// before allocating.
+func readFull(r io.Reader, n int) ([]byte, error) {
+	if n < 0 || n > 1<<30 {
+		return nil, fmt.Errorf("invalid data size %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); nil != err {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,326 @@
+package gputex
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// fill returns n bytes counting up from start, so images are told apart.
+func fill(n int, start byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = start + byte(i)
+	}
+	return b
+}
+
+func buildKTX(order binary.ByteOrder, h ktx1Header, kv []byte, levels ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(ktx1Identifier[:])
+	h.Endianness = 0x04030201
+	h.BytesOfKeyValueData = uint32(len(kv))
+	binary.Write(&buf, order, h)
+	buf.Write(kv)
+	for _, level := range levels {
+		binary.Write(&buf, order, uint32(len(level)))
+		buf.Write(level)
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeKTXUncompressedPadded(t *testing.T) {
+	// 3x2 RGB8: rows of 9 bytes padded to 12; level 1 is 1x1, 3 bytes padded to 4
+	level0 := append(append(fill(9, 0), 0, 0, 0), append(fill(9, 100), 0, 0, 0)...)
+	level1 := append(fill(3, 200), 0)
+	file := buildKTX(binary.LittleEndian, ktx1Header{
+		GLType: glUnsignedByte, GLTypeSize: 1, GLFormat: glRGB, GLInternalFormat: glRGB8,
+		GLBaseInternalFormat: glRGB, PixelWidth: 3, PixelHeight: 2, NumberOfFaces: 1, NumberOfMipmapLevels: 2,
+	}, []byte("kv pairs"), level0, level1)
+
+	tex, err := Decode(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if tex.Format.Name != "RGB8" || tex.Width != 3 || tex.Height != 2 || tex.Levels != 2 || tex.IsCubemap() || tex.IsArray() {
+		t.Fatalf("unexpected texture %+v", tex)
+	}
+	want0 := append(fill(9, 0), fill(9, 100)...)
+	if !bytes.Equal(tex.Image(0, 0, 0), want0) {
+		t.Errorf("level 0 = %v, want %v", tex.Image(0, 0, 0), want0)
+	}
+	if !bytes.Equal(tex.Image(1, 0, 0), fill(3, 200)) {
+		t.Errorf("level 1 = %v", tex.Image(1, 0, 0))
+	}
+}
+
+func TestDecodeKTXBigEndian(t *testing.T) {
+	// one RGBA16F texel, stored big-endian
+	texel := []byte{0x3C, 0x00, 0x40, 0x00, 0x42, 0x00, 0x44, 0x00}
+	file := buildKTX(binary.BigEndian, ktx1Header{
+		GLType: glHalfFloat, GLTypeSize: 2, GLFormat: glRGBA, GLInternalFormat: glRGBA16F,
+		GLBaseInternalFormat: glRGBA, PixelWidth: 1, PixelHeight: 1, NumberOfFaces: 1, NumberOfMipmapLevels: 1,
+	}, nil, texel)
+	tex, err := DecodeKTX(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := []byte{0x00, 0x3C, 0x00, 0x40, 0x00, 0x42, 0x00, 0x44}
+	if !bytes.Equal(tex.Image(0, 0, 0), want) {
+		t.Errorf("texel = % x, want % x", tex.Image(0, 0, 0), want)
+	}
+}
+
+func TestDecodeKTXCubemap(t *testing.T) {
+	// non-array cubemaps give imageSize once per level, the size of one face
+	var buf bytes.Buffer
+	buf.Write(ktx1Identifier[:])
+	binary.Write(&buf, binary.LittleEndian, ktx1Header{
+		Endianness: 0x04030201, GLInternalFormat: glCompressedRGBAS3TCDXT5,
+		GLBaseInternalFormat: glRGBA, PixelWidth: 8, PixelHeight: 8, NumberOfFaces: 6, NumberOfMipmapLevels: 2,
+	})
+	for level, size := range []int{64, 16} {
+		binary.Write(&buf, binary.LittleEndian, uint32(size))
+		for face := 0; face < 6; face++ {
+			buf.Write(fill(size, byte(level*100+face*10)))
+		}
+	}
+
+	tex, err := DecodeKTX(&buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !tex.IsCubemap() || !tex.Format.Compressed || tex.Format.Name != "BC3" {
+		t.Fatalf("unexpected texture %+v", tex.Format)
+	}
+	for level, size := range []int{64, 16} {
+		for face := 0; face < 6; face++ {
+			if got := tex.Image(level, 0, face); !bytes.Equal(got, fill(size, byte(level*100+face*10))) {
+				t.Errorf("level %d face %d = %v", level, face, got)
+			}
+		}
+	}
+}
+
+func TestDecodeKTXCubemapPadding(t *testing.T) {
+	// 1x1 RGB8 faces: the 3 byte row is padded to 4, and so is every face
+	var buf bytes.Buffer
+	buf.Write(ktx1Identifier[:])
+	binary.Write(&buf, binary.LittleEndian, ktx1Header{
+		Endianness: 0x04030201, GLType: glUnsignedByte, GLTypeSize: 1, GLFormat: glRGB, GLInternalFormat: glRGB8,
+		GLBaseInternalFormat: glRGB, PixelWidth: 1, PixelHeight: 1, NumberOfFaces: 6, NumberOfMipmapLevels: 1,
+	})
+	binary.Write(&buf, binary.LittleEndian, uint32(4))
+	for face := 0; face < 6; face++ {
+		buf.Write(append(fill(3, byte(face*10)), 0xff))
+	}
+
+	tex, err := DecodeKTX(&buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for face := 0; face < 6; face++ {
+		if got := tex.Image(0, 0, face); !bytes.Equal(got, fill(3, byte(face*10))) {
+			t.Errorf("face %d = %v", face, got)
+		}
+	}
+}
+
+func buildKTX2(h ktx2Header, levels [][]byte) []byte {
+	headerSize := 12 + binary.Size(h) + len(levels)*binary.Size(ktx2Level{})
+	index := make([]ktx2Level, len(levels))
+	var data bytes.Buffer
+	// store the smallest level first, as the spec asks
+	for i := len(levels) - 1; i >= 0; i-- {
+		index[i] = ktx2Level{ByteOffset: uint64(headerSize + data.Len()), ByteLength: uint64(len(levels[i])), UncompressedByteLength: uint64(len(levels[i]))}
+		data.Write(levels[i])
+	}
+	var buf bytes.Buffer
+	buf.Write(ktx2Identifier[:])
+	h.LevelCount = uint32(len(levels))
+	binary.Write(&buf, binary.LittleEndian, h)
+	binary.Write(&buf, binary.LittleEndian, index)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestDecodeKTX2Array(t *testing.T) {
+	// BC7 8x4, 2 layers, 2 levels: level 0 is 2 blocks per layer, level 1 one
+	level0 := append(fill(32, 0), fill(32, 100)...)
+	level1 := append(fill(16, 50), fill(16, 150)...)
+	file := buildKTX2(ktx2Header{VkFormat: 145, PixelWidth: 8, PixelHeight: 4, LayerCount: 2, FaceCount: 1}, [][]byte{level0, level1})
+
+	tex, err := Decode(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if tex.Format.Name != "BC7" || !tex.IsArray() || tex.Layers != 2 || tex.Levels != 2 {
+		t.Fatalf("unexpected texture %+v", tex)
+	}
+	if got := tex.Image(0, 1, 0); !bytes.Equal(got, fill(32, 100)) {
+		t.Errorf("level 0 layer 1 = %v", got)
+	}
+	if got := tex.Image(1, 0, 0); !bytes.Equal(got, fill(16, 50)) {
+		t.Errorf("level 1 layer 0 = %v", got)
+	}
+	if w, h, _ := tex.LevelSize(1); w != 4 || h != 2 {
+		t.Errorf("level 1 is %dx%d, want 4x2", w, h)
+	}
+	if tex.Format.SRGB().Name != "BC7_SRGB" {
+		t.Errorf("SRGB() = %v", tex.Format.SRGB())
+	}
+}
+
+func TestDecodeKTX2Zlib(t *testing.T) {
+	raw := fill(4*4*4, 7)
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(raw)
+	zw.Close()
+
+	file := buildKTX2(ktx2Header{VkFormat: 37, TypeSize: 1, PixelWidth: 4, PixelHeight: 4, FaceCount: 1, SupercompressionScheme: ktx2SchemeZlib}, [][]byte{z.Bytes()})
+	// fix up the uncompressed length the builder could not know
+	levelIndex := 12 + binary.Size(ktx2Header{})
+	binary.LittleEndian.PutUint64(file[levelIndex+16:], uint64(len(raw)))
+
+	tex, err := DecodeKTX2(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tex.Image(0, 0, 0), raw) {
+		t.Error("inflated data does not match")
+	}
+}
+
+func TestDecodeKTX2Unsupported(t *testing.T) {
+	file := buildKTX2(ktx2Header{VkFormat: 0, PixelWidth: 4, PixelHeight: 4, FaceCount: 1, SupercompressionScheme: ktx2SchemeBasis}, [][]byte{fill(16, 0)})
+	if _, err := DecodeKTX2(bytes.NewReader(file)); nil == err || !strings.Contains(err.Error(), "Basis") {
+		t.Errorf("err = %v, want a Basis Universal error", err)
+	}
+}
+
+func buildDDS(h ddsHeader, dx10 *ddsHeaderDX10, images ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(ddsMagic)
+	h.Size = 124
+	h.PixelFormat.Size = 32
+	binary.Write(&buf, binary.LittleEndian, h)
+	if nil != dx10 {
+		binary.Write(&buf, binary.LittleEndian, dx10)
+	}
+	for _, img := range images {
+		buf.Write(img)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeDDSLegacyMips(t *testing.T) {
+	h := ddsHeader{Flags: ddsdMipMapCount, Width: 8, Height: 8, MipMapCount: 4}
+	h.PixelFormat.Flags = ddpfFourCC
+	copy(h.PixelFormat.FourCC[:], "DXT1")
+	// 8x8, 4x4, 2x2 and 1x1 all round up to whole 8 byte blocks
+	file := buildDDS(h, nil, fill(32, 0), fill(8, 40), fill(8, 60), fill(8, 80))
+
+	tex, err := Decode(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if tex.Format.Name != "BC1_RGBA" || tex.Levels != 4 {
+		t.Fatalf("unexpected texture %+v", tex)
+	}
+	if got := tex.Image(3, 0, 0); !bytes.Equal(got, fill(8, 80)) {
+		t.Errorf("level 3 = %v", got)
+	}
+}
+
+func TestDecodeDDSDX10Cubemap(t *testing.T) {
+	h := ddsHeader{Flags: ddsdMipMapCount, Width: 2, Height: 2, MipMapCount: 2}
+	h.PixelFormat.Flags = ddpfFourCC
+	copy(h.PixelFormat.FourCC[:], "DX10")
+	dx10 := &ddsHeaderDX10{DXGIFormat: 87, ResourceDimension: 3, MiscFlag: dx10MiscTextureCube, ArraySize: 1}
+
+	// per face: level 0 (2x2 BGRA = 16 bytes) then level 1 (1x1 = 4 bytes)
+	var images [][]byte
+	for face := 0; face < 6; face++ {
+		images = append(images, fill(16, byte(face*20)), fill(4, byte(face*20+16)))
+	}
+	tex, err := DecodeDDS(bytes.NewReader(buildDDS(h, dx10, images...)))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !tex.IsCubemap() || tex.IsArray() || tex.Format.PixelFormat != glBGRA {
+		t.Fatalf("unexpected texture %+v", tex)
+	}
+	if got := tex.Image(1, 0, 5); !bytes.Equal(got, fill(4, 116)) {
+		t.Errorf("face 5 level 1 = %v", got)
+	}
+	if got := tex.Image(0, 0, 2); !bytes.Equal(got, fill(16, 40)) {
+		t.Errorf("face 2 level 0 = %v", got)
+	}
+}
+
+func TestDecodeDDSLegacyRGB(t *testing.T) {
+	h := ddsHeader{Width: 2, Height: 1}
+	h.PixelFormat = ddsPixelFormat{Flags: ddpfRGB | ddpfAlphaPixels, RGBBitCount: 32,
+		RBitMask: 0x00ff0000, GBitMask: 0x0000ff00, BBitMask: 0x000000ff, ABitMask: 0xff000000}
+	tex, err := DecodeDDS(bytes.NewReader(buildDDS(h, nil, fill(8, 0))))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if tex.Format.PixelFormat != glBGRA || tex.Format.InternalFormat != glRGBA8 {
+		t.Errorf("format = %+v, want BGRA8", tex.Format)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(strings.NewReader("PNG not a texture")); err != ErrUnknownContainer {
+		t.Errorf("Decode(png) = %v, want ErrUnknownContainer", err)
+	}
+
+	h := ddsHeader{Width: 4, Height: 4}
+	h.PixelFormat.Flags = ddpfFourCC
+	copy(h.PixelFormat.FourCC[:], "DXT5")
+	truncated := buildDDS(h, nil, fill(10, 0))
+	if _, err := Decode(bytes.NewReader(truncated)); nil == err {
+		t.Error("truncated DDS decoded without error")
+	}
+
+	level := fill(64, 0)
+	file := buildKTX(binary.LittleEndian, ktx1Header{
+		GLInternalFormat: 0x1234, PixelWidth: 4, PixelHeight: 4, NumberOfFaces: 1,
+	}, nil, level)
+	if _, err := Decode(bytes.NewReader(file)); nil == err {
+		t.Error("unknown internal format decoded without error")
+	}
+}
+
+func TestFormatImageSize(t *testing.T) {
+	bc1 := formats[glCompressedRGBAS3TCDXT1]
+	if got := bc1.ImageSize(5, 3, 1); got != 2*1*8 {
+		t.Errorf("BC1 5x3 = %d bytes, want 16", got)
+	}
+	rgb := formats[glRGB8]
+	if got := rgb.ImageSize(3, 2, 2); got != 36 {
+		t.Errorf("RGB8 3x2x2 = %d bytes, want 36", got)
+	}
+}
+
+func TestFormatSRGB(t *testing.T) {
+	for _, f := range []Format{formats[glRGBA8], bgraFormats[glRGBA8], bgraFormats[glRGB8]} {
+		srgb := f.SRGB()
+		if srgb.PixelFormat != f.PixelFormat || srgb.PixelType != f.PixelType || srgb.BlockBytes != f.BlockBytes {
+			t.Errorf("%v.SRGB() = %+v, pixels changed", f, srgb)
+		}
+		if want := srgbFormats[f.InternalFormat]; srgb.InternalFormat != want {
+			t.Errorf("%v.SRGB() internal format 0x%x, want 0x%x", f, srgb.InternalFormat, want)
+		}
+	}
+	if srgb := bgraFormats[glSRGB8Alpha8].SRGB(); srgb != bgraFormats[glSRGB8Alpha8] {
+		t.Errorf("sRGB BGRA8 became %+v", srgb)
+	}
+}
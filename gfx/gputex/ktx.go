@@ -0,0 +1,208 @@
+package gputex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+var ktx1Identifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+type ktx1Header struct {
+	Endianness            uint32
+	GLType                uint32
+	GLTypeSize            uint32
+	GLFormat              uint32
+	GLInternalFormat      uint32
+	GLBaseInternalFormat  uint32
+	PixelWidth            uint32
+	PixelHeight           uint32
+	PixelDepth            uint32
+	NumberOfArrayElements uint32
+	NumberOfFaces         uint32
+	NumberOfMipmapLevels  uint32
+	BytesOfKeyValueData   uint32
+}
+
+// DecodeKTX reads a KTX 1.1 container. Files written on big-endian machines
+// are byte swapped. Row padding is removed from uncompressed images.
+func DecodeKTX(r io.Reader) (*Texture, error) {
+	var ident [12]byte
+	if _, err := io.ReadFull(r, ident[:]); nil != err {
+		return nil, fmt.Errorf("ktx: %v", err)
+	}
+	if ident != ktx1Identifier {
+		return nil, errors.New("ktx: bad identifier")
+	}
+
+	raw := make([]byte, binary.Size(ktx1Header{}))
+	if _, err := io.ReadFull(r, raw); nil != err {
+		return nil, fmt.Errorf("ktx: header: %v", err)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch binary.LittleEndian.Uint32(raw) {
+	case 0x04030201:
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("ktx: bad endianness marker")
+	}
+	var h ktx1Header
+	binary.Read(bytes.NewReader(raw), order, &h)
+
+	format, err := ktx1Format(h)
+	if nil != err {
+		return nil, fmt.Errorf("ktx: %v", err)
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, int64(h.BytesOfKeyValueData)); nil != err {
+		return nil, fmt.Errorf("ktx: key/value data: %v", err)
+	}
+
+	t := &Texture{
+		Format: format,
+		Width:  int(h.PixelWidth),
+		Height: atLeastOne(h.PixelHeight),
+		Depth:  atLeastOne(h.PixelDepth),
+		Levels: atLeastOne(h.NumberOfMipmapLevels),
+		Layers: int(h.NumberOfArrayElements),
+		Faces:  int(h.NumberOfFaces),
+	}
+	if err := t.validate(); nil != err {
+		return nil, fmt.Errorf("ktx: %v", err)
+	}
+
+	// for non-array cubemaps imageSize is the size of one face and the six
+	// faces follow, each padded to 4 bytes; otherwise it covers the level
+	perFace := t.IsCubemap() && !t.IsArray()
+	images := t.layerCount() * t.Faces
+	t.Images = make([][][]byte, t.Levels)
+	for level := range t.Images {
+		var imageSize uint32
+		if err := binary.Read(r, order, &imageSize); nil != err {
+			return nil, fmt.Errorf("ktx: level %d: %v", level, err)
+		}
+
+		var chunks [][]byte
+		if perFace {
+			for face := 0; face < 6; face++ {
+				data, err := readPadded(r, int(imageSize))
+				if nil != err {
+					return nil, fmt.Errorf("ktx: level %d face %d: %v", level, face, err)
+				}
+				chunks = append(chunks, data)
+			}
+		} else {
+			data, err := readPadded(r, int(imageSize))
+			if nil != err {
+				return nil, fmt.Errorf("ktx: level %d: %v", level, err)
+			}
+			if 0 != len(data)%images {
+				return nil, fmt.Errorf("ktx: level %d: %d bytes do not split into %d images", level, len(data), images)
+			}
+			each := len(data) / images
+			for i := 0; i < images; i++ {
+				chunks = append(chunks, data[i*each:(i+1)*each])
+			}
+		}
+
+		w, hh, d := t.LevelSize(level)
+		for i, chunk := range chunks {
+			img, err := unpadRows(format, chunk, w, hh, d)
+			if nil != err {
+				return nil, fmt.Errorf("ktx: level %d image %d: %v", level, i, err)
+			}
+			if binary.BigEndian == order {
+				swapBytes(img, int(h.GLTypeSize))
+			}
+			chunks[i] = img
+		}
+		t.Images[level] = chunks
+	}
+	return t, nil
+}
+
+func ktx1Format(h ktx1Header) (Format, error) {
+	if 0 == h.GLType {
+		f, err := formatFor(h.GLInternalFormat)
+		if nil == err && !f.Compressed {
+			return Format{}, fmt.Errorf("internal format %s given without a type", f.Name)
+		}
+		return f, err
+	}
+	if glBGRA == h.GLFormat || glBGR == h.GLFormat {
+		if f, ok := bgraFormats[h.GLInternalFormat]; ok && f.PixelFormat == h.GLFormat {
+			return f, nil
+		}
+	}
+	f, err := formatFor(h.GLInternalFormat)
+	if nil != err {
+		return f, err
+	}
+	if f.Compressed || f.PixelFormat != h.GLFormat || f.PixelType != h.GLType {
+		return Format{}, fmt.Errorf("unsupported format 0x%x/type 0x%x for internal format %s", h.GLFormat, h.GLType, f.Name)
+	}
+	return f, nil
+}
+
+// readPadded reads n bytes followed by the padding to the next multiple of 4.
+func readPadded(r io.Reader, n int) ([]byte, error) {
+	data, err := readFull(r, n)
+	if nil != err {
+		return nil, err
+	}
+	if pad := 3 - (n+3)%4; pad > 0 {
+		if _, err := io.CopyN(ioutil.Discard, r, int64(pad)); nil != err {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// unpadRows strips KTX's 4 byte row alignment from an uncompressed image and
+// checks the size of compressed ones.
+func unpadRows(f Format, data []byte, width, height, depth int) ([]byte, error) {
+	packed := f.ImageSize(width, height, depth)
+	if f.Compressed {
+		if len(data) != packed {
+			return nil, fmt.Errorf("%d bytes, want %d", len(data), packed)
+		}
+		return data, nil
+	}
+
+	row := width * f.BlockBytes
+	stride := (row + 3) &^ 3
+	if len(data) == packed {
+		return data, nil
+	}
+	if len(data) != stride*height*depth {
+		return nil, fmt.Errorf("%d bytes, want %d", len(data), stride*height*depth)
+	}
+	out := make([]byte, 0, packed)
+	for y := 0; y < height*depth; y++ {
+		out = append(out, data[y*stride:y*stride+row]...)
+	}
+	return out, nil
+}
+
+func swapBytes(data []byte, size int) {
+	switch size {
+	case 2:
+		for i := 0; i+1 < len(data); i += 2 {
+			data[i], data[i+1] = data[i+1], data[i]
+		}
+	case 4:
+		for i := 0; i+3 < len(data); i += 4 {
+			data[i], data[i+1], data[i+2], data[i+3] = data[i+3], data[i+2], data[i+1], data[i]
+		}
+	}
+}
+
+func atLeastOne(n uint32) int {
+	if 0 == n {
+		return 1
+	}
+	return int(n)
+}
@@ -0,0 +1,194 @@
+package gputex
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+var ktx2Identifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+type ktx2Header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+
+	DFDByteOffset uint32
+	DFDByteLength uint32
+	KVDByteOffset uint32
+	KVDByteLength uint32
+	SGDByteOffset uint64
+	SGDByteLength uint64
+}
+
+type ktx2Level struct {
+	ByteOffset             uint64
+	ByteLength             uint64
+	UncompressedByteLength uint64
+}
+
+// KTX2 supercompression schemes.
+const (
+	ktx2SchemeNone  = 0
+	ktx2SchemeBasis = 1
+	ktx2SchemeZstd  = 2
+	ktx2SchemeZlib  = 3
+)
+
+// vkFormats maps the VkFormat values KTX2 uses to GL internal formats.
+var vkFormats = map[uint32]uint32{
+	9:   glR8,
+	16:  glRG8,
+	23:  glRGB8,
+	29:  glSRGB8,
+	37:  glRGBA8,
+	43:  glSRGB8Alpha8,
+	76:  glR16F,
+	83:  glRG16F,
+	90:  glRGB16F,
+	97:  glRGBA16F,
+	100: glR32F,
+	103: glRG32F,
+	106: glRGB32F,
+	109: glRGBA32F,
+
+	131: glCompressedRGBS3TCDXT1,
+	132: glCompressedSRGBS3TCDXT1,
+	133: glCompressedRGBAS3TCDXT1,
+	134: glCompressedSRGBAlphaS3TCDXT1,
+	135: glCompressedRGBAS3TCDXT3,
+	136: glCompressedSRGBAlphaS3TCDXT3,
+	137: glCompressedRGBAS3TCDXT5,
+	138: glCompressedSRGBAlphaS3TCDXT5,
+	139: glCompressedRedRGTC1,
+	140: glCompressedSignedRedRGTC1,
+	141: glCompressedRGRGTC2,
+	142: glCompressedSignedRGRGTC2,
+	143: glCompressedRGBBPTCUnsignedFloat,
+	144: glCompressedRGBBPTCSignedFloat,
+	145: glCompressedRGBABPTCUnorm,
+	146: glCompressedSRGBAlphaBPTCUnorm,
+	147: glCompressedRGB8ETC2,
+	148: glCompressedSRGB8ETC2,
+	149: glCompressedRGB8PunchthroughETC2,
+	150: glCompressedSRGB8PunchthroughETC2,
+	151: glCompressedRGBA8ETC2EAC,
+	152: glCompressedSRGB8Alpha8ETC2EAC,
+	153: glCompressedR11EAC,
+	154: glCompressedSignedR11EAC,
+	155: glCompressedRG11EAC,
+	156: glCompressedSignedRG11EAC,
+}
+
+// vkBGRAFormats are the VkFormats stored in BGR(A) order.
+var vkBGRAFormats = map[uint32]Format{
+	44: bgraFormats[glRGBA8],
+	50: bgraFormats[glSRGB8Alpha8],
+}
+
+// DecodeKTX2 reads a KTX 2.0 container. Levels compressed with zlib are
+// inflated; Basis Universal and Zstandard supercompression are not supported.
+func DecodeKTX2(r io.Reader) (*Texture, error) {
+	// level offsets are absolute, so it is simplest to hold the whole file
+	data, err := ioutil.ReadAll(r)
+	if nil != err {
+		return nil, fmt.Errorf("ktx2: %v", err)
+	}
+	if len(data) < 12 || !bytes.Equal(data[:12], ktx2Identifier[:]) {
+		return nil, errors.New("ktx2: bad identifier")
+	}
+
+	rd := bytes.NewReader(data[12:])
+	var h ktx2Header
+	if err := binary.Read(rd, binary.LittleEndian, &h); nil != err {
+		return nil, fmt.Errorf("ktx2: header: %v", err)
+	}
+
+	var format Format
+	if f, ok := vkBGRAFormats[h.VkFormat]; ok {
+		format = f
+	} else if internal, ok := vkFormats[h.VkFormat]; ok {
+		format = formats[internal]
+	} else if 0 == h.VkFormat {
+		return nil, errors.New("ktx2: VK_FORMAT_UNDEFINED (Basis Universal) is not supported")
+	} else {
+		return nil, fmt.Errorf("ktx2: unsupported VkFormat %d", h.VkFormat)
+	}
+
+	switch h.SupercompressionScheme {
+	case ktx2SchemeNone, ktx2SchemeZlib:
+	case ktx2SchemeBasis:
+		return nil, errors.New("ktx2: BasisLZ supercompression is not supported")
+	case ktx2SchemeZstd:
+		return nil, errors.New("ktx2: Zstandard supercompression is not supported")
+	default:
+		return nil, fmt.Errorf("ktx2: unknown supercompression scheme %d", h.SupercompressionScheme)
+	}
+
+	t := &Texture{
+		Format: format,
+		Width:  int(h.PixelWidth),
+		Height: atLeastOne(h.PixelHeight),
+		Depth:  atLeastOne(h.PixelDepth),
+		Levels: atLeastOne(h.LevelCount),
+		Layers: int(h.LayerCount),
+		Faces:  int(h.FaceCount),
+	}
+	if err := t.validate(); nil != err {
+		return nil, fmt.Errorf("ktx2: %v", err)
+	}
+
+	levels := make([]ktx2Level, t.Levels)
+	if err := binary.Read(rd, binary.LittleEndian, levels); nil != err {
+		return nil, fmt.Errorf("ktx2: level index: %v", err)
+	}
+
+	images := t.layerCount() * t.Faces
+	t.Images = make([][][]byte, t.Levels)
+	for level, index := range levels {
+		end := index.ByteOffset + index.ByteLength
+		if end < index.ByteOffset || end > uint64(len(data)) {
+			return nil, fmt.Errorf("ktx2: level %d lies outside the file", level)
+		}
+		chunk := data[index.ByteOffset:end]
+		if ktx2SchemeZlib == h.SupercompressionScheme {
+			if chunk, err = inflate(chunk, index.UncompressedByteLength); nil != err {
+				return nil, fmt.Errorf("ktx2: level %d: %v", level, err)
+			}
+		}
+
+		w, hh, d := t.LevelSize(level)
+		each := format.ImageSize(w, hh, d)
+		if len(chunk) != each*images {
+			return nil, fmt.Errorf("ktx2: level %d has %d bytes, want %d", level, len(chunk), each*images)
+		}
+		t.Images[level] = make([][]byte, images)
+		for i := range t.Images[level] {
+			t.Images[level][i] = chunk[i*each : (i+1)*each]
+		}
+	}
+	return t, nil
+}
+
+func inflate(data []byte, size uint64) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if nil != err {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := readFull(zr, int(size))
+	if nil != err {
+		return nil, fmt.Errorf("inflate: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package gfx
+
+import (
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/gputex"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestGPUTextureTarget(t *testing.T) {
+	cases := []struct {
+		tex    gputex.Texture
+		target uint32
+	}{
+		{gputex.Texture{Depth: 1, Faces: 1}, gl.TEXTURE_2D},
+		{gputex.Texture{Depth: 1, Faces: 6}, gl.TEXTURE_CUBE_MAP},
+		{gputex.Texture{Depth: 1, Faces: 1, Layers: 3}, gl.TEXTURE_2D_ARRAY},
+		{gputex.Texture{Depth: 4, Faces: 1}, gl.TEXTURE_3D},
+		{gputex.Texture{Depth: 1, Faces: 6, Layers: 2}, 0},
+	}
+	for _, c := range cases {
+		target, err := gpuTextureTarget(&c.tex)
+		if 0 == c.target {
+			if nil == err {
+				t.Errorf("%+v: expected an error", c.tex)
+			}
+			continue
+		}
+		if nil != err || target != c.target {
+			t.Errorf("%+v: target 0x%x, %v, want 0x%x", c.tex, target, err, c.target)
+		}
+	}
+}
+
+func TestIsGPUTextureFile(t *testing.T) {
+	for file, want := range map[string]bool{
+		"sky.ktx": true, "sky.KTX2": true, "wall.dds": true,
+		"wall.jpeg": false, "square.png": false, "dds": false,
+	} {
+		if got := isGPUTextureFile(file); got != want {
+			t.Errorf("isGPUTextureFile(%q) = %v", file, got)
+		}
+	}
+}
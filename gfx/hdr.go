@@ -0,0 +1,57 @@
+package gfx
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/hdr"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// isHDRFile reports whether file is a float image for package hdr.
+func isHDRFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".hdr", ".exr":
+		return true
+	}
+	return false
+}
+
+// NewHDRTexture loads a Radiance .hdr or OpenEXR .exr file into a new RGB16F
+// TEXTURE_2D. opts.SRGB is ignored, the data is already linear. The texture
+// is left bound to TEXTURE0.
+func NewHDRTexture(file string, opts TextureOptions) (uint32, error) {
+	img, err := hdr.DecodeFile(file)
+	if nil != err {
+		return 0, err
+	}
+	if opts.FlipV {
+		img.FlipV()
+	}
+	return NewHDRTextureFromImage(img, gl.RGB16F, opts), nil
+}
+
+// NewHDRTextureFromImage uploads img as internalFormat, gl.RGB16F or
+// gl.RGB32F. Pixels are passed as floats either way.
+func NewHDRTextureFromImage(img *hdr.Image, internalFormat int32, opts TextureOptions) uint32 {
+	opts = opts.withDefaults()
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		internalFormat,
+		int32(img.Width),
+		int32(img.Height),
+		0,
+		gl.RGB,
+		gl.FLOAT,
+		gl.Ptr(img.Pix),
+	)
+	opts.apply(gl.TEXTURE_2D)
+
+	return texture
+}
@@ -0,0 +1,261 @@
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+var exrMagic = []byte{0x76, 0x2f, 0x31, 0x01}
+
+// version field flags that take a file out of the single part scanline case
+const (
+	exrTiled     = 0x200
+	exrDeep      = 0x800
+	exrMultipart = 0x1000
+)
+
+// channel pixel types
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+func (c exrChannel) size() int {
+	if exrHalf == c.pixelType {
+		return 2
+	}
+	return 4
+}
+
+// DecodeEXR reads an OpenEXR image, the lite subset: a single part of
+// uncompressed scanlines whose R, G and B channels are HALF or FLOAT. A
+// luminance-only Y image decodes as gray. Other channels, alpha included,
+// are skipped. Compressed, tiled, deep and multi-part files are rejected.
+func DecodeEXR(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+	var head [8]byte
+	if _, err := io.ReadFull(br, head[:]); nil != err || !bytes.Equal(head[:4], exrMagic) {
+		return nil, errors.New("exr: not an OpenEXR file")
+	}
+	version := binary.LittleEndian.Uint32(head[4:])
+	if 2 != version&0xff {
+		return nil, fmt.Errorf("exr: unsupported version %d", version&0xff)
+	}
+	if 0 != version&(exrTiled|exrDeep|exrMultipart) {
+		return nil, errors.New("exr: only single part scanline files are supported")
+	}
+
+	var channels []exrChannel
+	var window [4]int32 // xMin, yMin, xMax, yMax
+	haveWindow := false
+	for {
+		name, err := readCString(br)
+		if nil != err {
+			return nil, fmt.Errorf("exr: header: %v", err)
+		}
+		if "" == name {
+			break
+		}
+		typ, err := readCString(br)
+		if nil != err {
+			return nil, fmt.Errorf("exr: header: %v", err)
+		}
+		var size int32
+		if err := binary.Read(br, binary.LittleEndian, &size); nil != err {
+			return nil, fmt.Errorf("exr: header: %v", err)
+		}
+		if size < 0 || size > 1<<20 {
+			return nil, fmt.Errorf("exr: attribute %s has bad size %d", name, size)
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(br, value); nil != err {
+			return nil, fmt.Errorf("exr: attribute %s: %v", name, err)
+		}
+
+		switch {
+		case "channels" == name && "chlist" == typ:
+			if channels, err = parseChannels(value); nil != err {
+				return nil, err
+			}
+		case "compression" == name && "compression" == typ:
+			if 1 != len(value) || 0 != value[0] {
+				return nil, errors.New("exr: only uncompressed files are supported")
+			}
+		case "dataWindow" == name && "box2i" == typ:
+			if 16 != len(value) {
+				return nil, errors.New("exr: bad data window")
+			}
+			binary.Read(bytes.NewReader(value), binary.LittleEndian, &window)
+			haveWindow = true
+		}
+	}
+	if !haveWindow || nil == channels {
+		return nil, errors.New("exr: header lacks channels or dataWindow")
+	}
+	width := int(window[2]) - int(window[0]) + 1
+	height := int(window[3]) - int(window[1]) + 1
+	if width < 1 || height < 1 || width > 1<<15 || height > 1<<15 {
+		return nil, fmt.Errorf("exr: bad data window %v", window)
+	}
+
+	// the color each channel feeds: 0 to 2 for R, G, B, 3 for all three
+	// from Y, -1 for none
+	targets := make([]int, len(channels))
+	found := [3]bool{}
+	gray := false
+	lineSize := 0
+	for i, c := range channels {
+		targets[i] = -1
+		switch c.name {
+		case "R", "G", "B":
+			targets[i] = strings.Index("RGB", c.name)
+			found[targets[i]] = true
+		case "Y":
+			gray = true
+		}
+		lineSize += width * c.size()
+	}
+	if !found[0] && !found[1] && !found[2] {
+		if !gray {
+			return nil, errors.New("exr: no R, G, B or Y channel")
+		}
+		for i, c := range channels {
+			if "Y" == c.name {
+				targets[i] = 3
+			}
+		}
+	}
+	for i, c := range channels {
+		if targets[i] >= 0 && exrUint == c.pixelType {
+			return nil, fmt.Errorf("exr: channel %s is UINT, want HALF or FLOAT", c.name)
+		}
+	}
+
+	// one offset per scanline, the chunks follow in file order
+	if _, err := br.Discard(8 * height); nil != err {
+		return nil, fmt.Errorf("exr: offset table: %v", err)
+	}
+
+	m := NewImage(width, height)
+	line := make([]byte, lineSize)
+	done := make([]bool, height)
+	for i := 0; i < height; i++ {
+		var chunk struct {
+			Y, Size int32
+		}
+		if err := binary.Read(br, binary.LittleEndian, &chunk); nil != err {
+			return nil, fmt.Errorf("exr: scanline %d: %v", i, err)
+		}
+		y := int(chunk.Y) - int(window[1])
+		if y < 0 || y >= height || done[y] || int(chunk.Size) != lineSize {
+			return nil, fmt.Errorf("exr: bad scanline chunk y %d, %d bytes", chunk.Y, chunk.Size)
+		}
+		done[y] = true
+		if _, err := io.ReadFull(br, line); nil != err {
+			return nil, fmt.Errorf("exr: scanline %d: %v", chunk.Y, err)
+		}
+
+		// each channel stores the whole row before the next begins
+		row := m.Pix[3*y*width : 3*(y+1)*width]
+		p := line
+		for ci, c := range channels {
+			n := width * c.size()
+			if t := targets[ci]; t >= 0 {
+				for x := 0; x < width; x++ {
+					var v float32
+					if exrHalf == c.pixelType {
+						v = halfToFloat(binary.LittleEndian.Uint16(p[2*x:]))
+					} else {
+						v = math.Float32frombits(binary.LittleEndian.Uint32(p[4*x:]))
+					}
+					if 3 == t {
+						row[3*x], row[3*x+1], row[3*x+2] = v, v, v
+					} else {
+						row[3*x+t] = v
+					}
+				}
+			}
+			p = p[n:]
+		}
+	}
+	return m, nil
+}
+
+// parseChannels reads a chlist attribute.
+func parseChannels(value []byte) ([]exrChannel, error) {
+	var channels []exrChannel
+	for {
+		end := bytes.IndexByte(value, 0)
+		if end < 0 {
+			return nil, errors.New("exr: unterminated channel list")
+		}
+		if 0 == end {
+			break
+		}
+		name := string(value[:end])
+		value = value[end+1:]
+		if len(value) < 16 {
+			return nil, errors.New("exr: short channel list")
+		}
+		c := exrChannel{name: name, pixelType: int32(binary.LittleEndian.Uint32(value))}
+		xSampling := binary.LittleEndian.Uint32(value[8:])
+		ySampling := binary.LittleEndian.Uint32(value[12:])
+		value = value[16:]
+		if c.pixelType < exrUint || c.pixelType > exrFloat {
+			return nil, fmt.Errorf("exr: channel %s has unknown pixel type %d", name, c.pixelType)
+		}
+		if 1 != xSampling || 1 != ySampling {
+			return nil, fmt.Errorf("exr: channel %s is subsampled", name)
+		}
+		channels = append(channels, c)
+	}
+	if 0 == len(channels) {
+		return nil, errors.New("exr: no channels")
+	}
+	return channels, nil
+}
+
+// readCString reads a NUL terminated attribute name or type.
+func readCString(br *bufio.Reader) (string, error) {
+	s, err := br.ReadString(0)
+	if nil != err {
+		return "", err
+	}
+	if len(s) > 256 {
+		return "", errors.New("name too long")
+	}
+	return s[:len(s)-1], nil
+}
+
+// halfToFloat widens an IEEE 754 half precision number.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch {
+	case 0 == exp && 0 == mant:
+		return math.Float32frombits(sign)
+	case 0 == exp:
+		// subnormal
+		v := float32(mant) / (1 << 24)
+		if 0 != sign {
+			v = -v
+		}
+		return v
+	case 0x1f == exp:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
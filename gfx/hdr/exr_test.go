@@ -0,0 +1,183 @@
+package hdr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exrChannelSpec is a channel to write into a test file, pixels in the
+// order they are stored.
+type exrChannelSpec struct {
+	name      string
+	pixelType int32
+	values    []float32
+}
+
+// buildEXR writes an uncompressed single part scanline file, with rows
+// stored bottom to top to check that chunks are placed by their y.
+func buildEXR(width, height int, yMin int32, channels []exrChannelSpec) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.Write(exrMagic)
+	binary.Write(&buf, le, uint32(2))
+
+	attribute := func(name, typ string, value []byte) {
+		buf.WriteString(name + "\x00" + typ + "\x00")
+		binary.Write(&buf, le, int32(len(value)))
+		buf.Write(value)
+	}
+	var chlist bytes.Buffer
+	for _, c := range channels {
+		chlist.WriteString(c.name + "\x00")
+		binary.Write(&chlist, le, []int32{c.pixelType, 0, 1, 1})
+	}
+	chlist.WriteByte(0)
+	attribute("channels", "chlist", chlist.Bytes())
+	attribute("compression", "compression", []byte{0})
+	var window bytes.Buffer
+	binary.Write(&window, le, []int32{0, yMin, int32(width - 1), yMin + int32(height-1)})
+	attribute("dataWindow", "box2i", window.Bytes())
+	attribute("displayWindow", "box2i", window.Bytes())
+	attribute("lineOrder", "lineOrder", []byte{1})
+	buf.WriteByte(0)
+
+	// the offsets are not read, zeros will do
+	buf.Write(make([]byte, 8*height))
+	for y := height - 1; y >= 0; y-- {
+		var line bytes.Buffer
+		for _, c := range channels {
+			for x := 0; x < width; x++ {
+				v := c.values[y*width+x]
+				if exrHalf == c.pixelType {
+					binary.Write(&line, le, floatToHalf(v))
+				} else {
+					binary.Write(&line, le, v)
+				}
+			}
+		}
+		binary.Write(&buf, le, []int32{yMin + int32(y), int32(line.Len())})
+		buf.Write(line.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// floatToHalf handles the normal numbers the tests use.
+func floatToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	if 0 == bits&0x7fffffff {
+		return uint16(bits >> 16)
+	}
+	exp := int(bits>>23&0xff) - 127 + 15
+	return uint16(bits>>16&0x8000) | uint16(exp)<<10 | uint16(bits>>13&0x3ff)
+}
+
+func TestDecodeEXR(t *testing.T) {
+	// channels are stored in alphabetical order, alpha is skipped
+	file := buildEXR(2, 2, -1, []exrChannelSpec{
+		{"A", exrHalf, []float32{1, 1, 1, 1}},
+		{"B", exrFloat, []float32{0.1, 0.2, 0.3, 1e6}},
+		{"G", exrHalf, []float32{0.5, -2, 0, 1024}},
+		{"R", exrHalf, []float32{1, 2, 0.25, 65504}},
+	})
+	m, err := DecodeEXR(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != m.Width || 2 != m.Height || 1 != m.Exposure {
+		t.Fatalf("decoded %dx%d exposure %g", m.Width, m.Height, m.Exposure)
+	}
+	want := []float32{
+		1, 0.5, 0.1, 2, -2, 0.2,
+		0.25, 0, 0.3, 65504, 1024, 1e6,
+	}
+	for i := range want {
+		if m.Pix[i] != want[i] {
+			t.Errorf("Pix[%d] = %g, want %g", i, m.Pix[i], want[i])
+		}
+	}
+}
+
+func TestDecodeEXRGray(t *testing.T) {
+	file := buildEXR(3, 1, 0, []exrChannelSpec{{"Y", exrFloat, []float32{0, 0.5, 8}}})
+	m, err := DecodeEXR(bytes.NewReader(file))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for x, v := range []float32{0, 0.5, 8} {
+		if r, g, b := m.At(x, 0); r != v || g != v || b != v {
+			t.Errorf("pixel %d = (%g, %g, %g), want %g", x, r, g, b, v)
+		}
+	}
+}
+
+func TestDecodeFileSniffsEXR(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sky.hdr")
+	data := buildEXR(1, 1, 0, []exrChannelSpec{{"G", exrHalf, []float32{3}}})
+	if err := ioutil.WriteFile(file, data, 0644); nil != err {
+		t.Fatal(err)
+	}
+	m, err := DecodeFile(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if r, g, b := m.At(0, 0); 0 != r || 3 != g || 0 != b {
+		t.Errorf("pixel = (%g, %g, %g)", r, g, b)
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	for h, want := range map[uint16]float32{
+		0x3c00: 1,
+		0xc000: -2,
+		0x7bff: 65504,
+		0x0001: 1.0 / (1 << 24),
+		0x8000: 0,
+	} {
+		if got := halfToFloat(h); got != want {
+			t.Errorf("halfToFloat(%#04x) = %g, want %g", h, got, want)
+		}
+	}
+	if !math.IsInf(float64(halfToFloat(0x7c00)), 1) {
+		t.Error("0x7c00 is not +Inf")
+	}
+}
+
+func TestDecodeEXRErrors(t *testing.T) {
+	rgb := []exrChannelSpec{{"R", exrHalf, []float32{1}}}
+	compressed := buildEXR(1, 1, 0, rgb)
+	i := bytes.Index(compressed, []byte("compression\x00compression\x00"))
+	compressed[i+len("compression\x00compression\x00")+4] = 3 // ZIP
+
+	tiled := buildEXR(1, 1, 0, rgb)
+	tiled[5] |= exrTiled >> 8
+
+	unsigned := buildEXR(1, 1, 0, []exrChannelSpec{{"R", exrUint, []float32{0}}})
+
+	noColor := buildEXR(1, 1, 0, []exrChannelSpec{{"Z", exrFloat, []float32{1}}})
+
+	noChannels := buildEXR(1, 1, 0, nil)
+	truncated := buildEXR(2, 2, 0, []exrChannelSpec{{"R", exrHalf, []float32{1, 2, 3, 4}}})
+	truncated = truncated[:len(truncated)-1]
+
+	for name, data := range map[string][]byte{
+		"magic":      []byte("#?RADIANCE\n"),
+		"compressed": compressed,
+		"tiled":      tiled,
+		"uint":       unsigned,
+		"no color":   noColor,
+		"no channel": noChannels,
+		"truncated":  truncated,
+	} {
+		if _, err := DecodeEXR(bytes.NewReader(data)); nil == err {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+	if _, err := DecodeEXR(strings.NewReader("")); nil == err {
+		t.Error("empty input decoded without error")
+	}
+}
@@ -0,0 +1,286 @@
+// Package hdr reads and writes Radiance RGBE (.hdr) images as float32 RGB,
+// for environment maps and lightmaps that do not fit in 8 bits per channel.
+// It also reads the simplest OpenEXR (.exr) files: uncompressed scanlines of
+// HALF or FLOAT channels.
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Image is a linear float RGB image. Pix holds three floats per pixel, rows
+// top to bottom as they appear on screen.
+type Image struct {
+	Width, Height int
+	Pix           []float32
+
+	// Exposure is the product of the EXPOSURE lines in the header, 1 if
+	// there were none. Divide by it to get back the original radiance.
+	Exposure float64
+}
+
+// NewImage allocates a black width x height image.
+func NewImage(width, height int) *Image {
+	return &Image{Width: width, Height: height, Pix: make([]float32, 3*width*height), Exposure: 1}
+}
+
+// At returns the color of pixel (x, y).
+func (m *Image) At(x, y int) (r, g, b float32) {
+	i := 3 * (y*m.Width + x)
+	return m.Pix[i], m.Pix[i+1], m.Pix[i+2]
+}
+
+// Set sets the color of pixel (x, y).
+func (m *Image) Set(x, y int, r, g, b float32) {
+	i := 3 * (y*m.Width + x)
+	m.Pix[i], m.Pix[i+1], m.Pix[i+2] = r, g, b
+}
+
+// FlipV mirrors the image top to bottom, OpenGL wants the bottom row first.
+func (m *Image) FlipV() {
+	row := 3 * m.Width
+	tmp := make([]float32, row)
+	for top, bottom := 0, m.Height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		t := m.Pix[top*row : (top+1)*row]
+		b := m.Pix[bottom*row : (bottom+1)*row]
+		copy(tmp, t)
+		copy(t, b)
+		copy(b, tmp)
+	}
+}
+
+// DecodeFile reads a Radiance or OpenEXR file from disk, telling them apart
+// by their magic numbers.
+func DecodeFile(file string) (*Image, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	decode := Decode
+	if magic, _ := br.Peek(len(exrMagic)); bytes.Equal(magic, exrMagic) {
+		decode = DecodeEXR
+	}
+	m, err := decode(br)
+	if nil != err {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return m, nil
+}
+
+// Decode reads a Radiance RGBE image. Flat, old style and adaptive run length
+// encoded scanlines are supported; XYZE images are rejected.
+func Decode(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+	exposure, err := readHeader(br)
+	if nil != err {
+		return nil, err
+	}
+
+	line, err := br.ReadString('\n')
+	if nil != err {
+		return nil, fmt.Errorf("hdr: resolution: %v", err)
+	}
+	width, height, bottomUp, rightToLeft, err := parseResolution(strings.TrimSpace(line))
+	if nil != err {
+		return nil, err
+	}
+
+	m := NewImage(width, height)
+	m.Exposure = exposure
+	scanline := make([]byte, 4*width)
+	for y := 0; y < height; y++ {
+		if err := readScanline(br, scanline); nil != err {
+			return nil, fmt.Errorf("hdr: scanline %d: %v", y, err)
+		}
+		row := y
+		if bottomUp {
+			row = height - 1 - y
+		}
+		for x := 0; x < width; x++ {
+			col := x
+			if rightToLeft {
+				col = width - 1 - x
+			}
+			p := scanline[4*x : 4*x+4]
+			m.Set(col, row, rgbeToFloat(p[0], p[3]), rgbeToFloat(p[1], p[3]), rgbeToFloat(p[2], p[3]))
+		}
+	}
+	return m, nil
+}
+
+func readHeader(br *bufio.Reader) (float64, error) {
+	magic, err := br.ReadString('\n')
+	if nil != err || !(strings.HasPrefix(magic, "#?RADIANCE") || strings.HasPrefix(magic, "#?RGBE")) {
+		return 0, errors.New("hdr: not a Radiance file")
+	}
+
+	exposure := 1.0
+	for {
+		line, err := br.ReadString('\n')
+		if nil != err {
+			return 0, fmt.Errorf("hdr: header: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case "" == line:
+			return exposure, nil
+		case strings.HasPrefix(line, "FORMAT="):
+			if format := strings.TrimPrefix(line, "FORMAT="); "32-bit_rle_rgbe" != format {
+				return 0, fmt.Errorf("hdr: unsupported format %s", format)
+			}
+		case strings.HasPrefix(line, "EXPOSURE="):
+			e, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, "EXPOSURE=")), 64)
+			if nil != err || e <= 0 {
+				return 0, fmt.Errorf("hdr: bad exposure %q", line)
+			}
+			exposure *= e
+		}
+	}
+}
+
+// parseResolution reads lines such as "-Y 512 +X 1024". Transposed images,
+// with X given first, are not supported.
+func parseResolution(line string) (width, height int, bottomUp, rightToLeft bool, err error) {
+	f := strings.Fields(line)
+	if 4 != len(f) || 'Y' != f[0][len(f[0])-1] || 'X' != f[2][len(f[2])-1] {
+		return 0, 0, false, false, fmt.Errorf("hdr: unsupported resolution line %q", line)
+	}
+	height, err1 := strconv.Atoi(f[1])
+	width, err2 := strconv.Atoi(f[3])
+	if nil != err1 || nil != err2 || width < 1 || height < 1 || width > 1<<15 || height > 1<<15 {
+		return 0, 0, false, false, fmt.Errorf("hdr: bad resolution %q", line)
+	}
+	return width, height, "+Y" == f[0], "-X" == f[2], nil
+}
+
+// readScanline fills buf with one row of RGBE quadruples.
+func readScanline(br *bufio.Reader, buf []byte) error {
+	width := len(buf) / 4
+	head, err := br.Peek(4)
+	if nil != err {
+		return err
+	}
+	if width < 8 || width > 0x7fff || 2 != head[0] || 2 != head[1] || 0 != head[2]&0x80 {
+		return readOldScanline(br, buf)
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return errors.New("scanline width mismatch")
+	}
+	br.Discard(4)
+
+	// adaptive RLE: each channel is stored separately as runs and literals
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := br.ReadByte()
+			if nil != err {
+				return err
+			}
+			if count > 128 {
+				n := int(count) - 128
+				v, err := br.ReadByte()
+				if nil != err {
+					return err
+				}
+				if x+n > width {
+					return errors.New("run overflows scanline")
+				}
+				for ; n > 0; n-- {
+					buf[4*x+c] = v
+					x++
+				}
+				continue
+			}
+			n := int(count)
+			if 0 == n || x+n > width {
+				return errors.New("bad literal run")
+			}
+			for ; n > 0; n-- {
+				v, err := br.ReadByte()
+				if nil != err {
+					return err
+				}
+				buf[4*x+c] = v
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// readOldScanline reads flat pixels, where (1, 1, 1, n) repeats the previous
+// pixel n times, shifted left by 8 bits for every consecutive repeat marker.
+func readOldScanline(br *bufio.Reader, buf []byte) error {
+	width := len(buf) / 4
+	shift := uint(0)
+	for x := 0; x < width; {
+		var p [4]byte
+		if _, err := io.ReadFull(br, p[:]); nil != err {
+			return err
+		}
+		if 1 == p[0] && 1 == p[1] && 1 == p[2] {
+			if 0 == x {
+				return errors.New("repeat marker at scanline start")
+			}
+			n := int(p[3]) << shift
+			if x+n > width {
+				return errors.New("repeat overflows scanline")
+			}
+			for ; n > 0; n-- {
+				copy(buf[4*x:4*x+4], buf[4*x-4:4*x])
+				x++
+			}
+			shift += 8
+			continue
+		}
+		copy(buf[4*x:4*x+4], p[:])
+		x++
+		shift = 0
+	}
+	return nil
+}
+
+func rgbeToFloat(v, e byte) float32 {
+	if 0 == e {
+		return 0
+	}
+	return float32(math.Ldexp(float64(v), int(e)-(128+8)))
+}
+
+// floatToRGBE packs a color into the shared exponent representation.
+func floatToRGBE(r, g, b float32) [4]byte {
+	v := math.Max(float64(r), math.Max(float64(g), float64(b)))
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	frac, exp := math.Frexp(v)
+	scale := frac * 256 / v
+	return [4]byte{byte(float64(r) * scale), byte(float64(g) * scale), byte(float64(b) * scale), byte(exp + 128)}
+}
+
+// Encode writes m as a Radiance file with flat (uncompressed) scanlines.
+func Encode(w io.Writer, m *Image) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n")
+	if 0 != m.Exposure && 1 != m.Exposure {
+		fmt.Fprintf(bw, "EXPOSURE=%g\n", m.Exposure)
+	}
+	fmt.Fprintf(bw, "\n-Y %d +X %d\n", m.Height, m.Width)
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			p := floatToRGBE(m.At(x, y))
+			bw.Write(p[:])
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,114 @@
+package hdr
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	// RGBE keeps 8 bits of mantissa
+	return math.Abs(float64(a-b)) <= 0.01*math.Max(1, math.Abs(float64(b)))
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := NewImage(3, 2)
+	m.Set(0, 0, 1, 0.5, 0.25)
+	m.Set(2, 1, 1000, 200, 0)
+	m.Set(1, 1, 0.001, 0.002, 0.003)
+	m.Exposure = 2
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, m); nil != err {
+		t.Fatal(err)
+	}
+	got, err := Decode(&buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got.Width != 3 || got.Height != 2 || got.Exposure != 2 {
+		t.Fatalf("decoded %dx%d exposure %g", got.Width, got.Height, got.Exposure)
+	}
+	for i := range m.Pix {
+		if !near(got.Pix[i], m.Pix[i]) {
+			t.Errorf("Pix[%d] = %g, want %g", i, got.Pix[i], m.Pix[i])
+		}
+	}
+}
+
+// header writes a minimal Radiance header for a width x height image.
+func header(resolution string) []byte {
+	return []byte("#?RADIANCE\n# made by hand\nFORMAT=32-bit_rle_rgbe\n\n" + resolution + "\n")
+}
+
+func TestDecodeAdaptiveRLE(t *testing.T) {
+	// 8 pixels wide: R is a run of 8 x 128, G literals 0..7, B a run, E a run
+	data := header("-Y 1 +X 8")
+	data = append(data, 2, 2, 0, 8)
+	data = append(data, 128+8, 128)
+	data = append(data, 8, 0, 16, 32, 48, 64, 80, 96, 112)
+	data = append(data, 128+8, 0)
+	data = append(data, 128+8, 129)
+
+	m, err := Decode(bytes.NewReader(data))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for x := 0; x < 8; x++ {
+		r, g, b := m.At(x, 0)
+		if !near(r, 1) || !near(g, float32(16*x)/128) || 0 != b {
+			t.Errorf("pixel %d = (%g, %g, %g)", x, r, g, b)
+		}
+	}
+}
+
+func TestDecodeOldRLEAndOrientation(t *testing.T) {
+	// bottom-up, 2 rows of 3: the first stored row is the bottom one and uses
+	// a repeat marker for its last two pixels
+	data := header("+Y 2 +X 3")
+	data = append(data, 128, 128, 128, 129, 1, 1, 1, 2)
+	data = append(data, 64, 64, 64, 129, 0, 0, 0, 0, 128, 0, 0, 129)
+
+	m, err := Decode(bytes.NewReader(data))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for x := 0; x < 3; x++ {
+		if r, _, _ := m.At(x, 1); !near(r, 1) {
+			t.Errorf("bottom row pixel %d red = %g, want 1", x, r)
+		}
+	}
+	if r, _, _ := m.At(0, 0); !near(r, 0.5) {
+		t.Errorf("top row pixel 0 red = %g, want 0.5", r)
+	}
+	if r, g, b := m.At(1, 0); 0 != r || 0 != g || 0 != b {
+		t.Errorf("zero exponent pixel = (%g, %g, %g)", r, g, b)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"magic":      "P6\n1 1\n",
+		"format":     "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n\x80\x80\x80\x81",
+		"transposed": "#?RADIANCE\n\n+X 1 -Y 1\n\x80\x80\x80\x81",
+		"truncated":  "#?RADIANCE\n\n-Y 2 +X 1\n\x80\x80\x80\x81",
+	} {
+		if _, err := Decode(strings.NewReader(data)); nil == err {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
+
+func TestFlipV(t *testing.T) {
+	m := NewImage(1, 3)
+	for y := 0; y < 3; y++ {
+		m.Set(0, y, float32(y), 0, 0)
+	}
+	m.FlipV()
+	for y := 0; y < 3; y++ {
+		if r, _, _ := m.At(0, y); r != float32(2-y) {
+			t.Errorf("row %d holds %g", y, r)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package gfx
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// VertexAttrib describes one attribute of an interleaved vertex.
+type VertexAttrib struct {
+	Name     string // the "in" variable, used by Bind
+	Location uint32 // used by BindLocations
+	Size     int32  // components, 1 to 4
+	Type     uint32 // gl.FLOAT when 0, or gl.BYTE, gl.UNSIGNED_BYTE, gl.SHORT, ...
+
+	// Normalized maps integer types to [0, 1] or [-1, 1].
+	Normalized bool
+	// Integer feeds integer types to int/ivec inputs unconverted, through
+	// glVertexAttribIPointer.
+	Integer bool
+	// Divisor advances the attribute once per Divisor instances instead of
+	// once per vertex, 0 for per-vertex data.
+	Divisor uint32
+
+	offset int
+}
+
+// VertexLayout lays attributes out one after the other in a vertex and
+// points a vertex array at them, replacing hand-computed strides and
+// offsets.
+type VertexLayout struct {
+	Attribs []VertexAttrib
+	stride  int
+}
+
+// NewVertexLayout computes the offsets and stride of attribs, packed in
+// the order given. The layout keeps a copy, attribs is left as it was.
+func NewVertexLayout(attribs ...VertexAttrib) (*VertexLayout, error) {
+	l := &VertexLayout{Attribs: append([]VertexAttrib(nil), attribs...)}
+	seen := map[string]bool{}
+	for i := range l.Attribs {
+		a := &l.Attribs[i]
+		if 0 == a.Type {
+			a.Type = gl.FLOAT
+		}
+		if a.Size < 1 || a.Size > 4 {
+			return nil, fmt.Errorf("vertex attribute %q has %d components, want 1 to 4", a.Name, a.Size)
+		}
+		size := typeSize(a.Type)
+		if 0 == size {
+			return nil, fmt.Errorf("vertex attribute %q has unsupported type 0x%x", a.Name, a.Type)
+		}
+		if a.Integer && (gl.FLOAT == a.Type || gl.HALF_FLOAT == a.Type || a.Normalized) {
+			return nil, fmt.Errorf("vertex attribute %q is Integer but not an unnormalized integer type", a.Name)
+		}
+		if "" != a.Name {
+			if seen[a.Name] {
+				return nil, fmt.Errorf("vertex attribute %q appears twice", a.Name)
+			}
+			seen[a.Name] = true
+		}
+		a.offset = l.stride
+		l.stride += int(a.Size) * size
+	}
+	return l, nil
+}
+
+// MustVertexLayout is NewVertexLayout for layouts fixed in the source,
+// it panics on error.
+func MustVertexLayout(attribs ...VertexAttrib) *VertexLayout {
+	l, err := NewVertexLayout(attribs...)
+	if nil != err {
+		panic(err)
+	}
+	return l
+}
+
+func typeSize(xtype uint32) int {
+	switch xtype {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return 2
+	case gl.INT, gl.UNSIGNED_INT, gl.FLOAT:
+		return 4
+	}
+	return 0
+}
+
+// Stride returns the size of one vertex in bytes.
+func (l *VertexLayout) Stride() int {
+	return l.stride
+}
+
+// Offset returns the byte offset of the attribute called name within a
+// vertex, -1 if there is none.
+func (l *VertexLayout) Offset(name string) int {
+	for _, a := range l.Attribs {
+		if a.Name == name {
+			return a.offset
+		}
+	}
+	return -1
+}
+
+// Validate checks that vertices holds whole vertices of the layout, and
+// returns how many.
+func (l *VertexLayout) Validate(vertices []float32) (int, error) {
+	if 0 != l.stride%4 {
+		return 0, fmt.Errorf("vertex layout stride %d is not a whole number of floats", l.stride)
+	}
+	floats := l.stride / 4
+	if 0 == floats || 0 != len(vertices)%floats {
+		return 0, fmt.Errorf("%d floats is not a whole number of %d float vertices", len(vertices), floats)
+	}
+	return len(vertices) / floats, nil
+}
+
+// Bind points the attributes of the bound vertex array at the buffer bound
+// to ARRAY_BUFFER, looking their locations up by name in program.
+// Attributes the program does not use are skipped, the linker drops inputs
+// that do not reach an output.
+func (l *VertexLayout) Bind(program *Program) error {
+	for _, a := range l.Attribs {
+		if "" == a.Name {
+			return fmt.Errorf("vertex attribute at offset %d has no name to bind by", a.offset)
+		}
+		location := gl.GetAttribLocation(program.ID, gl.Str(cString(a.Name)))
+		if location < 0 {
+			continue
+		}
+		a.point(uint32(location), int32(l.stride))
+	}
+	return nil
+}
+
+// BindLocations is Bind for shaders that fix their input locations with
+// layout(location = n), using each attribute's Location.
+func (l *VertexLayout) BindLocations() {
+	for _, a := range l.Attribs {
+		a.point(a.Location, int32(l.stride))
+	}
+}
+
+func (a *VertexAttrib) point(location uint32, stride int32) {
+	gl.EnableVertexAttribArray(location)
+	if a.Integer {
+		gl.VertexAttribIPointer(location, a.Size, a.Type, stride, gl.PtrOffset(a.offset))
+	} else {
+		gl.VertexAttribPointer(location, a.Size, a.Type, a.Normalized, stride, gl.PtrOffset(a.offset))
+	}
+	gl.VertexAttribDivisor(location, a.Divisor)
+}
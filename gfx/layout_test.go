@@ -0,0 +1,73 @@
+package gfx
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestVertexLayoutOffsets(t *testing.T) {
+	l, err := NewVertexLayout(
+		VertexAttrib{Name: "position", Size: 3},
+		VertexAttrib{Name: "color", Size: 4, Type: gl.UNSIGNED_BYTE, Normalized: true},
+		VertexAttrib{Name: "uv", Size: 2},
+	)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if l.Stride() != 24 {
+		t.Errorf("stride %d, want 24", l.Stride())
+	}
+	for name, want := range map[string]int{"position": 0, "color": 12, "uv": 16, "missing": -1} {
+		if got := l.Offset(name); got != want {
+			t.Errorf("offset of %s is %d, want %d", name, got, want)
+		}
+	}
+	if l.Attribs[0].Type != gl.FLOAT {
+		t.Errorf("default type 0x%x, want FLOAT", l.Attribs[0].Type)
+	}
+}
+
+func TestVertexLayoutCopiesAttribs(t *testing.T) {
+	attribs := []VertexAttrib{{Name: "position", Size: 3}, {Name: "uv", Size: 2}}
+	l, err := NewVertexLayout(attribs...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 0 != attribs[0].Type || 0 != attribs[1].offset {
+		t.Errorf("caller's attributes changed to %+v", attribs)
+	}
+	attribs[1].Name = "normal"
+	if l.Offset("uv") != 12 {
+		t.Errorf("layout follows the caller's slice, uv offset %d", l.Offset("uv"))
+	}
+}
+
+func TestVertexLayoutValidate(t *testing.T) {
+	l := MustVertexLayout(VertexAttrib{Name: "vert", Size: 3}, VertexAttrib{Name: "vertTexCoord", Size: 2})
+	if n, err := l.Validate(make([]float32, 36*5)); nil != err || n != 36 {
+		t.Errorf("36 vertices validate as %d, %v", n, err)
+	}
+	if _, err := l.Validate(make([]float32, 36*5-1)); nil == err {
+		t.Error("expected an error for a partial vertex")
+	}
+
+	odd := MustVertexLayout(VertexAttrib{Name: "flags", Size: 1, Type: gl.UNSIGNED_BYTE, Integer: true})
+	if _, err := odd.Validate(make([]float32, 4)); nil == err {
+		t.Error("expected an error for a stride that is not whole floats")
+	}
+}
+
+func TestVertexLayoutErrors(t *testing.T) {
+	cases := map[string][]VertexAttrib{
+		"size":      {{Name: "a", Size: 5}},
+		"type":      {{Name: "a", Size: 1, Type: gl.DOUBLE}},
+		"integer":   {{Name: "a", Size: 1, Integer: true}},
+		"duplicate": {{Name: "a", Size: 1}, {Name: "a", Size: 2}},
+	}
+	for name, attribs := range cases {
+		if _, err := NewVertexLayout(attribs...); nil == err {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gfx
+
+import (
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/alexniver/opengl-dev-go/gfx/meshcache"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Attribute locations NewGPUMesh binds the mesh attributes to. Shaders
+// declare them with layout(location = n).
+const (
+	AttribPosition = 0
+	AttribNormal   = 1
+	AttribUV       = 2
+	AttribTangent  = 3
+	AttribColor    = 4
+)
+
+var attribLocations = map[string]uint32{
+	"position": AttribPosition,
+	"normal":   AttribNormal,
+	"uv":       AttribUV,
+	"tangent":  AttribTangent,
+	"color":    AttribColor,
+}
+
+// GPUMesh is a mesh uploaded into a vertex array with its own vertex and
+// element buffers.
+type GPUMesh struct {
+	VAO, VBO, EBO uint32
+	Count         int32 // indices to draw
+
+	// Levels are the levels of detail uploaded by NewGPUMeshLODs, finest
+	// first, all in EBO.
+	Levels []GPULOD
+}
+
+// GPULOD is one level of detail of a GPUMesh.
+type GPULOD struct {
+	First, Count int32   // range of EBO, in indices
+	Error        float32 // mesh.LOD.Error
+}
+
+// MeshLayout returns the layout of m.Interleaved(), with each attribute
+// named as in mesh.Attribute and located at its Attrib* location.
+func MeshLayout(m *mesh.Mesh) *VertexLayout {
+	return attributeLayout(m.Attributes())
+}
+
+func attributeLayout(attrs []mesh.Attribute) *VertexLayout {
+	var attribs []VertexAttrib
+	for _, a := range attrs {
+		attribs = append(attribs, VertexAttrib{
+			Name:     a.Name,
+			Location: attribLocations[a.Name],
+			Size:     int32(a.Size),
+		})
+	}
+	return MustVertexLayout(attribs...)
+}
+
+// NewGPUMesh uploads m interleaved, with each attribute at its Attrib*
+// location. The vertex array is left bound.
+func NewGPUMesh(m *mesh.Mesh) *GPUMesh {
+	g := &GPUMesh{Count: int32(len(m.Indices))}
+	g.VBO = MakeVbo(m.Interleaved())
+	g.VAO = MakeVao(g.VBO)
+	MeshLayout(m).BindLocations()
+	g.EBO = MakeEbo(m.Indices)
+	return g
+}
+
+// NewGPUMeshLODs uploads m like NewGPUMesh, with the index buffers of lods,
+// as mesh.GenerateLODs makes them, one after another in the element buffer.
+// Draw draws the first level, DrawLOD any of them.
+func NewGPUMeshLODs(m *mesh.Mesh, lods []mesh.LOD) *GPUMesh {
+	var indices []uint32
+	var levels []GPULOD
+	for _, lod := range lods {
+		levels = append(levels, GPULOD{
+			First: int32(len(indices)),
+			Count: int32(len(lod.Indices)),
+			Error: lod.Error,
+		})
+		indices = append(indices, lod.Indices...)
+	}
+	if 0 == len(levels) {
+		return NewGPUMesh(m)
+	}
+	g := &GPUMesh{Count: levels[0].Count, Levels: levels}
+	g.VBO = MakeVbo(m.Interleaved())
+	g.VAO = MakeVao(g.VBO)
+	MeshLayout(m).BindLocations()
+	g.EBO = MakeEbo(indices)
+	return g
+}
+
+// LoadMeshCache uploads a file written by meshcache.Write, straight from
+// the memory it is mapped to, with its levels of detail.
+func LoadMeshCache(file string) (*GPUMesh, error) {
+	f, err := meshcache.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	return NewGPUMeshFromCache(f), nil
+}
+
+// NewGPUMeshFromCache uploads the vertex and index data of f as they are,
+// like NewGPUMeshLODs with the levels f holds. The vertex array is left
+// bound.
+func NewGPUMeshFromCache(f *meshcache.File) *GPUMesh {
+	g := &GPUMesh{}
+	for _, l := range f.Levels {
+		g.Levels = append(g.Levels, GPULOD{First: int32(l.First), Count: int32(l.Count), Error: l.Error})
+	}
+	if 0 != len(g.Levels) {
+		g.Count = g.Levels[0].Count
+	}
+
+	gl.GenBuffers(1, &g.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, g.VBO)
+	vertices := f.VertexData()
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	g.VAO = MakeVao(g.VBO)
+	attributeLayout(f.Attributes).BindLocations()
+
+	gl.GenBuffers(1, &g.EBO)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, g.EBO)
+	if indices := f.IndexData(); 0 != len(indices) {
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
+	}
+	return g
+}
+
+// SelectLOD returns the coarsest level whose error stays within maxPixels
+// on screen at distance, as mesh.SelectLOD does.
+func (g *GPUMesh) SelectLOD(distance float32, projection mgl32.Mat4, viewportHeight int, maxPixels float32) int {
+	lodError := func(i int) float32 { return g.Levels[i].Error }
+	return mesh.SelectLevel(len(g.Levels), lodError, distance, projection, viewportHeight, maxPixels)
+}
+
+// DrawLOD draws level of Levels with the program in use, or the whole
+// mesh if there are no levels.
+func (g *GPUMesh) DrawLOD(level int) {
+	if 0 == len(g.Levels) {
+		g.Draw()
+		return
+	}
+	l := g.Levels[level]
+	gl.BindVertexArray(g.VAO)
+	gl.DrawElements(gl.TRIANGLES, l.Count, gl.UNSIGNED_INT, gl.PtrOffset(4*int(l.First)))
+}
+
+// Draw draws the triangles with the program in use.
+func (g *GPUMesh) Draw() {
+	gl.BindVertexArray(g.VAO)
+	gl.DrawElements(gl.TRIANGLES, g.Count, gl.UNSIGNED_INT, gl.PtrOffset(0))
+}
+
+// Delete releases the buffers and the vertex array.
+func (g *GPUMesh) Delete() {
+	gl.DeleteVertexArrays(1, &g.VAO)
+	gl.DeleteBuffers(1, &g.VBO)
+	gl.DeleteBuffers(1, &g.EBO)
+	g.VAO, g.VBO, g.EBO, g.Count, g.Levels = 0, 0, 0, 0, nil
+}
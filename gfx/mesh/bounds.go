@@ -0,0 +1,74 @@
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Bounds is an axis-aligned bounding box. The bounds of an empty mesh
+// have Min > Max, see Empty.
+type Bounds struct {
+	Min, Max mgl32.Vec3
+}
+
+// Empty reports whether b contains no point.
+func (b Bounds) Empty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+// Center returns the middle of the box.
+func (b Bounds) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Size returns the extent of the box along each axis.
+func (b Bounds) Size() mgl32.Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// Radius returns the radius of the sphere around Center enclosing the box.
+func (b Bounds) Radius() float32 {
+	return b.Size().Len() / 2
+}
+
+// Extend returns b grown to contain p.
+func (b Bounds) Extend(p mgl32.Vec3) Bounds {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = float32(math.Min(float64(b.Min[i]), float64(p[i])))
+		b.Max[i] = float32(math.Max(float64(b.Max[i]), float64(p[i])))
+	}
+	return b
+}
+
+// Transform returns the box enclosing b transformed by mat.
+func (b Bounds) Transform(mat mgl32.Mat4) Bounds {
+	out := emptyBounds()
+	if b.Empty() {
+		return out
+	}
+	for i := 0; i < 8; i++ {
+		corner := b.Min
+		for axis := 0; axis < 3; axis++ {
+			if 0 != i&(1<<uint(axis)) {
+				corner[axis] = b.Max[axis]
+			}
+		}
+		out = out.Extend(mgl32.TransformCoordinate(corner, mat))
+	}
+	return out
+}
+
+func emptyBounds() Bounds {
+	inf := float32(math.Inf(1))
+	return Bounds{Min: mgl32.Vec3{inf, inf, inf}, Max: mgl32.Vec3{-inf, -inf, -inf}}
+}
+
+// Bounds returns the box around the positions.
+func (m *Mesh) Bounds() Bounds {
+	b := emptyBounds()
+	for _, p := range m.Positions {
+		b = b.Extend(p)
+	}
+	return b
+}
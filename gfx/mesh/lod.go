@@ -0,0 +1,78 @@
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LOD is one level of detail of a mesh: indices into its vertices and how
+// far, in mesh units, the surface may have moved to get there.
+type LOD struct {
+	Indices []uint32
+	Error   float32
+}
+
+// GenerateLODs returns up to levels levels of detail, the first being m's
+// own indices and each following one keeping ratio of the triangles of
+// the one before, 0.5 for instance. The chain ends early once nothing can
+// be collapsed any more, as when a border or seam is all that is left.
+// Each level is ordered for the vertex cache; all of them index m's
+// vertices, so one vertex buffer serves them all.
+func (m *Mesh) GenerateLODs(levels int, ratio float32) []LOD {
+	if levels < 1 {
+		return nil
+	}
+	lods := []LOD{{Indices: append([]uint32(nil), m.Indices...)}}
+	s := newSimplifier(m)
+	target := float32(s.live)
+	for len(lods) < levels {
+		target *= ratio
+		before := s.live
+		s.collapse(int(target), float32(math.Inf(1)))
+		if s.live == before {
+			break
+		}
+		lods = append(lods, LOD{
+			Indices: optimizeVertexCache(s.indices(), len(m.Positions)),
+			Error:   s.moved,
+		})
+	}
+	return lods
+}
+
+// ScreenSize returns how many pixels tall something size units across
+// looks from distance units away, seen through projection, as made by
+// mgl32.Perspective or mgl32.Ortho, in a viewport viewportHeight pixels
+// tall. At or behind the eye it is infinite.
+func ScreenSize(size, distance float32, projection mgl32.Mat4, viewportHeight int) float32 {
+	// clip w of a point distance in front of the eye: distance for a
+	// perspective projection, 1 for an orthographic one
+	w := projection[15] - projection[11]*distance
+	if w <= 0 {
+		return float32(math.Inf(1))
+	}
+	return size * projection[5] / w * float32(viewportHeight) / 2
+}
+
+// SelectLOD returns the index of the coarsest of lods, finest first as
+// GenerateLODs makes them, whose error stays within maxPixels on screen
+// when drawn distance units away; see ScreenSize. Use the distance to the
+// closest point of the mesh's bounds to be safe.
+func SelectLOD(lods []LOD, distance float32, projection mgl32.Mat4, viewportHeight int, maxPixels float32) int {
+	lodError := func(i int) float32 { return lods[i].Error }
+	return SelectLevel(len(lods), lodError, distance, projection, viewportHeight, maxPixels)
+}
+
+// SelectLevel is SelectLOD for count levels kept elsewhere, such as on the
+// GPU, finest first, where lodError(i) is the error of level i.
+func SelectLevel(count int, lodError func(i int) float32, distance float32, projection mgl32.Mat4, viewportHeight int, maxPixels float32) int {
+	selected := 0
+	for i := 0; i < count; i++ {
+		if ScreenSize(lodError(i), distance, projection, viewportHeight) > maxPixels {
+			break
+		}
+		selected = i
+	}
+	return selected
+}
@@ -0,0 +1,188 @@
+// Package mesh holds indexed triangle geometry on the CPU side, the common
+// output of the model loaders and input of gfx.MakeVbo and gfx.MakeEbo.
+//
+// A Mesh is plain slices of vertex data; the optimizer, the simplifier and
+// the bounds all work on those slices and leave uploading to package gfx.
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mesh is an indexed triangle list. Positions is the only attribute that is
+// required; every other attribute is either empty or has one entry per
+// position.
+type Mesh struct {
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	UVs       []mgl32.Vec2
+	Tangents  []mgl32.Vec4 // xyz is the tangent, w the sign of the bitangent
+	Colors    []mgl32.Vec4
+
+	Indices []uint32 // three per triangle, counter-clockwise
+}
+
+// Attribute is one field of an interleaved vertex, offsets and sizes are in
+// floats.
+type Attribute struct {
+	Name   string // "position", "normal", "uv", "tangent" or "color"
+	Size   int
+	Offset int
+}
+
+// VertexCount returns the number of vertices.
+func (m *Mesh) VertexCount() int {
+	return len(m.Positions)
+}
+
+// TriangleCount returns the number of triangles.
+func (m *Mesh) TriangleCount() int {
+	return len(m.Indices) / 3
+}
+
+// Validate checks that the attributes line up with the positions and that
+// every index is in range.
+func (m *Mesh) Validate() error {
+	n := len(m.Positions)
+	for _, a := range []struct {
+		name string
+		len  int
+	}{
+		{"normals", len(m.Normals)},
+		{"uvs", len(m.UVs)},
+		{"tangents", len(m.Tangents)},
+		{"colors", len(m.Colors)},
+	} {
+		if 0 != a.len && n != a.len {
+			return fmt.Errorf("mesh: %d %s for %d positions", a.len, a.name, n)
+		}
+	}
+	if 0 != len(m.Indices)%3 {
+		return fmt.Errorf("mesh: %d indices is not a whole number of triangles", len(m.Indices))
+	}
+	for i, index := range m.Indices {
+		if int(index) >= n {
+			return fmt.Errorf("mesh: index %d at %d out of range, %d vertices", index, i, n)
+		}
+	}
+	return nil
+}
+
+// Attributes lists the fields of Interleaved in order: position, then
+// normal, uv, tangent and color for the attributes present.
+func (m *Mesh) Attributes() []Attribute {
+	attrs := []Attribute{{Name: "position", Size: 3}}
+	if 0 != len(m.Normals) {
+		attrs = append(attrs, Attribute{Name: "normal", Size: 3})
+	}
+	if 0 != len(m.UVs) {
+		attrs = append(attrs, Attribute{Name: "uv", Size: 2})
+	}
+	if 0 != len(m.Tangents) {
+		attrs = append(attrs, Attribute{Name: "tangent", Size: 4})
+	}
+	if 0 != len(m.Colors) {
+		attrs = append(attrs, Attribute{Name: "color", Size: 4})
+	}
+	offset := 0
+	for i := range attrs {
+		attrs[i].Offset = offset
+		offset += attrs[i].Size
+	}
+	return attrs
+}
+
+// Stride returns the size of one interleaved vertex in floats.
+func (m *Mesh) Stride() int {
+	stride := 0
+	for _, a := range m.Attributes() {
+		stride += a.Size
+	}
+	return stride
+}
+
+// Interleaved packs the attributes into one slice for gfx.MakeVbo, laid out
+// as Attributes describes.
+func (m *Mesh) Interleaved() []float32 {
+	stride := m.Stride()
+	out := make([]float32, 0, stride*len(m.Positions))
+	for i, p := range m.Positions {
+		out = append(out, p[:]...)
+		if 0 != len(m.Normals) {
+			out = append(out, m.Normals[i][:]...)
+		}
+		if 0 != len(m.UVs) {
+			out = append(out, m.UVs[i][:]...)
+		}
+		if 0 != len(m.Tangents) {
+			out = append(out, m.Tangents[i][:]...)
+		}
+		if 0 != len(m.Colors) {
+			out = append(out, m.Colors[i][:]...)
+		}
+	}
+	return out
+}
+
+// Transform moves the mesh by mat: positions as points, normals by the
+// inverse transpose and tangents as directions. A mirroring mat also
+// reverses the triangles and the tangent signs, so the mesh stays
+// counter-clockwise.
+func (m *Mesh) Transform(mat mgl32.Mat4) {
+	linear := mat.Mat3()
+	normal := linear.Inv().Transpose()
+	mirror := linear.Det() < 0
+	for i, p := range m.Positions {
+		m.Positions[i] = mgl32.TransformCoordinate(p, mat)
+	}
+	for i, n := range m.Normals {
+		m.Normals[i] = safeNormalize(normal.Mul3x1(n), n)
+	}
+	for i, t := range m.Tangents {
+		w := t[3]
+		if mirror {
+			w = -w
+		}
+		m.Tangents[i] = safeNormalize(linear.Mul3x1(t.Vec3()), t.Vec3()).Vec4(w)
+	}
+	if mirror {
+		for c := 0; c+2 < len(m.Indices); c += 3 {
+			m.Indices[c+1], m.Indices[c+2] = m.Indices[c+2], m.Indices[c+1]
+		}
+	}
+}
+
+// Append adds the vertices and triangles of o to m. Both need the same
+// attributes.
+func (m *Mesh) Append(o *Mesh) error {
+	if 0 != len(m.Positions) {
+		a, b := m.Attributes(), o.Attributes()
+		same := len(a) == len(b)
+		for i := 0; same && i < len(a); i++ {
+			same = a[i].Name == b[i].Name
+		}
+		if !same {
+			return fmt.Errorf("mesh: cannot append a mesh with attributes %v to one with %v", names(b), names(a))
+		}
+	}
+	base := uint32(len(m.Positions))
+	m.Positions = append(m.Positions, o.Positions...)
+	m.Normals = append(m.Normals, o.Normals...)
+	m.UVs = append(m.UVs, o.UVs...)
+	m.Tangents = append(m.Tangents, o.Tangents...)
+	m.Colors = append(m.Colors, o.Colors...)
+	for _, index := range o.Indices {
+		m.Indices = append(m.Indices, base+index)
+	}
+	return nil
+}
+
+func names(attrs []Attribute) []string {
+	var out []string
+	for _, a := range attrs {
+		out = append(out, a.Name)
+	}
+	return out
+}
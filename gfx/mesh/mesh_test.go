@@ -0,0 +1,97 @@
+package mesh
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func triangle() *Mesh {
+	return &Mesh{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+		Indices:   []uint32{0, 1, 2},
+	}
+}
+
+func TestInterleaved(t *testing.T) {
+	m := triangle()
+	if err := m.Validate(); nil != err {
+		t.Fatal(err)
+	}
+	if m.Stride() != 5 {
+		t.Fatalf("stride %d, want 5", m.Stride())
+	}
+	attrs := m.Attributes()
+	if len(attrs) != 2 || attrs[1].Name != "uv" || attrs[1].Offset != 3 {
+		t.Fatalf("attributes %+v", attrs)
+	}
+	want := []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1}
+	got := m.Interleaved()
+	if len(got) != len(want) {
+		t.Fatalf("%d floats, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("interleaved %v, want %v", got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m := triangle()
+	m.Normals = []mgl32.Vec3{{0, 0, 1}}
+	if nil == m.Validate() {
+		t.Error("expected an error for a short normal slice")
+	}
+
+	m = triangle()
+	m.Indices = []uint32{0, 1, 3}
+	if nil == m.Validate() {
+		t.Error("expected an error for an index out of range")
+	}
+
+	m = triangle()
+	m.Indices = m.Indices[:2]
+	if nil == m.Validate() {
+		t.Error("expected an error for a partial triangle")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	m := triangle()
+	m.Normals = []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+	m.Tangents = []mgl32.Vec4{{1, 0, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1}}
+	// mirror in x and stretch in y
+	m.Transform(mgl32.Scale3D(-1, 2, 1))
+	if m.Positions[1] != (mgl32.Vec3{-1, 0, 0}) || m.Positions[2] != (mgl32.Vec3{0, 2, 0}) {
+		t.Errorf("positions %v", m.Positions)
+	}
+	if m.Indices[1] != 2 || m.Indices[2] != 1 {
+		t.Errorf("mirrored triangle not reversed: %v", m.Indices)
+	}
+	if m.Tangents[0] != (mgl32.Vec4{-1, 0, 0, -1}) || m.Normals[0] != (mgl32.Vec3{0, 0, 1}) {
+		t.Errorf("tangent %v normal %v", m.Tangents[0], m.Normals[0])
+	}
+	// the triangle still faces its normal
+	a, b, c := m.Positions[m.Indices[0]], m.Positions[m.Indices[1]], m.Positions[m.Indices[2]]
+	if b.Sub(a).Cross(c.Sub(a)).Dot(m.Normals[0]) <= 0 {
+		t.Error("winding does not match the normal")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	m := triangle()
+	if err := m.Append(triangle()); nil != err {
+		t.Fatal(err)
+	}
+	if m.VertexCount() != 6 || m.Indices[3] != 3 || m.Indices[5] != 5 {
+		t.Errorf("%d vertices, indices %v", m.VertexCount(), m.Indices)
+	}
+	if err := m.Validate(); nil != err {
+		t.Error(err)
+	}
+	if err := m.Append(&Mesh{Positions: []mgl32.Vec3{{}}}); nil == err {
+		t.Error("appended a mesh without uvs")
+	}
+}
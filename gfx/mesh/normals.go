@@ -0,0 +1,110 @@
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ComputeNormals replaces the normals with ones computed from the faces.
+// Faces meeting at a position share a normal when their own normals are
+// at most angle radians apart; vertices are split where they do not, so
+// hard edges stay hard even if the vertex was shared. Each face counts in
+// proportion to its corner angle.
+//
+// angle 0 gives flat shading, math.Pi smooths everything. Tangents are
+// copied to split vertices as they were, compute them again afterwards.
+func (m *Mesh) ComputeNormals(angle float32) {
+	faces := make([]mgl32.Vec3, len(m.Indices)/3)
+	weights := make([]float32, len(m.Indices))
+	for f := range faces {
+		tri := m.Indices[3*f : 3*f+3]
+		a, b, c := m.Positions[tri[0]], m.Positions[tri[1]], m.Positions[tri[2]]
+		faces[f] = safeNormalize(b.Sub(a).Cross(c.Sub(a)), mgl32.Vec3{})
+		weights[3*f] = cornerAngle(a, b, c)
+		weights[3*f+1] = cornerAngle(b, c, a)
+		weights[3*f+2] = cornerAngle(c, a, b)
+	}
+
+	// corners by position, so seams in the UVs do not show in the shading
+	byPosition := map[mgl32.Vec3][]int{}
+	for corner := range m.Indices[:3*len(faces)] {
+		p := m.Positions[m.Indices[corner]]
+		byPosition[p] = append(byPosition[p], corner)
+	}
+
+	limit := float32(math.Cos(float64(angle))) - 1e-5
+	normals := make([]mgl32.Vec3, 3*len(faces))
+	for _, corners := range byPosition {
+		for _, c := range corners {
+			own := faces[c/3]
+			var sum mgl32.Vec3
+			// a face without area takes the normal of everything around it
+			degenerate := own == (mgl32.Vec3{})
+			for _, d := range corners {
+				if degenerate || faces[d/3].Dot(own) >= limit {
+					sum = sum.Add(faces[d/3].Mul(weights[d]))
+				}
+			}
+			normals[c] = safeNormalize(sum, safeNormalize(own, mgl32.Vec3{0, 1, 0}))
+		}
+	}
+
+	// the first normal a vertex gets keeps its index, others make copies
+	// after the existing vertices
+	type key struct {
+		vertex uint32
+		normal mgl32.Vec3
+	}
+	n := len(m.Positions)
+	sources := make([]uint32, n)
+	for i := range sources {
+		sources[i] = uint32(i)
+	}
+	out := make([]mgl32.Vec3, n)
+	assigned := make([]bool, n)
+	copies := map[key]uint32{}
+	indices := make([]uint32, len(normals))
+	for c, normal := range normals {
+		v := m.Indices[c]
+		switch {
+		case !assigned[v]:
+			assigned[v], out[v] = true, normal
+		case out[v] != normal:
+			k := key{v, normal}
+			index, ok := copies[k]
+			if !ok {
+				index = uint32(len(sources))
+				sources = append(sources, v)
+				out = append(out, normal)
+				copies[k] = index
+			}
+			v = index
+		}
+		indices[c] = v
+	}
+	m.rebuild(sources, indices)
+	m.Normals = out
+}
+
+// FlatNormals gives every face its own normal, ComputeNormals(0).
+func (m *Mesh) FlatNormals() {
+	m.ComputeNormals(0)
+}
+
+// SmoothNormals averages the normals of all faces around a position,
+// ComputeNormals(math.Pi).
+func (m *Mesh) SmoothNormals() {
+	m.ComputeNormals(math.Pi)
+}
+
+// cornerAngle returns the angle at a of triangle abc.
+func cornerAngle(a, b, c mgl32.Vec3) float32 {
+	e1, e2 := b.Sub(a), c.Sub(a)
+	l := e1.Len() * e2.Len()
+	if 0 == l {
+		return 0
+	}
+	cos := math.Max(-1, math.Min(1, float64(e1.Dot(e2)/l)))
+	return float32(math.Acos(cos))
+}
@@ -0,0 +1,355 @@
+package mesh
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// CacheSize is the post-transform cache size the optimizations target and
+// Stats simulates, small enough to suit every GPU.
+const CacheSize = 16
+
+// CacheStats is the outcome of running indices through a simulated FIFO
+// post-transform cache.
+type CacheStats struct {
+	// Transformed counts the vertex shader invocations, the cache misses.
+	Transformed int
+	// ACMR is the average number of vertices transformed per triangle, 3
+	// when nothing is reused; large regular grids get close to 0.5.
+	ACMR float32
+	// ATVR is the number transformed per distinct vertex used, 1 at best.
+	ATVR float32
+}
+
+// HitRate is the fraction of vertex references served by the cache.
+func (s CacheStats) HitRate() float32 {
+	if 0 == s.ACMR {
+		return 0
+	}
+	return 1 - s.ACMR/3
+}
+
+// SimulateCache runs indices through a FIFO cache of cacheSize vertices,
+// which is how most GPUs have behaved for a long time.
+func SimulateCache(indices []uint32, cacheSize int) CacheStats {
+	var stats CacheStats
+	for _, miss := range triangleMisses(indices, cacheSize) {
+		stats.Transformed += miss
+	}
+	distinct := map[uint32]bool{}
+	for _, v := range indices {
+		distinct[v] = true
+	}
+	if triangles := len(indices) / 3; 0 != triangles {
+		stats.ACMR = float32(stats.Transformed) / float32(triangles)
+		stats.ATVR = float32(stats.Transformed) / float32(len(distinct))
+	}
+	return stats
+}
+
+// Stats simulates drawing m with a CacheSize vertex cache.
+func (m *Mesh) Stats() CacheStats {
+	return SimulateCache(m.Indices, CacheSize)
+}
+
+// Optimize reorders the triangles for the vertex cache and then the
+// vertices for fetching. The triangles, and how they look, are unchanged.
+// To sort for overdraw too, call OptimizeVertexCache, OptimizeOverdraw and
+// OptimizeVertexFetch in that order instead.
+func (m *Mesh) Optimize() {
+	m.OptimizeVertexCache()
+	m.OptimizeVertexFetch()
+}
+
+// Tom Forsyth's "Linear-Speed Vertex Cache Optimisation" scoring.
+const (
+	forsythCacheSize       = 32
+	forsythDecayPower      = 1.5
+	forsythLastTriangle    = 0.75
+	forsythValenceScale    = 2
+	forsythValencePower    = 0.5
+	forsythNotInCache      = -1
+	forsythNoTrianglesLeft = -1
+)
+
+// forsythScore rates a vertex at position in the LRU cache, or not in it,
+// with valence triangles left to draw.
+func forsythScore(position, valence int) float32 {
+	if 0 == valence {
+		return forsythNoTrianglesLeft
+	}
+	var score float64
+	switch {
+	case forsythNotInCache == position:
+	case position < 3:
+		// the last triangle's vertices, fixed so strips are not favoured
+		score = forsythLastTriangle
+	default:
+		score = math.Pow(1-float64(position-3)/(forsythCacheSize-3), forsythDecayPower)
+	}
+	// vertices with few triangles left are finished first
+	return float32(score + forsythValenceScale*math.Pow(float64(valence), -forsythValencePower))
+}
+
+// OptimizeVertexCache reorders the triangles so vertices are reused while
+// the GPU still has them transformed, with Tom Forsyth's algorithm. The
+// vertices stay where they are, OptimizeVertexFetch puts them in the new
+// order.
+func (m *Mesh) OptimizeVertexCache() {
+	m.Indices = optimizeVertexCache(m.Indices, len(m.Positions))
+}
+
+func optimizeVertexCache(indices []uint32, vertexCount int) []uint32 {
+	triangles := len(indices) / 3
+
+	// the triangles still to draw of each vertex, packed in adjacency
+	valence := make([]int, vertexCount)
+	for _, v := range indices[:3*triangles] {
+		valence[v]++
+	}
+	offsets := make([]int, vertexCount+1)
+	for v, n := range valence {
+		offsets[v+1] = offsets[v] + n
+	}
+	adjacency := make([]int, offsets[vertexCount])
+	fill := append([]int(nil), offsets[:vertexCount]...)
+	for t := 0; t < triangles; t++ {
+		for _, v := range indices[3*t : 3*t+3] {
+			adjacency[fill[v]] = t
+			fill[v]++
+		}
+	}
+	remaining := func(v uint32) []int {
+		return adjacency[offsets[v] : offsets[v]+valence[v]]
+	}
+
+	position := make([]int, vertexCount)
+	score := make([]float32, vertexCount)
+	for v := range score {
+		position[v] = forsythNotInCache
+		score[v] = forsythScore(forsythNotInCache, valence[v])
+	}
+	triangleScore := make([]float32, triangles)
+	for t := range triangleScore {
+		for _, v := range indices[3*t : 3*t+3] {
+			triangleScore[t] += score[v]
+		}
+	}
+
+	emitted := make([]bool, triangles)
+	out := make([]uint32, 0, 3*triangles)
+	// the cache briefly holds the three new vertices on top of a full one
+	cache := make([]uint32, 0, forsythCacheSize+3)
+	next := make([]uint32, 0, forsythCacheSize+3)
+	best, cursor := -1, 0
+	for len(out) < 3*triangles {
+		if best < 0 {
+			// nothing in the cache has triangles left, start elsewhere
+			for emitted[cursor] {
+				cursor++
+			}
+			best = cursor
+		}
+		triangle := indices[3*best : 3*best+3]
+		out = append(out, triangle...)
+		emitted[best] = true
+
+		next = next[:0]
+		for _, v := range triangle {
+			if !contains(next, v) {
+				next = append(next, v)
+			}
+			list := remaining(v)
+			for i, t := range list {
+				if t == best {
+					list[i] = list[len(list)-1]
+					break
+				}
+			}
+			valence[v]--
+		}
+		for _, v := range cache {
+			if !contains(triangle, v) {
+				next = append(next, v)
+			}
+		}
+		cache, next = next, cache
+
+		// rescore what moved in the cache, including what fell out of it
+		for i, v := range cache {
+			position[v] = i
+			if i >= forsythCacheSize {
+				position[v] = forsythNotInCache
+			}
+			s := forsythScore(position[v], valence[v])
+			for _, t := range remaining(v) {
+				triangleScore[t] += s - score[v]
+			}
+			score[v] = s
+		}
+		if len(cache) > forsythCacheSize {
+			cache = cache[:forsythCacheSize]
+		}
+
+		best = -1
+		bestScore := float32(math.Inf(-1))
+		for _, v := range cache {
+			for _, t := range remaining(v) {
+				if triangleScore[t] > bestScore {
+					best, bestScore = t, triangleScore[t]
+				}
+			}
+		}
+	}
+	return out
+}
+
+func contains(vertices []uint32, v uint32) bool {
+	for _, u := range vertices {
+		if u == v {
+			return true
+		}
+	}
+	return false
+}
+
+// OptimizeVertexFetch renumbers the vertices in the order the triangles
+// first use them, so the GPU reads vertex memory front to back, and drops
+// vertices no triangle uses.
+func (m *Mesh) OptimizeVertexFetch() {
+	const unused = ^uint32(0)
+	remap := make([]uint32, len(m.Positions))
+	for i := range remap {
+		remap[i] = unused
+	}
+	var sources []uint32
+	indices := make([]uint32, len(m.Indices))
+	for c, v := range m.Indices {
+		if unused == remap[v] {
+			remap[v] = uint32(len(sources))
+			sources = append(sources, v)
+		}
+		indices[c] = remap[v]
+	}
+	m.rebuild(sources, indices)
+}
+
+// OptimizeOverdraw reorders the triangles, after OptimizeVertexCache, so
+// that those likely to hide others are drawn first from any direction,
+// letting the depth test skip more fragments. Triangles are moved in
+// clusters that start where the vertex cache would have to start over
+// anyway; threshold is how much worse, as a factor of the ACMR, the cache
+// use may get to make the clusters smaller. 1.05 is a good compromise, 1
+// keeps the cache use as it is.
+//
+// Clusters facing away from the middle of the mesh go first: on a convex
+// shape those are exactly the ones that cover the rest.
+func (m *Mesh) OptimizeOverdraw(threshold float32) {
+	triangles := len(m.Indices) / 3
+	if 0 == triangles {
+		return
+	}
+
+	type cluster struct {
+		first, count int
+		sort         float32
+	}
+	var clusters []cluster
+	// hard boundaries, where all three vertices of a triangle miss
+	var hard []int
+	misses := triangleMisses(m.Indices, CacheSize)
+	for t, miss := range misses {
+		if 0 == t || 3 == miss {
+			hard = append(hard, t)
+		}
+	}
+	hard = append(hard, triangles)
+	for i := 0; i+1 < len(hard); i++ {
+		first, end := hard[i], hard[i+1]
+		total := 0
+		for _, miss := range misses[first:end] {
+			total += miss
+		}
+		limit := float32(total) / float32(end-first) * threshold
+
+		// soft boundaries, wherever the cluster so far, simulated from a
+		// cold cache, does no worse than the limit
+		start := first
+		for start < end {
+			loaded := map[uint32]int{}
+			transformed, t := 0, start
+			for t < end {
+				for _, v := range m.Indices[3*t : 3*t+3] {
+					if when, ok := loaded[v]; !ok || transformed-when > CacheSize {
+						loaded[v] = transformed
+						transformed++
+					}
+				}
+				t++
+				if threshold > 1 && t < end && float32(transformed)/float32(t-start) <= limit {
+					break
+				}
+			}
+			clusters = append(clusters, cluster{first: start, count: t - start})
+			start = t
+		}
+	}
+
+	centroid := func(first, count int) (mgl32.Vec3, mgl32.Vec3, float32) {
+		var center, normal mgl32.Vec3
+		var area float32
+		for t := first; t < first+count; t++ {
+			a := m.Positions[m.Indices[3*t]]
+			b := m.Positions[m.Indices[3*t+1]]
+			c := m.Positions[m.Indices[3*t+2]]
+			n := b.Sub(a).Cross(c.Sub(a))
+			w := n.Len()
+			center = center.Add(a.Add(b).Add(c).Mul(w / 3))
+			normal = normal.Add(n)
+			area += w
+		}
+		return center, normal, area
+	}
+	meshCenter, _, meshArea := centroid(0, triangles)
+	if 0 != meshArea {
+		meshCenter = meshCenter.Mul(1 / meshArea)
+	}
+	for i, c := range clusters {
+		center, normal, area := centroid(c.first, c.count)
+		if 0 == area {
+			continue
+		}
+		center = center.Mul(1 / area)
+		clusters[i].sort = center.Sub(meshCenter).Dot(safeNormalize(normal, mgl32.Vec3{}))
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].sort > clusters[j].sort
+	})
+
+	indices := make([]uint32, 0, len(m.Indices))
+	for _, c := range clusters {
+		indices = append(indices, m.Indices[3*c.first:3*(c.first+c.count)]...)
+	}
+	m.Indices = indices
+}
+
+// triangleMisses runs indices through a FIFO cache of cacheSize vertices
+// and returns how many vertices each triangle had to transform.
+func triangleMisses(indices []uint32, cacheSize int) []int {
+	misses := make([]int, len(indices)/3)
+	loaded := map[uint32]int{}
+	// a vertex is cached while fewer than cacheSize others were loaded
+	// after it
+	transformed := 0
+	for c, v := range indices[:3*len(misses)] {
+		if when, ok := loaded[v]; ok && transformed-when <= cacheSize {
+			continue
+		}
+		loaded[v] = transformed
+		transformed++
+		misses[c/3]++
+	}
+	return misses
+}
@@ -0,0 +1,126 @@
+package mesh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSimulateCache(t *testing.T) {
+	stats := SimulateCache([]uint32{0, 1, 2, 2, 1, 3}, CacheSize)
+	if stats.Transformed != 4 || stats.ACMR != 2 || stats.ATVR != 1 {
+		t.Errorf("got %+v", stats)
+	}
+	// with room for two vertices 0 is gone when it comes back
+	stats = SimulateCache([]uint32{0, 1, 2, 0, 2, 3}, 2)
+	if stats.Transformed != 5 {
+		t.Errorf("got %d transformed, want 5", stats.Transformed)
+	}
+}
+
+// shuffled returns m with its triangles in random order, the worst case for
+// the vertex cache.
+func shuffled(m *Mesh) *Mesh {
+	r := rand.New(rand.NewSource(1))
+	triangles := len(m.Indices) / 3
+	indices := make([]uint32, 0, len(m.Indices))
+	for _, t := range r.Perm(triangles) {
+		indices = append(indices, m.Indices[3*t:3*t+3]...)
+	}
+	m.Indices = indices
+	return m
+}
+
+// triangleSet lists the triangles of m by their corner positions, in a
+// canonical order.
+func triangleSet(m *Mesh) [][9]float32 {
+	var set [][9]float32
+	for c := 0; c+2 < len(m.Indices); c += 3 {
+		var tri [9]float32
+		for k := 0; k < 3; k++ {
+			copy(tri[3*k:], m.Positions[m.Indices[c+k]][:])
+		}
+		set = append(set, tri)
+	}
+	sort.Slice(set, func(i, j int) bool {
+		for k := range set[i] {
+			if set[i][k] != set[j][k] {
+				return set[i][k] < set[j][k]
+			}
+		}
+		return false
+	})
+	return set
+}
+
+func sameTriangles(t *testing.T, a, b *Mesh) {
+	sa, sb := triangleSet(a), triangleSet(b)
+	if len(sa) != len(sb) {
+		t.Fatalf("%d triangles, want %d", len(sb), len(sa))
+	}
+	for i := range sa {
+		if sa[i] != sb[i] {
+			t.Fatalf("triangle %v is missing", sa[i])
+		}
+	}
+}
+
+func TestOptimizeVertexCache(t *testing.T) {
+	m := shuffled(Plane(1, 1, 64, 64))
+	original := *m
+	before := m.Stats()
+	m.OptimizeVertexCache()
+	after := m.Stats()
+	t.Logf("ACMR %.3f -> %.3f", before.ACMR, after.ACMR)
+	if after.ACMR > 0.8 || after.ACMR >= before.ACMR {
+		t.Errorf("ACMR %.3f -> %.3f", before.ACMR, after.ACMR)
+	}
+	sameTriangles(t, &original, m)
+}
+
+func TestOptimizeVertexFetch(t *testing.T) {
+	m := shuffled(Sphere(1, 24, 16))
+	m.Positions = append(m.Positions, mgl32.Vec3{9, 9, 9}) // unused
+	m.Normals = append(m.Normals, mgl32.Vec3{0, 1, 0})
+	m.UVs = append(m.UVs, mgl32.Vec2{})
+	m.Tangents = append(m.Tangents, mgl32.Vec4{1, 0, 0, 1})
+	original := *m
+	m.Optimize()
+	if err := m.Validate(); nil != err {
+		t.Fatal(err)
+	}
+	if m.VertexCount() != original.VertexCount()-1 {
+		t.Errorf("%d vertices, the unused one should be gone", m.VertexCount())
+	}
+	// every vertex is first used after the ones before it
+	next := uint32(0)
+	for _, v := range m.Indices {
+		if v > next {
+			t.Fatalf("vertex %d is used before %d", v, next)
+		}
+		if v == next {
+			next++
+		}
+	}
+	sameTriangles(t, &original, m)
+}
+
+func TestOptimizeOverdraw(t *testing.T) {
+	m := shuffled(Torus(1, 0.3, 48, 24))
+	m.OptimizeVertexCache()
+	optimized := *m
+	before := m.Stats()
+	m.OptimizeOverdraw(1)
+	if got := m.Stats(); got.ACMR != before.ACMR {
+		t.Errorf("threshold 1 changed the ACMR from %.3f to %.3f", before.ACMR, got.ACMR)
+	}
+	m.OptimizeOverdraw(1.05)
+	after := m.Stats()
+	t.Logf("ACMR %.3f -> %.3f", before.ACMR, after.ACMR)
+	if after.ACMR > before.ACMR*1.1 {
+		t.Errorf("ACMR %.3f -> %.3f", before.ACMR, after.ACMR)
+	}
+	sameTriangles(t, &optimized, m)
+}
@@ -0,0 +1,119 @@
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Surface is a parametric surface over the unit square, u and v in [0, 1].
+type Surface struct {
+	Position func(u, v float32) mgl32.Vec3
+
+	// Normal returns the unit normal at (u, v). When nil the normal is
+	// worked out from finite differences of Position, pointing to the side
+	// dP/du x dP/dv points to.
+	Normal func(u, v float32) mgl32.Vec3
+}
+
+// differenceStep is the step of the finite differences. Positions are
+// float32, much smaller steps drown in rounding.
+const differenceStep = 1e-3
+
+// Parametric samples s on a cols x rows grid. u maps to the UV u and v to
+// the UV v; tangents follow dP/du. Where the surface pinches to a point,
+// like the poles of a sphere, the frame is taken from just beside it.
+func Parametric(s Surface, cols, rows int) *Mesh {
+	cols, rows = atLeast(cols, 1), atLeast(rows, 1)
+	m := &Mesh{}
+	m.grid(cols, rows, func(col, row int) vertex {
+		u, v := float32(col)/float32(cols), float32(row)/float32(rows)
+		n, t := s.frame(u, v)
+		return vertex{p: s.Position(u, v), n: n, t: t, uv: mgl32.Vec2{u, v}}
+	})
+	return m
+}
+
+// frame returns the normal and tangent at (u, v).
+func (s Surface) frame(u, v float32) (n, t mgl32.Vec3) {
+	du, dv := s.derivatives(u, v)
+	// a degenerate point borrows the derivatives of a point toward the
+	// middle of the square
+	for step := float32(differenceStep); step < 0.1 && du.Cross(dv).Len() < 1e-12; step *= 4 {
+		du, dv = s.derivatives(toward(u, step), toward(v, step))
+	}
+
+	if nil != s.Normal {
+		n = s.Normal(u, v)
+	} else {
+		n = safeNormalize(du.Cross(dv), mgl32.Vec3{0, 1, 0})
+	}
+	// Gram-Schmidt, so the tangent is perpendicular to whichever normal
+	t = du.Sub(n.Mul(n.Dot(du)))
+	return n, safeNormalize(t, anyPerpendicular(n))
+}
+
+// derivatives returns dP/du and dP/dv by central differences, one-sided at
+// the edges of the square.
+func (s Surface) derivatives(u, v float32) (du, dv mgl32.Vec3) {
+	h := float32(differenceStep)
+	u0, u1 := clamp01(u-h), clamp01(u+h)
+	v0, v1 := clamp01(v-h), clamp01(v+h)
+	du = s.Position(u1, v).Sub(s.Position(u0, v)).Mul(1 / (u1 - u0))
+	dv = s.Position(u, v1).Sub(s.Position(u, v0)).Mul(1 / (v1 - v0))
+	return du, dv
+}
+
+func toward(x, step float32) float32 {
+	if x > 0.5 {
+		return x - step
+	}
+	return x + step
+}
+
+func clamp01(x float32) float32 {
+	return float32(math.Max(0, math.Min(1, float64(x))))
+}
+
+func safeNormalize(v, fallback mgl32.Vec3) mgl32.Vec3 {
+	if l := v.Len(); l > 1e-12 {
+		return v.Mul(1 / l)
+	}
+	return fallback
+}
+
+func anyPerpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if math.Abs(float64(n[0])) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return n.Cross(axis).Normalize()
+}
+
+// EggSurface is a Hügelschäffer egg along the Y axis, a surface of
+// revolution whose profile is an ellipse pulled toward one end. length is
+// its extent along Y and breadth its widest diameter. asymmetry 0 gives an
+// ellipsoid; positive values move the wide part down and sharpen the top,
+// a hen's egg is around 0.1. It is clamped to [-0.45, 0.45], the shape
+// stops looking like an egg long before the formula breaks down at 0.5.
+//
+// v runs from the bottom to the top spaced like the latitudes of a sphere,
+// so rows bunch up where the profile curves most.
+func EggSurface(length, breadth, asymmetry float32) Surface {
+	a := float64(asymmetry)
+	a = math.Max(-0.45, math.Min(0.45, a))
+	L, B := float64(length), float64(breadth)
+	w := a * L
+	return Surface{Position: func(u, v float32) mgl32.Vec3 {
+		y := -L / 2 * math.Cos(math.Pi*float64(v))
+		r := B / 2 * math.Sqrt(math.Max(0, (L*L-4*y*y)/(L*L+8*w*y+4*w*w)))
+		radial, _ := around(u)
+		return radial.Mul(float32(r)).Add(mgl32.Vec3{0, float32(y), 0})
+	}}
+}
+
+// Egg samples EggSurface with segments columns around the Y axis and rings
+// rows from the bottom to the top.
+func Egg(length, breadth, asymmetry float32, segments, rings int) *Mesh {
+	return Parametric(EggSurface(length, breadth, asymmetry), atLeast(segments, 3), atLeast(rings, 2))
+}
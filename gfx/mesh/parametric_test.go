@@ -0,0 +1,90 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// unitSphere is Sphere as a Surface, without normals.
+var unitSphere = Surface{Position: func(u, v float32) mgl32.Vec3 {
+	radial, _ := around(u)
+	s, c := sincos(math.Pi * float64(v))
+	return radial.Mul(s).Sub(mgl32.Vec3{0, c, 0})
+}}
+
+func TestParametricSphere(t *testing.T) {
+	got := Parametric(unitSphere, 16, 8)
+	want := Sphere(1, 16, 8)
+	if got.VertexCount() != want.VertexCount() || len(got.Indices) != len(want.Indices) {
+		t.Fatalf("%d vertices %d indices, want %d and %d",
+			got.VertexCount(), len(got.Indices), want.VertexCount(), len(want.Indices))
+	}
+	for i := range want.Positions {
+		// finite differences, the poles included, match the analytic frame
+		if got.Normals[i].Sub(want.Normals[i]).Len() > 1e-2 {
+			t.Errorf("vertex %d normal %v, want %v", i, got.Normals[i], want.Normals[i])
+		}
+		if got.Tangents[i].Vec3().Sub(want.Tangents[i].Vec3()).Len() > 1e-2 {
+			t.Errorf("vertex %d tangent %v, want %v", i, got.Tangents[i], want.Tangents[i])
+		}
+		if got.UVs[i] != want.UVs[i] {
+			t.Errorf("vertex %d uv %v, want %v", i, got.UVs[i], want.UVs[i])
+		}
+	}
+	checkFrames(t, "parametric", got)
+	checkWinding(t, "parametric", got)
+}
+
+func TestParametricNormal(t *testing.T) {
+	up := mgl32.Vec3{0, 0, 1}
+	s := Surface{
+		Position: func(u, v float32) mgl32.Vec3 { return mgl32.Vec3{u, v, 0} },
+		Normal:   func(u, v float32) mgl32.Vec3 { return up },
+	}
+	m := Parametric(s, 2, 2)
+	for i, n := range m.Normals {
+		if n != up {
+			t.Fatalf("vertex %d normal %v, want the given %v", i, n, up)
+		}
+	}
+	checkFrames(t, "given normal", m)
+}
+
+func TestEgg(t *testing.T) {
+	m := Egg(2, 1.4, 0.15, 32, 24)
+	if err := m.Validate(); nil != err {
+		t.Fatal(err)
+	}
+	checkFrames(t, "egg", m)
+	checkWinding(t, "egg", m)
+
+	// the widest ring sits below the middle and both ends are at ±length/2
+	var widest, at, lo, hi float32
+	for _, p := range m.Positions {
+		if r := (mgl32.Vec2{p.X(), p.Z()}).Len(); r > widest {
+			widest, at = r, p.Y()
+		}
+		lo = float32(math.Min(float64(lo), float64(p.Y())))
+		hi = float32(math.Max(float64(hi), float64(p.Y())))
+	}
+	if at >= 0 {
+		t.Errorf("widest ring at y %v, want below 0", at)
+	}
+	if !near(lo, -1) || !near(hi, 1) {
+		t.Errorf("egg spans %v to %v, want -1 to 1", lo, hi)
+	}
+	if widest > 0.7+1e-4 {
+		t.Errorf("egg is %v wide, more than its breadth", 2*widest)
+	}
+
+	// without asymmetry it is an ellipsoid, symmetric about y = 0
+	e := EggSurface(2, 1, 0)
+	for _, v := range []float32{0.1, 0.3, 0.45} {
+		a, b := e.Position(0.25, v), e.Position(0.25, 1-v)
+		if !near(a.Y(), -b.Y()) || !near(a.X(), b.X()) || !near(a.Z(), b.Z()) {
+			t.Errorf("ellipsoid not symmetric: %v and %v", a, b)
+		}
+	}
+}
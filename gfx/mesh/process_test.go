@@ -0,0 +1,221 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// flatCube is Cube(2, 1) unwelded: 36 vertices with positions and uvs, like
+// the demos' cubeVertices.
+func flatCube(t *testing.T) *Mesh {
+	c := Cube(2, 1)
+	var vertices []float32
+	for _, i := range c.Indices {
+		vertices = append(vertices, c.Positions[i][:]...)
+		vertices = append(vertices, c.UVs[i][:]...)
+	}
+	m, err := FromVertices(vertices, Attribute{Name: "position", Size: 3}, Attribute{Name: "uv", Size: 2, Offset: 3})
+	if nil != err {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFromVertices(t *testing.T) {
+	m := flatCube(t)
+	if m.VertexCount() != 36 || m.TriangleCount() != 12 || nil != m.Normals {
+		t.Fatalf("%d vertices %d triangles", m.VertexCount(), m.TriangleCount())
+	}
+	if _, err := FromVertices(make([]float32, 7), Attribute{Name: "position", Size: 3}); nil == err {
+		t.Error("expected an error for a partial vertex")
+	}
+	if _, err := FromVertices(make([]float32, 6), Attribute{Name: "normal", Size: 3}); nil == err {
+		t.Error("expected an error without positions")
+	}
+	if _, err := FromVertices(make([]float32, 6), Attribute{Name: "position", Size: 2}); nil == err {
+		t.Error("expected an error for a two component position")
+	}
+}
+
+func TestWeld(t *testing.T) {
+	m := flatCube(t)
+	before := m.Interleaved()
+	distinct := map[[5]float32]bool{}
+	for i, p := range m.Positions {
+		distinct[[5]float32{p[0], p[1], p[2], m.UVs[i][0], m.UVs[i][1]}] = true
+	}
+	m.Weld(0)
+	if m.VertexCount() != len(distinct) {
+		t.Errorf("welded cube has %d vertices, want %d", m.VertexCount(), len(distinct))
+	}
+	if err := m.Validate(); nil != err {
+		t.Fatal(err)
+	}
+	// drawing the welded mesh gives the same triangles
+	stride := m.Stride()
+	after := m.Interleaved()
+	for c, index := range m.Indices {
+		for k := 0; k < stride; k++ {
+			if after[int(index)*stride+k] != before[c*stride+k] {
+				t.Fatalf("corner %d changed", c)
+			}
+		}
+	}
+
+	m.UVs = nil
+	m.Weld(0)
+	if m.VertexCount() != 8 {
+		t.Errorf("cube without uvs welds to %d vertices, want 8", m.VertexCount())
+	}
+}
+
+func TestWeldEpsilon(t *testing.T) {
+	m := &Mesh{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1.00001, 0, 0}, {0, 1, 0}, {0, -0.00001, 0}},
+		Indices:   []uint32{0, 1, 2, 3, 4, 5},
+	}
+	exact := *m
+	exact.Weld(0)
+	if exact.VertexCount() != 5 {
+		t.Errorf("exact weld kept %d vertices, want 5", exact.VertexCount())
+	}
+	m.Weld(1e-4)
+	if m.VertexCount() != 3 {
+		t.Errorf("weld within 1e-4 kept %d vertices, want 3", m.VertexCount())
+	}
+	if m.Indices[3] != 1 || m.Indices[5] != 0 {
+		t.Errorf("indices %v", m.Indices)
+	}
+}
+
+func TestComputeNormals(t *testing.T) {
+	m := flatCube(t)
+	m.UVs = nil
+	m.Weld(0)
+
+	m.SmoothNormals()
+	if m.VertexCount() != 8 {
+		t.Errorf("smooth cube has %d vertices, want 8", m.VertexCount())
+	}
+	for i, n := range m.Normals {
+		if want := m.Positions[i].Normalize(); n.Sub(want).Len() > 1e-5 {
+			t.Errorf("smooth normal %v at %v, want %v", n, m.Positions[i], want)
+		}
+	}
+
+	// a cube's edges are 90 degrees, a 45 degree threshold keeps them hard
+	m.ComputeNormals(math.Pi / 4)
+	if m.VertexCount() != 24 {
+		t.Errorf("hard edged cube has %d vertices, want 24", m.VertexCount())
+	}
+	checkWinding(t, "hard cube", &Mesh{Positions: m.Positions, Normals: m.Normals, Indices: m.Indices})
+	for i, n := range m.Normals {
+		if !near(n.Len(), 1) || !near(abs(n.X())+abs(n.Y())+abs(n.Z()), 1) {
+			t.Errorf("vertex %d normal %v is not along an axis", i, n)
+		}
+	}
+}
+
+func TestComputeNormalsSphere(t *testing.T) {
+	want := Sphere(1, 24, 12)
+	m := Sphere(1, 24, 12)
+	m.ComputeNormals(math.Pi / 3)
+	if m.VertexCount() != want.VertexCount() {
+		t.Fatalf("sphere split into %d vertices, want %d", m.VertexCount(), want.VertexCount())
+	}
+	for i, n := range m.Normals {
+		if want := m.Positions[i].Normalize(); n.Dot(want) < 0.99 {
+			t.Errorf("vertex %d normal %v, want about %v", i, n, want)
+		}
+	}
+}
+
+func TestComputeTangents(t *testing.T) {
+	want := Sphere(1, 32, 16)
+	m := Sphere(1, 32, 16)
+	m.Tangents = nil
+	if err := m.ComputeTangents(); nil != err {
+		t.Fatal(err)
+	}
+	if m.VertexCount() != want.VertexCount() {
+		t.Fatalf("%d vertices, want %d", m.VertexCount(), want.VertexCount())
+	}
+	checkFrames(t, "computed", m)
+	for i, tan := range m.Tangents {
+		p := m.Positions[i]
+		if math.Abs(float64(p.Y())) > 0.99 {
+			continue // the poles have no UV area around them
+		}
+		// the vertices may have been reordered, compare with the analytic
+		// tangent at the same u
+		_, analytic := around(m.UVs[i].X())
+		if tan.Vec3().Dot(analytic) < 0.99 || tan.W() != 1 {
+			t.Errorf("vertex %d tangent %v, want about %v", i, tan, analytic)
+		}
+	}
+
+	if err := (&Mesh{Positions: m.Positions}).ComputeTangents(); nil == err {
+		t.Error("expected an error without normals and uvs")
+	}
+}
+
+func TestComputeTangentsMirrored(t *testing.T) {
+	// two quads sharing an edge, the right one with its uvs mirrored in u
+	m := &Mesh{
+		Positions: []mgl32.Vec3{{-1, 0, 0}, {0, 0, 0}, {0, 1, 0}, {-1, 1, 0}, {1, 0, 0}, {1, 1, 0}},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}, {0, 1}},
+		Indices:   []uint32{0, 1, 2, 0, 2, 3, 1, 4, 5, 1, 5, 2},
+	}
+	m.Normals = make([]mgl32.Vec3, len(m.Positions))
+	for i := range m.Normals {
+		m.Normals[i] = mgl32.Vec3{0, 0, 1}
+	}
+	if err := m.ComputeTangents(); nil != err {
+		t.Fatal(err)
+	}
+	// the shared edge is split into a copy for each side
+	if m.VertexCount() != 8 {
+		t.Fatalf("%d vertices, want 8", m.VertexCount())
+	}
+	for c, index := range m.Indices {
+		tan := m.Tangents[index]
+		want := mgl32.Vec4{1, 0, 0, 1}
+		if c >= 6 {
+			want = mgl32.Vec4{-1, 0, 0, -1}
+		}
+		if tan.Sub(want).Len() > 1e-5 {
+			t.Errorf("corner %d tangent %v, want %v", c, tan, want)
+		}
+	}
+	// bitangent = w * normal x tangent points along +v on both sides
+	for _, index := range m.Indices {
+		tan := m.Tangents[index]
+		b := m.Normals[index].Cross(tan.Vec3()).Mul(tan.W())
+		if b.Sub(mgl32.Vec3{0, 1, 0}).Len() > 1e-5 {
+			t.Errorf("bitangent %v, want +Y", b)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	b := Cube(2, 1).Bounds()
+	if b.Min != (mgl32.Vec3{-1, -1, -1}) || b.Max != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("bounds %v", b)
+	}
+	if b.Center() != (mgl32.Vec3{}) || !near(b.Radius(), float32(math.Sqrt(3))) {
+		t.Errorf("center %v radius %v", b.Center(), b.Radius())
+	}
+	moved := b.Transform(mgl32.Translate3D(1, 0, 0).Mul4(mgl32.HomogRotate3DY(math.Pi / 4)))
+	if !near(moved.Max.X(), 1+float32(math.Sqrt2)) || !near(moved.Size().Y(), 2) {
+		t.Errorf("transformed bounds %v", moved)
+	}
+	if !(&Mesh{}).Bounds().Empty() || b.Empty() {
+		t.Error("Empty is wrong")
+	}
+}
+
+func abs(f float32) float32 {
+	return float32(math.Abs(float64(f)))
+}
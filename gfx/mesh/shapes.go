@@ -0,0 +1,344 @@
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The generators below build meshes centered on the origin with unit
+// normals, UVs and tangents. UVs have their origin at the bottom left like
+// the cube in the demos; tangents point along +u and their w is 1, the
+// bitangent normal x tangent points along +v. Segment counts below the
+// minimum a shape needs are raised to it.
+
+// vertex is one generated vertex, tangent without its w.
+type vertex struct {
+	p, n, t mgl32.Vec3
+	uv      mgl32.Vec2
+}
+
+func (m *Mesh) add(v vertex) uint32 {
+	m.Positions = append(m.Positions, v.p)
+	m.Normals = append(m.Normals, v.n)
+	m.UVs = append(m.UVs, v.uv)
+	m.Tangents = append(m.Tangents, v.t.Vec4(1))
+	return uint32(len(m.Positions) - 1)
+}
+
+// grid adds (cols+1) x (rows+1) vertices from at and two triangles per cell.
+// The triangles face the side at(col, row)'s column x row directions
+// cross to, so parameterizations with du x dv pointing out come out
+// counter-clockwise.
+func (m *Mesh) grid(cols, rows int, at func(col, row int) vertex) {
+	first := uint32(len(m.Positions))
+	for row := 0; row <= rows; row++ {
+		for col := 0; col <= cols; col++ {
+			m.add(at(col, row))
+		}
+	}
+	stride := uint32(cols + 1)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			a := first + uint32(row)*stride + uint32(col)
+			b, c, d := a+1, a+stride+1, a+stride
+			m.Indices = append(m.Indices, a, b, c, a, c, d)
+		}
+	}
+}
+
+func atLeast(n, min int) int {
+	if n < min {
+		return min
+	}
+	return n
+}
+
+func sincos(a float64) (float32, float32) {
+	s, c := math.Sincos(a)
+	// drop the rounding left at multiples of π/2, so that seams and poles
+	// land on exactly the same positions
+	if math.Abs(s) < 1e-12 {
+		s = 0
+	}
+	if math.Abs(c) < 1e-12 {
+		c = 0
+	}
+	return float32(s), float32(c)
+}
+
+// around returns the outward direction at angle u*2π around +Y and the
+// tangent along it. u runs counter-clockwise seen from below so that, with
+// v going up, du x dv points outward.
+func around(u float32) (radial, tangent mgl32.Vec3) {
+	s, c := sincos(2 * math.Pi * float64(u))
+	return mgl32.Vec3{c, 0, -s}, mgl32.Vec3{-s, 0, -c}
+}
+
+// Plane returns a width x depth grid in the XZ plane facing +Y, split into
+// cols x rows cells.
+func Plane(width, depth float32, cols, rows int) *Mesh {
+	cols, rows = atLeast(cols, 1), atLeast(rows, 1)
+	m := &Mesh{}
+	m.grid(cols, rows, func(col, row int) vertex {
+		u, v := float32(col)/float32(cols), float32(row)/float32(rows)
+		return vertex{
+			p:  mgl32.Vec3{(u - 0.5) * width, 0, (0.5 - v) * depth},
+			n:  mgl32.Vec3{0, 1, 0},
+			t:  mgl32.Vec3{1, 0, 0},
+			uv: mgl32.Vec2{u, v},
+		}
+	})
+	return m
+}
+
+// Cube returns a cube with edges of length size, each face split into
+// segments x segments cells and mapped to the whole UV square.
+func Cube(size float32, segments int) *Mesh {
+	segments = atLeast(segments, 1)
+	faces := []struct{ n, t mgl32.Vec3 }{
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}},
+		{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 0, 1}},
+		{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{1, 0, 0}},
+		{mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 0, 0}},
+		{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{1, 0, 0}},
+		{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{-1, 0, 0}},
+	}
+	m := &Mesh{}
+	for _, f := range faces {
+		b := f.n.Cross(f.t)
+		m.grid(segments, segments, func(col, row int) vertex {
+			u, v := float32(col)/float32(segments), float32(row)/float32(segments)
+			p := f.n.Mul(0.5).Add(f.t.Mul(u - 0.5)).Add(b.Mul(v - 0.5))
+			return vertex{p: p.Mul(size), n: f.n, t: f.t, uv: mgl32.Vec2{u, v}}
+		})
+	}
+	return m
+}
+
+// Sphere returns a UV sphere with segments columns around the Y axis and
+// rings rows from the south pole to the north pole.
+func Sphere(radius float32, segments, rings int) *Mesh {
+	segments, rings = atLeast(segments, 3), atLeast(rings, 2)
+	m := &Mesh{}
+	m.grid(segments, rings, func(col, row int) vertex {
+		u, v := float32(col)/float32(segments), float32(row)/float32(rings)
+		radial, tangent := around(u)
+		s, c := sincos(math.Pi * float64(v))
+		n := radial.Mul(s).Sub(mgl32.Vec3{0, c, 0})
+		return vertex{p: n.Mul(radius), n: n, t: tangent, uv: mgl32.Vec2{u, v}}
+	})
+	return m
+}
+
+// Icosphere returns a sphere made by splitting every triangle of an
+// icosahedron into four, subdivisions times, for evenly sized triangles.
+// UVs use the same mapping as Sphere, vertices on the seam and the poles are
+// duplicated so no triangle wraps around.
+func Icosphere(radius float32, subdivisions int) *Mesh {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	points := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range points {
+		points[i] = points[i].Normalize()
+	}
+	tris := [][3]uint32{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		midpoints := map[[2]uint32]uint32{}
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{a, b}
+			if a > b {
+				key = [2]uint32{b, a}
+			}
+			if m, ok := midpoints[key]; ok {
+				return m
+			}
+			points = append(points, points[a].Add(points[b]).Normalize())
+			midpoints[key] = uint32(len(points) - 1)
+			return midpoints[key]
+		}
+		next := make([][3]uint32, 0, 4*len(tris))
+		for _, tri := range tris {
+			a, b, c := midpoint(tri[0], tri[1]), midpoint(tri[1], tri[2]), midpoint(tri[2], tri[0])
+			next = append(next,
+				[3]uint32{tri[0], a, c}, [3]uint32{tri[1], b, a},
+				[3]uint32{tri[2], c, b}, [3]uint32{a, b, c})
+		}
+		tris = next
+	}
+
+	m := &Mesh{}
+	type key struct {
+		point uint32
+		u     float32
+	}
+	added := map[key]uint32{}
+	for _, tri := range tris {
+		var uv [3]mgl32.Vec2
+		for j, p := range tri {
+			uv[j] = sphereUV(points[p])
+		}
+		// a triangle across the seam gets its small u wrapped past 1
+		if max3(uv[0][0], uv[1][0], uv[2][0])-min3(uv[0][0], uv[1][0], uv[2][0]) > 0.5 {
+			for j := range uv {
+				if uv[j][0] < 0.5 {
+					uv[j][0]++
+				}
+			}
+		}
+		// the u of a pole is meaningless, use the middle of the other two
+		for j, p := range tri {
+			if isPole(points[p]) {
+				uv[j][0] = (uv[(j+1)%3][0] + uv[(j+2)%3][0]) / 2
+			}
+		}
+		for j, p := range tri {
+			k := key{p, uv[j][0]}
+			index, ok := added[k]
+			if !ok {
+				n := points[p]
+				_, tangent := around(uv[j][0])
+				index = m.add(vertex{p: n.Mul(radius), n: n, t: tangent, uv: uv[j]})
+				added[k] = index
+			}
+			m.Indices = append(m.Indices, index)
+		}
+	}
+	return m
+}
+
+// sphereUV inverts the mapping of Sphere for a unit vector.
+func sphereUV(n mgl32.Vec3) mgl32.Vec2 {
+	u := math.Atan2(float64(-n[2]), float64(n[0])) / (2 * math.Pi)
+	if u < 0 {
+		u++
+	}
+	v := math.Acos(math.Max(-1, math.Min(1, float64(-n[1])))) / math.Pi
+	return mgl32.Vec2{float32(u), float32(v)}
+}
+
+func isPole(n mgl32.Vec3) bool {
+	return 0 == n[0] && 0 == n[2]
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
+
+// Cylinder returns a capped cylinder along the Y axis with segments columns
+// around it and rows rows along it.
+func Cylinder(radius, height float32, segments, rows int) *Mesh {
+	return frustum(radius, radius, height, segments, rows)
+}
+
+// Cone returns a cone along the Y axis with its base at -height/2 and its
+// tip at height/2.
+func Cone(radius, height float32, segments, rows int) *Mesh {
+	return frustum(radius, 0, height, segments, rows)
+}
+
+func frustum(bottom, top, height float32, segments, rows int) *Mesh {
+	segments, rows = atLeast(segments, 3), atLeast(rows, 1)
+	slope := mgl32.Vec2{height, bottom - top}.Normalize()
+	m := &Mesh{}
+	m.grid(segments, rows, func(col, row int) vertex {
+		u, v := float32(col)/float32(segments), float32(row)/float32(rows)
+		radial, tangent := around(u)
+		r := bottom + (top-bottom)*v
+		return vertex{
+			p:  radial.Mul(r).Add(mgl32.Vec3{0, (v - 0.5) * height, 0}),
+			n:  radial.Mul(slope[0]).Add(mgl32.Vec3{0, slope[1], 0}),
+			t:  tangent,
+			uv: mgl32.Vec2{u, v},
+		}
+	})
+	m.disc(-height/2, bottom, segments)
+	if top > 0 {
+		m.disc(height/2, top, segments)
+	}
+	return m
+}
+
+// disc adds a cap at height y facing up when y > 0 and down otherwise,
+// mapped to the UV square as seen from outside.
+func (m *Mesh) disc(y, radius float32, segments int) {
+	up := float32(1)
+	if y < 0 {
+		up = -1
+	}
+	n, t := mgl32.Vec3{0, up, 0}, mgl32.Vec3{1, 0, 0}
+	center := m.add(vertex{p: mgl32.Vec3{0, y, 0}, n: n, t: t, uv: mgl32.Vec2{0.5, 0.5}})
+	for col := 0; col <= segments; col++ {
+		radial, _ := around(float32(col) / float32(segments))
+		m.add(vertex{
+			p:  radial.Mul(radius).Add(mgl32.Vec3{0, y, 0}),
+			n:  n,
+			t:  t,
+			uv: mgl32.Vec2{0.5 + radial[0]/2, 0.5 - up*radial[2]/2},
+		})
+	}
+	for col := uint32(0); col < uint32(segments); col++ {
+		a, b := center+1+col, center+2+col
+		if up > 0 {
+			m.Indices = append(m.Indices, center, a, b)
+		} else {
+			m.Indices = append(m.Indices, center, b, a)
+		}
+	}
+}
+
+// Capsule returns a cylinder of the given height capped by two hemispheres,
+// along the Y axis; its total height is height + 2*radius. Each hemisphere
+// has rings rows, v runs along the profile proportionally to its length.
+func Capsule(radius, height float32, segments, rings int) *Mesh {
+	segments, rings = atLeast(segments, 3), atLeast(rings, 1)
+	total := math.Pi*float64(radius) + float64(height)
+	m := &Mesh{}
+	// rows 0..rings are the bottom hemisphere, rings+1..2*rings+1 the top
+	m.grid(segments, 2*rings+1, func(col, row int) vertex {
+		u := float32(col) / float32(segments)
+		radial, tangent := around(u)
+		y, along := -height/2, 0.0
+		ring := row
+		if row > rings {
+			y, along = height/2, float64(height)
+			ring = row - 1
+		}
+		phi := math.Pi * float64(ring) / float64(2*rings)
+		s, c := sincos(phi)
+		n := radial.Mul(s).Sub(mgl32.Vec3{0, c, 0})
+		v := float32((phi*float64(radius) + along) / total)
+		return vertex{p: n.Mul(radius).Add(mgl32.Vec3{0, y, 0}), n: n, t: tangent, uv: mgl32.Vec2{u, v}}
+	})
+	return m
+}
+
+// Torus returns a ring around the Y axis: major is the distance from the
+// center to the middle of the tube, minor the radius of the tube. u runs
+// around the ring in segments steps, v around the tube in sides steps.
+func Torus(major, minor float32, segments, sides int) *Mesh {
+	segments, sides = atLeast(segments, 3), atLeast(sides, 3)
+	m := &Mesh{}
+	m.grid(segments, sides, func(col, row int) vertex {
+		u, v := float32(col)/float32(segments), float32(row)/float32(sides)
+		radial, tangent := around(u)
+		// v starts on the inside of the ring so the seam is hidden
+		s, c := sincos(2*math.Pi*float64(v) + math.Pi)
+		n := radial.Mul(c).Add(mgl32.Vec3{0, s, 0})
+		return vertex{p: radial.Mul(major).Add(n.Mul(minor)), n: n, t: tangent, uv: mgl32.Vec2{u, v}}
+	})
+	return m
+}
@@ -0,0 +1,139 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShapes(t *testing.T) {
+	cases := []struct {
+		name      string
+		m         *Mesh
+		vertices  int
+		triangles int
+	}{
+		{"plane", Plane(2, 1, 4, 3), 5 * 4, 2 * 4 * 3},
+		{"cube", Cube(1, 2), 6 * 3 * 3, 6 * 2 * 2 * 2},
+		{"sphere", Sphere(1, 16, 8), 17 * 9, 2 * 16 * 8},
+		{"icosphere", Icosphere(1, 2), -1, 20 * 4 * 4},
+		{"cylinder", Cylinder(1, 2, 12, 2), 13*3 + 2*(1+13), 2*12*2 + 2*12},
+		{"cone", Cone(1, 2, 12, 1), 13*2 + 1 + 13, 2*12 + 12},
+		{"capsule", Capsule(0.5, 1, 12, 4), 13 * 10, 2 * 12 * 9},
+		{"torus", Torus(1, 0.25, 24, 8), 25 * 9, 2 * 24 * 8},
+		{"clamped", Sphere(1, 0, 0), 4 * 3, 2 * 3 * 2},
+	}
+	for _, c := range cases {
+		m := c.m
+		if err := m.Validate(); nil != err {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if c.vertices >= 0 && m.VertexCount() != c.vertices {
+			t.Errorf("%s: %d vertices, want %d", c.name, m.VertexCount(), c.vertices)
+		}
+		if m.TriangleCount() != c.triangles {
+			t.Errorf("%s: %d triangles, want %d", c.name, m.TriangleCount(), c.triangles)
+		}
+		if len(m.Normals) != m.VertexCount() || len(m.UVs) != m.VertexCount() || len(m.Tangents) != m.VertexCount() {
+			t.Errorf("%s: missing attributes", c.name)
+			continue
+		}
+		checkFrames(t, c.name, m)
+		checkWinding(t, c.name, m)
+	}
+}
+
+// checkFrames checks that normals and tangents are unit length and
+// perpendicular.
+func checkFrames(t *testing.T, name string, m *Mesh) {
+	for i, n := range m.Normals {
+		tan := m.Tangents[i]
+		if !near(n.Len(), 1) || !near(tan.Vec3().Len(), 1) || !near(n.Dot(tan.Vec3()), 0) || 1 != tan[3] {
+			t.Errorf("%s: vertex %d has normal %v tangent %v", name, i, n, tan)
+			return
+		}
+	}
+}
+
+// checkWinding checks that triangles are counter-clockwise seen from the
+// side their normals point to, and that the tangents follow +u.
+func checkWinding(t *testing.T, name string, m *Mesh) {
+	for i := 0; i < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		e1, e2 := m.Positions[b].Sub(m.Positions[a]), m.Positions[c].Sub(m.Positions[a])
+		face := e1.Cross(e2)
+		if face.Len() < 1e-6 {
+			continue // collapsed at a pole or the tip of a cone
+		}
+		normal := m.Normals[a].Add(m.Normals[b]).Add(m.Normals[c])
+		if face.Dot(normal) <= 0 {
+			t.Errorf("%s: triangle %d winds clockwise", name, i/3)
+			return
+		}
+
+		if 0 == len(m.UVs) {
+			continue
+		}
+		d1, d2 := m.UVs[b].Sub(m.UVs[a]), m.UVs[c].Sub(m.UVs[a])
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+		if math.Abs(float64(det)) < 1e-9 {
+			continue
+		}
+		if det < 0 {
+			t.Errorf("%s: triangle %d is mirrored in UV space", name, i/3)
+			return
+		}
+		dpdu := e1.Mul(d2[1]).Sub(e2.Mul(d1[1]))
+		tangent := m.Tangents[a].Vec3().Add(m.Tangents[b].Vec3()).Add(m.Tangents[c].Vec3())
+		if dpdu.Dot(tangent) <= 0 {
+			t.Errorf("%s: triangle %d has tangents against +u", name, i/3)
+			return
+		}
+	}
+}
+
+func TestSphereRadius(t *testing.T) {
+	for name, m := range map[string]*Mesh{
+		"sphere":    Sphere(2, 10, 6),
+		"icosphere": Icosphere(2, 1),
+	} {
+		for i, p := range m.Positions {
+			if !near(p.Len(), 2) {
+				t.Errorf("%s: vertex %d at distance %v", name, i, p.Len())
+				break
+			}
+		}
+	}
+}
+
+func TestCapsuleExtent(t *testing.T) {
+	m := Capsule(0.5, 1, 8, 3)
+	var lo, hi float32
+	for _, p := range m.Positions {
+		lo = float32(math.Min(float64(lo), float64(p.Y())))
+		hi = float32(math.Max(float64(hi), float64(p.Y())))
+	}
+	if !near(lo, -1) || !near(hi, 1) {
+		t.Errorf("capsule spans %v to %v, want -1 to 1", lo, hi)
+	}
+	if uv := m.UVs[len(m.UVs)-1]; !near(uv.Y(), 1) {
+		t.Errorf("top of the capsule has v %v, want 1", uv.Y())
+	}
+}
+
+func TestIcosphereSeam(t *testing.T) {
+	m := Icosphere(1, 3)
+	for i := 0; i < len(m.Indices); i += 3 {
+		var us []float64
+		for _, index := range m.Indices[i : i+3] {
+			us = append(us, float64(m.UVs[index].X()))
+		}
+		if math.Max(us[0], math.Max(us[1], us[2]))-math.Min(us[0], math.Min(us[1], us[2])) > 0.5 {
+			t.Fatalf("triangle %d wraps around the seam, u %v", i/3, us)
+		}
+	}
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
@@ -0,0 +1,413 @@
+package mesh
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Simplify returns indices drawing m with at most targetIndexCount indices,
+// or as close to it as collapsing edges without moving the surface more
+// than maxError mesh units allows, and the error reached. The vertices are
+// shared with m, so a chain of them can go into one buffer; see
+// GenerateLODs.
+//
+// Edges are collapsed cheapest first by their quadric error (Garland and
+// Heckbert), each onto one of its existing vertices. Vertices at the same
+// position are treated as one, so Weld the mesh first; where they differ
+// in other attributes, along UV seams, and along open borders, vertices
+// only slide along the seam or border and its corners stay.
+func (m *Mesh) Simplify(targetIndexCount int, maxError float32) ([]uint32, float32) {
+	s := newSimplifier(m)
+	s.collapse(targetIndexCount/3, maxError)
+	return s.indices(), s.moved
+}
+
+// quadric is the sum of squared distances to weighted planes, as the
+// symmetric matrix (a b c d)ᵀ(a b c d) over homogeneous points.
+type quadric struct {
+	a2, ab, ac, ad, b2, bc, bd, c2, cd, d2 float64
+	weight                                 float64
+}
+
+func planeQuadric(normal mgl32.Vec3, point mgl32.Vec3, weight float64) quadric {
+	a, b, c := float64(normal[0]), float64(normal[1]), float64(normal[2])
+	d := -float64(normal.Dot(point))
+	return quadric{
+		a2: a * a * weight, ab: a * b * weight, ac: a * c * weight, ad: a * d * weight,
+		b2: b * b * weight, bc: b * c * weight, bd: b * d * weight,
+		c2: c * c * weight, cd: c * d * weight,
+		d2:     d * d * weight,
+		weight: weight,
+	}
+}
+
+func (q quadric) add(r quadric) quadric {
+	return quadric{
+		q.a2 + r.a2, q.ab + r.ab, q.ac + r.ac, q.ad + r.ad,
+		q.b2 + r.b2, q.bc + r.bc, q.bd + r.bd,
+		q.c2 + r.c2, q.cd + r.cd,
+		q.d2 + r.d2,
+		q.weight + r.weight,
+	}
+}
+
+// distance is the weighted root mean square distance of p to the planes.
+func (q quadric) distance(p mgl32.Vec3) float32 {
+	if 0 == q.weight {
+		return 0
+	}
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+	e := x*x*q.a2 + 2*x*y*q.ab + 2*x*z*q.ac + 2*x*q.ad +
+		y*y*q.b2 + 2*y*z*q.bc + 2*y*q.bd +
+		z*z*q.c2 + 2*z*q.cd +
+		q.d2
+	return float32(math.Sqrt(math.Max(e, 0) / q.weight))
+}
+
+// simplifier collapses the mesh's positions, each the class of vertices
+// sharing one, while keeping its vertices. Classes are numbered by their
+// first vertex.
+type simplifier struct {
+	m         *Mesh
+	triangles [][3]uint32
+	alive     []bool
+	live      int
+
+	class     []uint32   // the class of each vertex
+	wedges    [][]uint32 // the vertices of each class
+	incident  [][]int    // triangles using each class, dead ones included
+	collapsed []bool
+	quadrics  []quadric
+
+	queue collapses
+	moved float32 // the largest collapse error so far
+}
+
+func newSimplifier(m *Mesh) *simplifier {
+	n := len(m.Positions)
+	s := &simplifier{
+		m:         m,
+		class:     make([]uint32, n),
+		wedges:    make([][]uint32, n),
+		incident:  make([][]int, n),
+		collapsed: make([]bool, n),
+		quadrics:  make([]quadric, n),
+	}
+	first := map[mgl32.Vec3]uint32{}
+	for v, p := range m.Positions {
+		c, ok := first[p]
+		if !ok {
+			c = uint32(v)
+			first[p] = c
+		}
+		s.class[v] = c
+		s.wedges[c] = append(s.wedges[c], uint32(v))
+	}
+
+	edges := map[[2]uint32]int{}
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		tri := [3]uint32{m.Indices[t], m.Indices[t+1], m.Indices[t+2]}
+		if s.class[tri[0]] == s.class[tri[1]] || s.class[tri[1]] == s.class[tri[2]] || s.class[tri[2]] == s.class[tri[0]] {
+			continue // already degenerate
+		}
+		index := len(s.triangles)
+		s.triangles = append(s.triangles, tri)
+		s.alive = append(s.alive, true)
+		s.live++
+		for k, v := range tri {
+			s.incident[s.class[v]] = append(s.incident[s.class[v]], index)
+			edges[edgeKey(v, tri[(k+1)%3])]++
+		}
+	}
+
+	for t, tri := range s.triangles {
+		a, b, c := s.position(tri[0]), s.position(tri[1]), s.position(tri[2])
+		normal := b.Sub(a).Cross(c.Sub(a))
+		area := float64(normal.Len()) / 2
+		if 0 == area {
+			continue
+		}
+		normal = normal.Normalize()
+		q := planeQuadric(normal, a, area)
+		for _, v := range tri {
+			s.quadrics[s.class[v]] = s.quadrics[s.class[v]].add(q)
+		}
+		// keep open edges where they are with a plane through them at
+		// right angles to the triangle
+		for k := range tri {
+			from, to := s.triangles[t][k], s.triangles[t][(k+1)%3]
+			if 1 != edges[edgeKey(from, to)] {
+				continue
+			}
+			p, edge := s.position(from), s.position(to).Sub(s.position(from))
+			length := float64(edge.Len())
+			q := planeQuadric(edge.Cross(normal).Normalize(), p, length*length)
+			s.quadrics[s.class[from]] = s.quadrics[s.class[from]].add(q)
+			s.quadrics[s.class[to]] = s.quadrics[s.class[to]].add(q)
+		}
+	}
+
+	for c := range s.wedges {
+		if 0 != len(s.wedges[c]) {
+			s.push(uint32(c))
+		}
+	}
+	return s
+}
+
+func edgeKey(a, b uint32) [2]uint32 {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint32{a, b}
+}
+
+func (s *simplifier) position(v uint32) mgl32.Vec3 {
+	return s.m.Positions[v]
+}
+
+// collapse goes on until at most target triangles are left or the next
+// collapse would move the surface by more than maxError.
+func (s *simplifier) collapse(target int, maxError float32) {
+	for s.live > target && 0 != s.queue.Len() {
+		next := heap.Pop(&s.queue).(collapseCandidate)
+		if s.collapsed[next.from] {
+			continue
+		}
+		// the neighbourhood may have changed since it was queued
+		current, ok := s.candidate(next.from)
+		if !ok {
+			continue
+		}
+		if current.to != next.to || current.error > next.error {
+			heap.Push(&s.queue, current)
+			continue
+		}
+		if current.error > maxError {
+			// everything left costs more, keep it for a later call
+			heap.Push(&s.queue, current)
+			return
+		}
+		s.apply(current)
+	}
+}
+
+// collapseCandidate moves class from onto class to, taking each of the
+// from vertices to the matching vertex of to.
+type collapseCandidate struct {
+	from, to uint32
+	error    float32
+	match    map[uint32]uint32
+}
+
+func (s *simplifier) push(class uint32) {
+	if c, ok := s.candidate(class); ok {
+		heap.Push(&s.queue, c)
+	}
+}
+
+// live triangles of class, pruning the dead ones.
+func (s *simplifier) around(class uint32) []int {
+	list := s.incident[class][:0]
+	for _, t := range s.incident[class] {
+		if s.alive[t] {
+			list = append(list, t)
+		}
+	}
+	s.incident[class] = list
+	return list
+}
+
+// candidate finds the cheapest allowed collapse of class.
+func (s *simplifier) candidate(class uint32) (collapseCandidate, bool) {
+	triangles := s.around(class)
+	if 0 == len(triangles) {
+		return collapseCandidate{}, false
+	}
+
+	// edges used by one triangle are on a border or a seam
+	edges := map[[2]uint32]int{}
+	neighbours := map[uint32]bool{}
+	for _, t := range triangles {
+		tri := s.triangles[t]
+		for k := range tri {
+			edges[edgeKey(tri[k], tri[(k+1)%3])]++
+			if s.class[tri[k]] != class {
+				neighbours[s.class[tri[k]]] = true
+			}
+		}
+	}
+	open := map[uint32]bool{}
+	for e, count := range edges {
+		if 1 != count {
+			continue
+		}
+		a, b := s.class[e[0]], s.class[e[1]]
+		switch class {
+		case a:
+			open[b] = true
+		case b:
+			open[a] = true
+		}
+	}
+	if 0 != len(open) {
+		// on a border or seam only slide along it, and not at all where it
+		// turns into another one
+		if 2 != len(open) {
+			return collapseCandidate{}, false
+		}
+		neighbours = open
+	}
+
+	best := collapseCandidate{error: float32(math.Inf(1))}
+	for to := range neighbours {
+		match, ok := s.matchWedges(class, to, triangles)
+		if !ok || s.flips(class, to, triangles) {
+			continue
+		}
+		e := s.quadrics[class].add(s.quadrics[to]).distance(s.position(to))
+		if e < best.error || (e == best.error && to < best.to) {
+			best = collapseCandidate{from: class, to: to, error: e, match: match}
+		}
+	}
+	return best, nil != best.match
+}
+
+// matchWedges pairs each vertex of from with the vertex of to it shares an
+// edge with, failing where there is none: that collapse would tear a seam.
+func (s *simplifier) matchWedges(from, to uint32, triangles []int) (map[uint32]uint32, bool) {
+	match := map[uint32]uint32{}
+	for _, t := range triangles {
+		tri := s.triangles[t]
+		var f, g int = -1, -1
+		for k, v := range tri {
+			switch s.class[v] {
+			case from:
+				f = k
+			case to:
+				g = k
+			}
+		}
+		if f < 0 || g < 0 {
+			continue
+		}
+		if previous, ok := match[tri[f]]; ok && previous != tri[g] {
+			return nil, false
+		}
+		match[tri[f]] = tri[g]
+	}
+	for _, t := range triangles {
+		for _, v := range s.triangles[t] {
+			if s.class[v] == from {
+				if _, ok := match[v]; !ok {
+					return nil, false
+				}
+			}
+		}
+	}
+	return match, 0 != len(match)
+}
+
+// flips reports whether moving from onto to turns or squashes a triangle
+// that survives the collapse, or whether none does: that would wipe out a
+// loose piece of the mesh.
+func (s *simplifier) flips(from, to uint32, triangles []int) bool {
+	target := s.position(to)
+	survivors := 0
+Triangles:
+	for _, t := range triangles {
+		tri := s.triangles[t]
+		var before, after [3]mgl32.Vec3
+		for k, v := range tri {
+			switch s.class[v] {
+			case to:
+				continue Triangles // collapses away
+			case from:
+				after[k] = target
+			default:
+				after[k] = s.position(v)
+			}
+			before[k] = s.position(v)
+		}
+		n0 := before[1].Sub(before[0]).Cross(before[2].Sub(before[0]))
+		n1 := after[1].Sub(after[0]).Cross(after[2].Sub(after[0]))
+		if n0.Dot(n1) <= 0 {
+			return true
+		}
+		survivors++
+	}
+	return 0 == survivors
+}
+
+func (s *simplifier) apply(c collapseCandidate) {
+	for _, t := range s.around(c.from) {
+		tri := &s.triangles[t]
+		dies := false
+		for k, v := range tri {
+			if s.class[v] == c.to {
+				dies = true
+			}
+			if s.class[v] == c.from {
+				tri[k] = c.match[v]
+			}
+		}
+		if dies {
+			s.alive[t] = false
+			s.live--
+			continue
+		}
+		s.incident[c.to] = append(s.incident[c.to], t)
+	}
+	s.incident[c.from] = nil
+	s.collapsed[c.from] = true
+	s.quadrics[c.to] = s.quadrics[c.to].add(s.quadrics[c.from])
+	if c.error > s.moved {
+		s.moved = c.error
+	}
+
+	// costs around the merged class changed
+	s.push(c.to)
+	neighbours := map[uint32]bool{}
+	for _, t := range s.around(c.to) {
+		for _, v := range s.triangles[t] {
+			neighbours[s.class[v]] = true
+		}
+	}
+	delete(neighbours, c.to)
+	for n := range neighbours {
+		s.push(n)
+	}
+}
+
+// indices lists the live triangles.
+func (s *simplifier) indices() []uint32 {
+	out := make([]uint32, 0, 3*s.live)
+	for t, tri := range s.triangles {
+		if s.alive[t] {
+			out = append(out, tri[:]...)
+		}
+	}
+	return out
+}
+
+// collapses is a min-heap on error, ties broken by class for a stable
+// result.
+type collapses []collapseCandidate
+
+func (q collapses) Len() int { return len(q) }
+func (q collapses) Less(i, j int) bool {
+	if q[i].error != q[j].error {
+		return q[i].error < q[j].error
+	}
+	return q[i].from < q[j].from
+}
+func (q collapses) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *collapses) Push(x interface{}) { *q = append(*q, x.(collapseCandidate)) }
+func (q *collapses) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,102 @@
+package mesh
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// area sums the triangle areas of indices into m.
+func area(m *Mesh, indices []uint32) float32 {
+	var sum float32
+	for c := 0; c+2 < len(indices); c += 3 {
+		a, b, d := m.Positions[indices[c]], m.Positions[indices[c+1]], m.Positions[indices[c+2]]
+		sum += b.Sub(a).Cross(d.Sub(a)).Len() / 2
+	}
+	return sum
+}
+
+func TestSimplifySphere(t *testing.T) {
+	m := Sphere(1, 32, 24)
+	target := len(m.Indices) / 4
+	indices, e := m.Simplify(target, 1)
+	t.Logf("%d -> %d indices, error %.4f", len(m.Indices), len(indices), e)
+	if len(indices) > target || 0 != len(indices)%3 {
+		t.Fatalf("%d indices, want at most %d", len(indices), target)
+	}
+	if e <= 0 || e > 0.1 {
+		t.Errorf("error %f", e)
+	}
+	// the triangles stay close to the surface and none crosses the seam
+	for c := 0; c < len(indices); c += 3 {
+		a, b, d := indices[c], indices[c+1], indices[c+2]
+		center := m.Positions[a].Add(m.Positions[b]).Add(m.Positions[d]).Mul(1.0 / 3)
+		if center.Len() < 0.8 {
+			t.Fatalf("triangle %d sinks to %f", c/3, center.Len())
+		}
+		for _, pair := range [][2]uint32{{a, b}, {b, d}, {d, a}} {
+			if du := m.UVs[pair[0]][0] - m.UVs[pair[1]][0]; abs(du) > 0.5 {
+				t.Fatalf("triangle %d crosses the UV seam", c/3)
+			}
+		}
+	}
+}
+
+func TestSimplifyPlane(t *testing.T) {
+	m := Plane(2, 2, 16, 16)
+	indices, e := m.Simplify(0, 1e-4)
+	t.Logf("%d -> %d indices, error %g", len(m.Indices), len(indices), e)
+	if e > 1e-4 {
+		t.Errorf("error %g", e)
+	}
+	if len(indices) > len(m.Indices)/4 {
+		t.Errorf("a flat plane only simplified to %d indices", len(indices))
+	}
+	// the border, and so the area, stays
+	if got := area(m, indices); !near(got, 4) {
+		t.Errorf("area %f, want 4", got)
+	}
+}
+
+func TestGenerateLODs(t *testing.T) {
+	m := Sphere(1, 32, 24)
+	lods := m.GenerateLODs(4, 0.5)
+	if 4 != len(lods) {
+		t.Fatalf("%d levels", len(lods))
+	}
+	if len(lods[0].Indices) != len(m.Indices) || 0 != lods[0].Error {
+		t.Error("the first level is not the mesh itself")
+	}
+	for i := 1; i < len(lods); i++ {
+		if len(lods[i].Indices) >= len(lods[i-1].Indices) || lods[i].Error < lods[i-1].Error {
+			t.Errorf("level %d: %d indices error %f after %d indices error %f", i,
+				len(lods[i].Indices), lods[i].Error, len(lods[i-1].Indices), lods[i-1].Error)
+		}
+		for _, v := range lods[i].Indices {
+			if int(v) >= m.VertexCount() {
+				t.Fatalf("level %d indexes vertex %d", i, v)
+			}
+		}
+	}
+}
+
+func TestSelectLOD(t *testing.T) {
+	lods := []LOD{{Error: 0}, {Error: 0.01}, {Error: 0.1}}
+	// 90 degrees vertically, so one unit at distance one fills half of it
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 1000)
+	if got := ScreenSize(1, 1, projection, 1000); !near(got, 500) {
+		t.Errorf("screen size %f, want 500", got)
+	}
+	for _, c := range []struct {
+		distance float32
+		want     int
+	}{{-1, 0}, {1, 0}, {10, 1}, {100, 2}} {
+		if got := SelectLOD(lods, c.distance, projection, 1000, 1); got != c.want {
+			t.Errorf("at %f got level %d, want %d", c.distance, got, c.want)
+		}
+	}
+	ortho := mgl32.Ortho(-1, 1, -1, 1, 0.1, 1000)
+	if got := SelectLOD(lods, 100, ortho, 1000, 1); 0 != got {
+		t.Errorf("orthographic got level %d, distance should not matter", got)
+	}
+}
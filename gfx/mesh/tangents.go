@@ -0,0 +1,105 @@
+package mesh
+
+import (
+	"errors"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ComputeTangents generates tangents from the UVs the way MikkTSpace does,
+// so normal maps baked by tools using it look right:
+//
+//   - each face contributes dP/du projected into the tangent plane of the
+//     vertex normal, weighted by the corner angle;
+//   - faces whose UVs are mirrored relative to the normal are never
+//     averaged with unmirrored ones, the vertex is split instead and the two
+//     copies get w = 1 and w = -1;
+//   - w is the sign of the bitangent, bitangent = w * cross(normal, tangent).
+//
+// Vertices are expected to be welded, MikkTSpace shares a tangent between
+// corners with the same position, normal and UV, which is what a shared
+// index means. Faces without UV area add nothing; vertices left without a
+// tangent get an arbitrary one perpendicular to the normal.
+func (m *Mesh) ComputeTangents() error {
+	if 0 == len(m.Normals) || 0 == len(m.UVs) {
+		return errors.New("mesh: tangents need normals and uvs")
+	}
+	type key struct {
+		vertex uint32
+		sign   float32
+	}
+	sums := map[key]mgl32.Vec3{}
+	signs := make([]float32, len(m.Indices))
+
+	for f := 0; f+2 < len(m.Indices); f += 3 {
+		tri := m.Indices[f : f+3]
+		p0, p1, p2 := m.Positions[tri[0]], m.Positions[tri[1]], m.Positions[tri[2]]
+		t0, t1, t2 := m.UVs[tri[0]], m.UVs[tri[1]], m.UVs[tri[2]]
+		e1, e2 := p1.Sub(p0), p2.Sub(p0)
+		d1, d2 := t1.Sub(t0), t2.Sub(t0)
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+
+		var sdir, tdir mgl32.Vec3
+		if det != 0 {
+			sdir = e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(1 / det)
+			tdir = e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(1 / det)
+		}
+		corners := [3]mgl32.Vec3{p0, p1, p2}
+		for k, v := range tri {
+			n := m.Normals[v]
+			sign := float32(1)
+			if n.Cross(sdir).Dot(tdir) < 0 {
+				sign = -1
+			}
+			signs[f+k] = sign
+			t := sdir.Sub(n.Mul(n.Dot(sdir)))
+			if t.Len() == 0 {
+				continue
+			}
+			weight := cornerAngle(corners[k], corners[(k+1)%3], corners[(k+2)%3])
+			sums[key{v, sign}] = sums[key{v, sign}].Add(t.Normalize().Mul(weight))
+		}
+	}
+
+	// split vertices used with both signs, the first sign keeps the index
+	n := len(m.Positions)
+	sources := make([]uint32, n)
+	for i := range sources {
+		sources[i] = uint32(i)
+	}
+	vertexSign := make([]float32, n)
+	copies := map[uint32]uint32{}
+	indices := make([]uint32, len(m.Indices))
+	for c, v := range m.Indices {
+		switch {
+		case 0 == vertexSign[v]:
+			vertexSign[v] = signs[c]
+		case vertexSign[v] != signs[c]:
+			index, ok := copies[v]
+			if !ok {
+				index = uint32(len(sources))
+				sources = append(sources, v)
+				copies[v] = index
+			}
+			v = index
+		}
+		indices[c] = v
+	}
+	m.rebuild(sources, indices)
+
+	m.Tangents = make([]mgl32.Vec4, len(sources))
+	for i, v := range sources {
+		sign := vertexSign[v]
+		if uint32(i) != v {
+			sign = -sign
+		}
+		if 0 == sign {
+			sign = 1 // not used by any triangle
+		}
+		normal := m.Normals[i]
+		t := sums[key{v, sign}]
+		t = safeNormalize(t.Sub(normal.Mul(normal.Dot(t))), anyPerpendicular(normal))
+		m.Tangents[i] = t.Vec4(sign)
+	}
+	return nil
+}
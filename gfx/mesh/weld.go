@@ -0,0 +1,167 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// FromVertices splits interleaved vertices, laid out as attrs describes,
+// into a mesh with one index per vertex, the way flat arrays like the
+// demos' cubeVertices are drawn with DrawArrays. Weld then shares the
+// duplicates. Attribute names are those of Attributes; unknown names and
+// sizes that do not fit are errors.
+func FromVertices(vertices []float32, attrs ...Attribute) (*Mesh, error) {
+	stride := 0
+	for _, a := range attrs {
+		if end := a.Offset + a.Size; end > stride {
+			stride = end
+		}
+	}
+	if 0 == stride || 0 != len(vertices)%stride {
+		return nil, fmt.Errorf("mesh: %d floats is not a whole number of %d float vertices", len(vertices), stride)
+	}
+	n := len(vertices) / stride
+	m := &Mesh{Indices: make([]uint32, n)}
+	for i := range m.Indices {
+		m.Indices[i] = uint32(i)
+	}
+	for _, a := range attrs {
+		want := map[string]int{"position": 3, "normal": 3, "uv": 2, "tangent": 4, "color": 4}[a.Name]
+		if 0 == want {
+			return nil, fmt.Errorf("mesh: unknown attribute %q", a.Name)
+		}
+		if a.Size != want {
+			return nil, fmt.Errorf("mesh: attribute %q has %d components, want %d", a.Name, a.Size, want)
+		}
+		for i := 0; i < n; i++ {
+			v := vertices[i*stride+a.Offset:]
+			switch a.Name {
+			case "position":
+				m.Positions = append(m.Positions, mgl32.Vec3{v[0], v[1], v[2]})
+			case "normal":
+				m.Normals = append(m.Normals, mgl32.Vec3{v[0], v[1], v[2]})
+			case "uv":
+				m.UVs = append(m.UVs, mgl32.Vec2{v[0], v[1]})
+			case "tangent":
+				m.Tangents = append(m.Tangents, mgl32.Vec4{v[0], v[1], v[2], v[3]})
+			case "color":
+				m.Colors = append(m.Colors, mgl32.Vec4{v[0], v[1], v[2], v[3]})
+			}
+		}
+	}
+	if 0 == len(m.Positions) {
+		return nil, fmt.Errorf("mesh: no position attribute")
+	}
+	return m, nil
+}
+
+// Weld merges vertices whose attributes all differ by at most epsilon per
+// component, 0 for exact duplicates, and rewrites the indices to match.
+// The first of each set of duplicates is kept, in order.
+func (m *Mesh) Weld(epsilon float32) {
+	cells := map[[3]int64][]uint32{}
+	cell := func(p mgl32.Vec3) [3]int64 {
+		if 0 == epsilon {
+			// +0 turns -0 into 0, so the two land in the same cell
+			return [3]int64{
+				int64(math.Float32bits(p[0] + 0)),
+				int64(math.Float32bits(p[1] + 0)),
+				int64(math.Float32bits(p[2] + 0)),
+			}
+		}
+		size := float64(2 * epsilon)
+		return [3]int64{
+			int64(math.Floor(float64(p[0]) / size)),
+			int64(math.Floor(float64(p[1]) / size)),
+			int64(math.Floor(float64(p[2]) / size)),
+		}
+	}
+	// with a tolerance a duplicate can sit in a neighboring cell
+	reach := int64(1)
+	if 0 == epsilon {
+		reach = 0
+	}
+
+	remap := make([]uint32, len(m.Positions))
+	var kept []uint32
+	for i, p := range m.Positions {
+		c := cell(p)
+		found := -1
+	search:
+		for dx := -reach; dx <= reach; dx++ {
+			for dy := -reach; dy <= reach; dy++ {
+				for dz := -reach; dz <= reach; dz++ {
+					for _, k := range cells[[3]int64{c[0] + dx, c[1] + dy, c[2] + dz}] {
+						if m.sameVertex(int(kept[k]), i, epsilon) {
+							found = int(k)
+							break search
+						}
+					}
+				}
+			}
+		}
+		if found < 0 {
+			found = len(kept)
+			kept = append(kept, uint32(i))
+			cells[c] = append(cells[c], uint32(found))
+		}
+		remap[i] = uint32(found)
+	}
+
+	indices := make([]uint32, len(m.Indices))
+	for i, index := range m.Indices {
+		indices[i] = remap[index]
+	}
+	m.rebuild(kept, indices)
+}
+
+func (m *Mesh) sameVertex(a, b int, epsilon float32) bool {
+	near := func(x, y []float32) bool {
+		for i := range x {
+			if float32(math.Abs(float64(x[i]-y[i]))) > epsilon {
+				return false
+			}
+		}
+		return true
+	}
+	if !near(m.Positions[a][:], m.Positions[b][:]) {
+		return false
+	}
+	if 0 != len(m.Normals) && !near(m.Normals[a][:], m.Normals[b][:]) {
+		return false
+	}
+	if 0 != len(m.UVs) && !near(m.UVs[a][:], m.UVs[b][:]) {
+		return false
+	}
+	if 0 != len(m.Tangents) && !near(m.Tangents[a][:], m.Tangents[b][:]) {
+		return false
+	}
+	if 0 != len(m.Colors) && !near(m.Colors[a][:], m.Colors[b][:]) {
+		return false
+	}
+	return true
+}
+
+// rebuild replaces the vertices with copies of the old vertices listed in
+// sources, and the indices with indices into the new vertices.
+func (m *Mesh) rebuild(sources []uint32, indices []uint32) {
+	out := Mesh{Indices: indices}
+	for _, s := range sources {
+		out.Positions = append(out.Positions, m.Positions[s])
+		if 0 != len(m.Normals) {
+			out.Normals = append(out.Normals, m.Normals[s])
+		}
+		if 0 != len(m.UVs) {
+			out.UVs = append(out.UVs, m.UVs[s])
+		}
+		if 0 != len(m.Tangents) {
+			out.Tangents = append(out.Tangents, m.Tangents[s])
+		}
+		if 0 != len(m.Colors) {
+			out.Colors = append(out.Colors, m.Colors[s])
+		}
+	}
+	*m = out
+}
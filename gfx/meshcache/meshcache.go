@@ -0,0 +1,357 @@
+// Package meshcache reads and writes meshes in a compact binary form that
+// loads without parsing: the vertex and index data are stored exactly as
+// gl.BufferData takes them and are memory-mapped when read.
+//
+// A file is little-endian and laid out as
+//
+//	magic     "GFXMESH\x00"
+//	header    version, counts, vertex stride, bounds, data offsets, CRC-32C
+//	attribute name [16]byte, size and offset in floats, per attribute
+//	level     first index, index count, error, per level of detail
+//	vertices  interleaved float32s, 16 byte aligned
+//	indices   uint32s, every level one after another, 16 byte aligned
+//
+// The checksum covers the whole file except the checksum itself.
+package meshcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+)
+
+// Version is the format version Write produces and Open accepts.
+const Version = 1
+
+var magic = [8]byte{'G', 'F', 'X', 'M', 'E', 'S', 'H', 0}
+
+type header struct {
+	Version        uint32
+	AttributeCount uint32
+	LevelCount     uint32
+	VertexCount    uint32
+	IndexCount     uint32
+	Stride         uint32 // bytes
+	BoundsMin      [3]float32
+	BoundsMax      [3]float32
+	VertexOffset   uint64
+	IndexOffset    uint64
+	Checksum       uint32
+	Reserved       uint32
+}
+
+type attribute struct {
+	Name   [16]byte
+	Size   uint32
+	Offset uint32
+}
+
+type level struct {
+	First    uint32
+	Count    uint32
+	Error    float32
+	Reserved uint32
+}
+
+var (
+	headerSize    = binary.Size(header{})
+	attributeSize = binary.Size(attribute{})
+	levelSize     = binary.Size(level{})
+	// the checksum is the second to last field of the header
+	checksumOffset = len(magic) + headerSize - 8
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrChecksum is returned by Open for a file whose contents do not match
+// its checksum, usually one that was cut short or overwritten.
+var ErrChecksum = errors.New("meshcache: checksum mismatch")
+
+// Level is one level of detail in the index data.
+type Level struct {
+	First, Count int // in indices
+	Error        float32
+}
+
+// Write stores m with lods, as mesh.GenerateLODs makes them, in w. Without
+// lods m.Indices is stored as the only level.
+func Write(w io.Writer, m *mesh.Mesh, lods []mesh.LOD) error {
+	if err := m.Validate(); nil != err {
+		return fmt.Errorf("meshcache: %v", err)
+	}
+	if 0 == len(m.Positions) {
+		return errors.New("meshcache: empty mesh")
+	}
+	if 0 == len(lods) {
+		lods = []mesh.LOD{{Indices: m.Indices}}
+	}
+
+	indexCount := 0
+	for i, lod := range lods {
+		for _, index := range lod.Indices {
+			if int(index) >= len(m.Positions) {
+				return fmt.Errorf("meshcache: level %d: index %d out of range", i, index)
+			}
+		}
+		indexCount += len(lod.Indices)
+	}
+	if uint64(len(m.Positions)) > math.MaxUint32 || uint64(indexCount) > math.MaxUint32 {
+		return fmt.Errorf("meshcache: %d vertices and %d indices do not fit the 32 bit counts", len(m.Positions), indexCount)
+	}
+
+	attrs := m.Attributes()
+	bounds := m.Bounds()
+	h := header{
+		Version:        Version,
+		AttributeCount: uint32(len(attrs)),
+		LevelCount:     uint32(len(lods)),
+		VertexCount:    uint32(len(m.Positions)),
+		IndexCount:     uint32(indexCount),
+		Stride:         uint32(4 * m.Stride()),
+		BoundsMin:      bounds.Min,
+		BoundsMax:      bounds.Max,
+	}
+	tables := len(magic) + headerSize + len(attrs)*attributeSize + len(lods)*levelSize
+	h.VertexOffset, h.IndexOffset = dataOffsets(tables, h.VertexCount, h.Stride)
+
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	binary.Write(&buf, binary.LittleEndian, h)
+	for _, a := range attrs {
+		var name [16]byte
+		copy(name[:], a.Name)
+		binary.Write(&buf, binary.LittleEndian, attribute{name, uint32(a.Size), uint32(a.Offset)})
+	}
+	first := 0
+	for _, lod := range lods {
+		binary.Write(&buf, binary.LittleEndian, level{First: uint32(first), Count: uint32(len(lod.Indices)), Error: lod.Error})
+		first += len(lod.Indices)
+	}
+	pad(&buf)
+	binary.Write(&buf, binary.LittleEndian, m.Interleaved())
+	pad(&buf)
+	for _, lod := range lods {
+		binary.Write(&buf, binary.LittleEndian, lod.Indices)
+	}
+
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[checksumOffset:], checksum(data))
+	_, err := w.Write(data)
+	return err
+}
+
+// WriteFile writes m and lods to file, through a temporary file so a
+// reader never sees it half written.
+func WriteFile(file string, m *mesh.Mesh, lods []mesh.LOD) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if nil != err {
+		return err
+	}
+	if err := Write(tmp, m, lods); nil != err {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); nil != err {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// dataOffsets places the vertices after tables bytes of header and tables
+// and the indices after the vertices, both 16 byte aligned. The vertex data
+// may pass 4 GiB, so the sizes are worked out in 64 bits.
+func dataOffsets(tables int, vertexCount, stride uint32) (vertexOffset, indexOffset uint64) {
+	vertexOffset = uint64(align(tables))
+	indexOffset = (vertexOffset + uint64(vertexCount)*uint64(stride) + 15) &^ 15
+	return vertexOffset, indexOffset
+}
+
+func align(n int) int {
+	return (n + 15) &^ 15
+}
+
+func pad(buf *bytes.Buffer) {
+	buf.Write(make([]byte, align(buf.Len())-buf.Len()))
+}
+
+func checksum(data []byte) uint32 {
+	crc := crc32.Update(0, castagnoli, data[:checksumOffset])
+	return crc32.Update(crc, castagnoli, data[checksumOffset+4:])
+}
+
+// File is an open mesh cache. Its data stays valid until Close.
+type File struct {
+	Attributes  []mesh.Attribute
+	VertexCount int
+	Bounds      mesh.Bounds
+	// Levels are the levels of detail in IndexData, finest first.
+	Levels []Level
+
+	data     []byte
+	vertices []byte
+	indices  []byte
+	unmap    func() error
+}
+
+// Open maps file into memory and checks it, returning ErrChecksum if it
+// was damaged.
+func Open(file string) (*File, error) {
+	data, unmap, err := mapFile(file)
+	if nil != err {
+		return nil, err
+	}
+	f, err := parse(data)
+	if nil != err {
+		unmap()
+		return nil, err
+	}
+	f.unmap = unmap
+	return f, nil
+}
+
+// Decode reads a mesh cache from memory, which must not change while the
+// File is used.
+func Decode(data []byte) (*File, error) {
+	return parse(data)
+}
+
+func parse(data []byte) (*File, error) {
+	if len(data) < len(magic)+headerSize || !bytes.Equal(data[:len(magic)], magic[:]) {
+		return nil, errors.New("meshcache: not a mesh cache")
+	}
+	var h header
+	binary.Read(bytes.NewReader(data[len(magic):]), binary.LittleEndian, &h)
+	if Version != h.Version {
+		return nil, fmt.Errorf("meshcache: version %d, want %d", h.Version, Version)
+	}
+	if 0 == h.VertexCount || 0 == h.Stride {
+		return nil, errors.New("meshcache: no vertices")
+	}
+	// every offset is checked against what precedes it before any sum is
+	// formed, so a corrupt header cannot wrap around
+	size := uint64(len(data))
+	tables := uint64(len(magic)+headerSize) + uint64(h.AttributeCount)*uint64(attributeSize) + uint64(h.LevelCount)*uint64(levelSize)
+	if tables > h.VertexOffset || h.VertexOffset > h.IndexOffset || h.IndexOffset > size ||
+		uint64(h.VertexCount)*uint64(h.Stride) > h.IndexOffset-h.VertexOffset ||
+		4*uint64(h.IndexCount) > size-h.IndexOffset {
+		return nil, errors.New("meshcache: truncated")
+	}
+	vertexEnd := h.VertexOffset + uint64(h.VertexCount)*uint64(h.Stride)
+	indexEnd := h.IndexOffset + 4*uint64(h.IndexCount)
+	if checksum(data) != h.Checksum {
+		return nil, ErrChecksum
+	}
+
+	f := &File{
+		VertexCount: int(h.VertexCount),
+		Bounds:      mesh.Bounds{Min: h.BoundsMin, Max: h.BoundsMax},
+		data:        data,
+		vertices:    data[h.VertexOffset:vertexEnd],
+		indices:     data[h.IndexOffset:indexEnd],
+	}
+	r := bytes.NewReader(data[len(magic)+headerSize : tables])
+	stride := 0
+	for i := 0; i < int(h.AttributeCount); i++ {
+		var a attribute
+		binary.Read(r, binary.LittleEndian, &a)
+		name := string(bytes.TrimRight(a.Name[:], "\x00"))
+		f.Attributes = append(f.Attributes, mesh.Attribute{Name: name, Size: int(a.Size), Offset: int(a.Offset)})
+		if end := int(a.Offset) + int(a.Size); end > stride {
+			stride = end
+		}
+	}
+	if 4*stride != int(h.Stride) {
+		return nil, fmt.Errorf("meshcache: attributes take %d bytes of a %d byte vertex", 4*stride, h.Stride)
+	}
+	for i := 0; i < int(h.LevelCount); i++ {
+		var l level
+		binary.Read(r, binary.LittleEndian, &l)
+		if uint64(l.First)+uint64(l.Count) > uint64(h.IndexCount) {
+			return nil, fmt.Errorf("meshcache: level %d out of range", i)
+		}
+		f.Levels = append(f.Levels, Level{First: int(l.First), Count: int(l.Count), Error: l.Error})
+	}
+	// the indices go to the GPU unread, an out of range one would draw garbage
+	for i := 0; i < len(f.indices); i += 4 {
+		if v := binary.LittleEndian.Uint32(f.indices[i:]); v >= h.VertexCount {
+			return nil, fmt.Errorf("meshcache: index %d is %d, past %d vertices", i/4, v, h.VertexCount)
+		}
+	}
+	return f, nil
+}
+
+// Stride returns the size of one vertex in bytes.
+func (f *File) Stride() int {
+	return len(f.vertices) / f.VertexCount
+}
+
+// VertexData returns the interleaved vertices, laid out as Attributes
+// describes, to hand to gl.BufferData as they are.
+func (f *File) VertexData() []byte {
+	return f.vertices
+}
+
+// IndexData returns the uint32 indices of all the levels, to hand to
+// gl.BufferData as they are.
+func (f *File) IndexData() []byte {
+	return f.indices
+}
+
+// Mesh decodes the vertices and the indices of the first level into a
+// mesh, a copy that outlives the File.
+func (f *File) Mesh() (*mesh.Mesh, error) {
+	stride := f.Stride() / 4
+	vertices := make([]float32, f.VertexCount*stride)
+	for i := range vertices {
+		vertices[i] = math.Float32frombits(binary.LittleEndian.Uint32(f.vertices[4*i:]))
+	}
+	m, err := mesh.FromVertices(vertices, f.Attributes...)
+	if nil != err {
+		return nil, fmt.Errorf("meshcache: %v", err)
+	}
+	m.Indices = nil
+	if 0 != len(f.Levels) {
+		m.Indices = f.levelIndices(f.Levels[0])
+	}
+	return m, nil
+}
+
+// LODs decodes the indices of every level.
+func (f *File) LODs() []mesh.LOD {
+	lods := make([]mesh.LOD, len(f.Levels))
+	for i, l := range f.Levels {
+		lods[i] = mesh.LOD{Indices: f.levelIndices(l), Error: l.Error}
+	}
+	return lods
+}
+
+func (f *File) levelIndices(l Level) []uint32 {
+	indices := make([]uint32, l.Count)
+	for i := range indices {
+		indices[i] = binary.LittleEndian.Uint32(f.indices[4*(l.First+i):])
+	}
+	return indices
+}
+
+// Close releases the mapping. The slices returned by VertexData and
+// IndexData must not be used afterwards.
+func (f *File) Close() error {
+	f.data, f.vertices, f.indices = nil, nil, nil
+	if nil == f.unmap {
+		return nil
+	}
+	unmap := f.unmap
+	f.unmap = nil
+	return unmap()
+}
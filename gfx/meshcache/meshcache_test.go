@@ -0,0 +1,178 @@
+package meshcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+)
+
+func TestRoundTrip(t *testing.T) {
+	m := mesh.Sphere(1, 16, 8)
+	lods := m.GenerateLODs(3, 0.5)
+
+	dir, err := ioutil.TempDir("", "meshcache")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "sphere.mesh")
+	if err := WriteFile(file, m, lods); nil != err {
+		t.Fatal(err)
+	}
+
+	f, err := Open(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.VertexCount != m.VertexCount() || f.Stride() != 4*m.Stride() || f.Bounds != m.Bounds() {
+		t.Errorf("%d vertices of %d bytes in %v", f.VertexCount, f.Stride(), f.Bounds)
+	}
+	if len(f.Attributes) != len(m.Attributes()) {
+		t.Errorf("attributes %v", f.Attributes)
+	}
+	// the vertex data is what MakeVbo would upload, byte for byte
+	var want bytes.Buffer
+	binary.Write(&want, binary.LittleEndian, m.Interleaved())
+	if !bytes.Equal(f.VertexData(), want.Bytes()) {
+		t.Error("vertex data differs from Interleaved")
+	}
+	if len(f.IndexData()) != 4*(len(lods[0].Indices)+len(lods[1].Indices)+len(lods[2].Indices)) {
+		t.Errorf("%d bytes of indices", len(f.IndexData()))
+	}
+
+	got, err := f.Mesh()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got.VertexCount() != m.VertexCount() || len(got.Indices) != len(m.Indices) {
+		t.Fatalf("%d vertices %d indices", got.VertexCount(), len(got.Indices))
+	}
+	for i := range m.Positions {
+		if got.Positions[i] != m.Positions[i] || got.UVs[i] != m.UVs[i] || got.Tangents[i] != m.Tangents[i] {
+			t.Fatalf("vertex %d differs", i)
+		}
+	}
+	for i, lod := range f.LODs() {
+		if lod.Error != lods[i].Error || len(lod.Indices) != len(lods[i].Indices) {
+			t.Errorf("level %d: %d indices error %f", i, len(lod.Indices), lod.Error)
+		}
+		for k := range lod.Indices {
+			if lod.Indices[k] != lods[i].Indices[k] {
+				t.Fatalf("level %d differs at %d", i, k)
+			}
+		}
+	}
+}
+
+func TestWithoutLODs(t *testing.T) {
+	m := mesh.Cube(2, 1)
+	var buf bytes.Buffer
+	if err := Write(&buf, m, nil); nil != err {
+		t.Fatal(err)
+	}
+	f, err := Decode(buf.Bytes())
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 1 != len(f.Levels) || f.Levels[0].Count != len(m.Indices) {
+		t.Errorf("levels %+v", f.Levels)
+	}
+	if 0 != bytes.Index(buf.Bytes(), f.VertexData())%16 {
+		t.Error("vertex data is not aligned")
+	}
+}
+
+func TestDamaged(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, mesh.Cube(2, 1), nil); nil != err {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-5] ^= 1
+	if _, err := Decode(flipped); ErrChecksum != err {
+		t.Errorf("flipped bit: %v", err)
+	}
+	if _, err := Decode(data[:len(data)-4]); nil == err {
+		t.Error("truncated file accepted")
+	}
+	if _, err := Decode([]byte("# obj file")); nil == err {
+		t.Error("not a mesh cache accepted")
+	}
+	newer := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(newer[len(magic):], Version+1)
+	if _, err := Decode(newer); nil == err || ErrChecksum == err {
+		t.Errorf("newer version: %v", err)
+	}
+}
+
+func TestCorruptHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, mesh.Cube(2, 1), nil); nil != err {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// the offsets sit just before the checksum, which is recomputed so that
+	// only the checks on the header itself can catch them
+	vertexOffset, indexOffset := checksumOffset-16, checksumOffset-8
+	resealed := func(edit func(b []byte)) []byte {
+		b := append([]byte(nil), data...)
+		edit(b)
+		binary.LittleEndian.PutUint32(b[checksumOffset:], checksum(b))
+		return b
+	}
+
+	wrapped := resealed(func(b []byte) {
+		binary.LittleEndian.PutUint64(b[vertexOffset:], 1<<64-8)
+	})
+	if _, err := Decode(wrapped); nil == err || ErrChecksum == err {
+		t.Errorf("wrapping vertex offset: %v", err)
+	}
+	past := resealed(func(b []byte) {
+		binary.LittleEndian.PutUint64(b[indexOffset:], uint64(len(b)+4))
+	})
+	if _, err := Decode(past); nil == err || ErrChecksum == err {
+		t.Errorf("index offset past the end: %v", err)
+	}
+	outOfRange := resealed(func(b []byte) {
+		first := binary.LittleEndian.Uint64(b[indexOffset:])
+		binary.LittleEndian.PutUint32(b[first:], 1000)
+	})
+	if _, err := Decode(outOfRange); nil == err || ErrChecksum == err {
+		t.Errorf("index out of range: %v", err)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if nil == Write(&buf, &mesh.Mesh{}, nil) {
+		t.Error("wrote an empty mesh")
+	}
+	m := mesh.Cube(2, 1)
+	bad := []mesh.LOD{{Indices: []uint32{0, 1, uint32(m.VertexCount())}}}
+	if nil == Write(&buf, m, bad) {
+		t.Error("wrote an index out of range")
+	}
+}
+
+func TestDataOffsetsPast4GiB(t *testing.T) {
+	// 2^28 vertices of 32 bytes are 8 GiB, which wraps to 0 in 32 bits
+	vertexOffset, indexOffset := dataOffsets(100, 1<<28, 32)
+	if 112 != vertexOffset {
+		t.Errorf("vertex offset %d, want 112", vertexOffset)
+	}
+	if want := uint64(112 + 8<<30); indexOffset != want {
+		t.Errorf("index offset %d, want %d", indexOffset, want)
+	}
+	if _, indexOffset := dataOffsets(16, 3, 12); 64 != indexOffset {
+		t.Errorf("index offset %d after 36 bytes of vertices, want 64", indexOffset)
+	}
+}
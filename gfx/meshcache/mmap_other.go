@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package meshcache
+
+import "io/ioutil"
+
+// mapFile reads the whole file where there is no mmap to use.
+func mapFile(file string) ([]byte, func() error, error) {
+	data, err := ioutil.ReadFile(file)
+	if nil != err {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
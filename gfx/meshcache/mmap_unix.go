@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package meshcache
+
+import (
+	"os"
+	"syscall"
+)
+
+func mapFile(file string) ([]byte, func() error, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if nil != err {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if 0 == size || int64(int(size)) != size {
+		// nothing to map, let parse complain
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if nil != err {
+		return nil, nil, &os.PathError{Op: "mmap", Path: file, Err: err}
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
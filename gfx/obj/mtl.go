@@ -0,0 +1,190 @@
+package obj
+
+import (
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Material is a newmtl block of an MTL library. Texture paths are resolved
+// relative to the library when it was read from a file, so they can go
+// straight to gfx.NewTexture.
+type Material struct {
+	Name string
+
+	Ambient   mgl32.Vec3 // Ka
+	Diffuse   mgl32.Vec3 // Kd
+	Specular  mgl32.Vec3 // Ks
+	Emissive  mgl32.Vec3 // Ke
+	Shininess float32    // Ns, the specular exponent
+	Opacity   float32    // d, or 1-Tr
+	IOR       float32    // Ni
+	Illum     int        // the illumination model
+
+	AmbientMap   string // map_Ka
+	DiffuseMap   string // map_Kd
+	SpecularMap  string // map_Ks
+	ShininessMap string // map_Ns
+	EmissiveMap  string // map_Ke
+	AlphaMap     string // map_d
+	BumpMap      string // map_Bump or bump, exporters often put normal maps here
+	NormalMap    string // norm
+	BumpScale    float32
+}
+
+func newMaterial(name string) *Material {
+	return &Material{
+		Name:      name,
+		Diffuse:   mgl32.Vec3{1, 1, 1},
+		Opacity:   1,
+		IOR:       1,
+		BumpScale: 1,
+	}
+}
+
+// DecodeMaterials reads an MTL library from r. Texture paths are returned
+// as written.
+func DecodeMaterials(r io.Reader) (map[string]*Material, error) {
+	return decodeMaterials("mtl", "", r)
+}
+
+func decodeMaterials(name, dir string, r io.Reader) (map[string]*Material, error) {
+	p := &parser{name: name}
+	materials := map[string]*Material{}
+	var m *Material
+	err := eachLine(r, func(line int, fields []string) error {
+		p.line = line
+		key, args := fields[0], fields[1:]
+		if "newmtl" == key {
+			m = newMaterial(strings.Join(args, " "))
+			materials[m.Name] = m
+			return nil
+		}
+		if nil == m {
+			return p.errorf("%s before newmtl", key)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "ka":
+			m.Ambient, err = p.color(args)
+		case "kd":
+			m.Diffuse, err = p.color(args)
+		case "ks":
+			m.Specular, err = p.color(args)
+		case "ke":
+			m.Emissive, err = p.color(args)
+		case "ns":
+			m.Shininess, err = p.float(args)
+		case "ni":
+			m.IOR, err = p.float(args)
+		case "d":
+			// "d -halo 0.5" is rare enough to read as plain d
+			if len(args) > 1 {
+				args = args[len(args)-1:]
+			}
+			m.Opacity, err = p.float(args)
+		case "tr":
+			var tr float32
+			tr, err = p.float(args)
+			m.Opacity = 1 - tr
+		case "illum":
+			var f float32
+			f, err = p.float(args)
+			m.Illum = int(f)
+		case "map_ka":
+			m.AmbientMap, _ = p.textureMap(dir, args)
+		case "map_kd":
+			m.DiffuseMap, _ = p.textureMap(dir, args)
+		case "map_ks":
+			m.SpecularMap, _ = p.textureMap(dir, args)
+		case "map_ns":
+			m.ShininessMap, _ = p.textureMap(dir, args)
+		case "map_ke":
+			m.EmissiveMap, _ = p.textureMap(dir, args)
+		case "map_d":
+			m.AlphaMap, _ = p.textureMap(dir, args)
+		case "map_bump", "bump":
+			m.BumpMap, m.BumpScale = p.textureMap(dir, args)
+		case "norm", "map_norm":
+			m.NormalMap, _ = p.textureMap(dir, args)
+		}
+		return err
+	})
+	if nil != err {
+		return nil, err
+	}
+	return materials, nil
+}
+
+func (p *parser) float(args []string) (float32, error) {
+	f, err := p.floats(args, 1, 1)
+	if nil != err {
+		return 0, err
+	}
+	return f[0], nil
+}
+
+// color parses "r g b", or a single value for gray. The spectral and xyz
+// forms are not supported.
+func (p *parser) color(args []string) (mgl32.Vec3, error) {
+	f, err := p.floats(args, 1, 3)
+	if nil != err {
+		return mgl32.Vec3{}, err
+	}
+	if len(f) < 3 {
+		return mgl32.Vec3{f[0], f[0], f[0]}, nil
+	}
+	return mgl32.Vec3{f[0], f[1], f[2]}, nil
+}
+
+// textureOptions maps the texture map options to how many arguments they
+// take at most.
+var textureOptions = map[string]int{
+	"-blendu": 1, "-blendv": 1, "-bm": 1, "-boost": 1, "-cc": 1,
+	"-clamp": 1, "-imfchan": 1, "-mm": 2, "-o": 3, "-s": 3, "-t": 3,
+	"-texres": 1, "-type": 1,
+}
+
+// textureMap skips the options of a map_ statement and returns its file
+// along with the -bm bump multiplier, 1 when not given.
+func (p *parser) textureMap(dir string, args []string) (string, float32) {
+	scale := float32(1)
+	i := 0
+	for i < len(args) {
+		n, ok := textureOptions[strings.ToLower(args[i])]
+		if !ok {
+			break
+		}
+		option := strings.ToLower(args[i])
+		i++
+		for taken := 0; taken < n && i < len(args); taken++ {
+			f, err := strconv.ParseFloat(args[i], 32)
+			if nil != err && taken > 0 {
+				// -o, -s and -t take one to three numbers
+				break
+			}
+			if "-bm" == option && nil == err {
+				scale = float32(f)
+			}
+			i++
+		}
+	}
+	if i >= len(args) {
+		return "", scale
+	}
+	return resolvePath(dir, strings.Join(args[i:], " ")), scale
+}
+
+// resolvePath makes file relative to dir unless it is absolute. Backslashes
+// from Windows exporters are turned into separators.
+func resolvePath(dir, file string) string {
+	file = filepath.FromSlash(strings.Replace(file, "\\", "/", -1))
+	if "" == dir || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
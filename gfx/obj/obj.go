@@ -0,0 +1,449 @@
+// Package obj reads Wavefront OBJ models and their MTL material libraries
+// into indexed meshes ready for gfx.MakeVbo and gfx.MakeEbo.
+//
+// Polygons are triangulated, negative (relative) indices are resolved and
+// normals missing from the file are generated per smoothing group.
+package obj
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Model is a decoded OBJ file. All its faces share one vertex and index
+// buffer; Groups split the index buffer by object, group and material.
+type Model struct {
+	Mesh      *mesh.Mesh
+	Groups    []Group
+	Materials map[string]*Material // from every mtllib, by name
+}
+
+// Group is a run of triangles drawn with one material, Mesh.Indices[First :
+// First+Count].
+type Group struct {
+	Object   string // the last "o" statement
+	Name     string // the last "g" statement
+	Material string // the "usemtl" in effect, "" for none
+	First    int
+	Count    int
+}
+
+// Material returns the material of g, nil if it has none or it was not in
+// any material library.
+func (m *Model) Material(g Group) *Material {
+	return m.Materials[g.Material]
+}
+
+// Decoder reads OBJ files. The zero value reads from disk.
+//
+// Material libraries are resolved relative to the OBJ file; models that do
+// not come from a file resolve them relative to Dir.
+type Decoder struct {
+	Dir string
+
+	// ReadFile replaces ioutil.ReadFile, mostly for tests.
+	ReadFile func(file string) ([]byte, error)
+}
+
+// Decode reads an OBJ model from r, material libraries from the working
+// directory.
+func Decode(r io.Reader) (*Model, error) {
+	return new(Decoder).Decode(r)
+}
+
+// DecodeFile reads an OBJ file and the material libraries it references.
+func DecodeFile(file string) (*Model, error) {
+	return new(Decoder).DecodeFile(file)
+}
+
+// Decode reads an OBJ model from r.
+func (d *Decoder) Decode(r io.Reader) (*Model, error) {
+	return d.decode("obj", d.Dir, r)
+}
+
+// DecodeFile reads an OBJ file and the material libraries it references.
+func (d *Decoder) DecodeFile(file string) (*Model, error) {
+	src, err := d.readFile(file)
+	if nil != err {
+		return nil, err
+	}
+	return d.decode(file, filepath.Dir(file), bytes.NewReader(src))
+}
+
+func (d *Decoder) readFile(file string) ([]byte, error) {
+	if nil != d.ReadFile {
+		return d.ReadFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+func (d *Decoder) decode(name, dir string, r io.Reader) (*Model, error) {
+	p := &parser{
+		d:        d,
+		name:     name,
+		dir:      dir,
+		model:    &Model{Mesh: &mesh.Mesh{}, Materials: map[string]*Material{}},
+		vertices: map[vertexKey]uint32{},
+	}
+	err := eachLine(r, func(line int, fields []string) error {
+		p.line = line
+		return p.statement(fields)
+	})
+	if nil != err {
+		return nil, err
+	}
+	p.finish()
+	return p.model, nil
+}
+
+// eachLine calls fn with the whitespace separated fields of every line of r
+// that is not blank or a comment, joining lines continued with a backslash.
+func eachLine(r io.Reader, fn func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var pending string
+	start := 0
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if "" == pending {
+			start = n
+		}
+		if strings.HasSuffix(text, "\\") {
+			pending += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		text, pending = pending+text, ""
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if 0 == len(fields) {
+			continue
+		}
+		if err := fn(start, fields); nil != err {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// vertexKey identifies an output vertex. Corners without a normal also key
+// on their smoothing group, or on their face when smoothing is off, so the
+// generated normals are shared exactly where the file asks for it.
+type vertexKey struct {
+	v, vt, vn int // 0-based, -1 when missing
+	smooth    int
+}
+
+type parser struct {
+	d         *Decoder
+	name, dir string
+	line      int
+
+	positions []mgl32.Vec3
+	colors    []mgl32.Vec4 // parallel to positions once a colored v shows up
+	uvs       []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	object, group, material string
+	smooth                  int // current smoothing group, 0 for off
+	faces                   int
+
+	model     *Model
+	vertices  map[vertexKey]uint32
+	generated []bool // per output vertex, normal still to be computed
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) statement(fields []string) error {
+	args := fields[1:]
+	switch fields[0] {
+	case "v":
+		f, err := p.floats(args, 3, 7)
+		if nil != err {
+			return err
+		}
+		p.positions = append(p.positions, mgl32.Vec3{f[0], f[1], f[2]})
+		// "v x y z r g b" is a common extension, "v x y z w" is the spec
+		if 6 <= len(f) || nil != p.colors {
+			c := mgl32.Vec4{1, 1, 1, 1}
+			if 6 <= len(f) {
+				rgb := f[len(f)-3:]
+				c = mgl32.Vec4{rgb[0], rgb[1], rgb[2], 1}
+			}
+			for len(p.colors) < len(p.positions)-1 {
+				p.colors = append(p.colors, mgl32.Vec4{1, 1, 1, 1})
+			}
+			p.colors = append(p.colors, c)
+		}
+	case "vt":
+		f, err := p.floats(args, 1, 3)
+		if nil != err {
+			return err
+		}
+		uv := mgl32.Vec2{f[0], 0}
+		if len(f) > 1 {
+			uv[1] = f[1]
+		}
+		p.uvs = append(p.uvs, uv)
+	case "vn":
+		f, err := p.floats(args, 3, 3)
+		if nil != err {
+			return err
+		}
+		p.normals = append(p.normals, mgl32.Vec3{f[0], f[1], f[2]})
+	case "f", "fo":
+		return p.face(args)
+	case "o":
+		p.object = strings.Join(args, " ")
+	case "g":
+		p.group = strings.Join(args, " ")
+	case "usemtl":
+		p.material = strings.Join(args, " ")
+	case "s":
+		p.smooth = 0
+		if 1 == len(args) && "off" != args[0] {
+			s, err := strconv.Atoi(args[0])
+			if nil != err {
+				return p.errorf("bad smoothing group %q", args[0])
+			}
+			p.smooth = s
+		}
+	case "mtllib":
+		for _, lib := range libraryNames(args) {
+			if err := p.loadMaterials(lib); nil != err {
+				return err
+			}
+		}
+	}
+	// points, lines, curves and render attributes are not meshes, skip them
+	return nil
+}
+
+// libraryNames splits the mtllib arguments. Names may contain spaces, so
+// the split only happens between names ending in ".mtl".
+func libraryNames(args []string) []string {
+	var names []string
+	start := 0
+	for i, a := range args {
+		if strings.HasSuffix(strings.ToLower(a), ".mtl") {
+			names = append(names, strings.Join(args[start:i+1], " "))
+			start = i + 1
+		}
+	}
+	if start < len(args) {
+		names = append(names, strings.Join(args[start:], " "))
+	}
+	return names
+}
+
+func (p *parser) loadMaterials(lib string) error {
+	file := resolvePath(p.dir, lib)
+	src, err := p.d.readFile(file)
+	if nil != err {
+		return p.errorf("mtllib: %v", err)
+	}
+	materials, err := decodeMaterials(file, filepath.Dir(file), bytes.NewReader(src))
+	if nil != err {
+		return err
+	}
+	for name, m := range materials {
+		p.model.Materials[name] = m
+	}
+	return nil
+}
+
+// floats parses between min and max numbers.
+func (p *parser) floats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min || len(args) > max {
+		return nil, p.errorf("want %d to %d numbers, got %d", min, max, len(args))
+	}
+	out := make([]float32, len(args))
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 32)
+		if nil != err {
+			return nil, p.errorf("bad number %q", a)
+		}
+		out[i] = float32(f)
+	}
+	return out, nil
+}
+
+// index resolves a 1-based or negative OBJ index into a slice of length n.
+func (p *parser) index(s string, n int, kind string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if nil != err {
+		return 0, p.errorf("bad %s index %q", kind, s)
+	}
+	if i < 0 {
+		i += n
+	} else {
+		i--
+	}
+	if i < 0 || i >= n {
+		return 0, p.errorf("%s index %s out of range, %d defined", kind, s, n)
+	}
+	return i, nil
+}
+
+func (p *parser) face(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face with %d vertices", len(args))
+	}
+	p.faces++
+
+	corners := make([]vertexKey, len(args))
+	points := make([]mgl32.Vec3, len(args))
+	for i, a := range args {
+		parts := strings.Split(a, "/")
+		if len(parts) > 3 {
+			return p.errorf("bad face vertex %q", a)
+		}
+		k := vertexKey{vt: -1, vn: -1}
+		var err error
+		if k.v, err = p.index(parts[0], len(p.positions), "vertex"); nil != err {
+			return err
+		}
+		if len(parts) > 1 && "" != parts[1] {
+			if k.vt, err = p.index(parts[1], len(p.uvs), "texture"); nil != err {
+				return err
+			}
+		}
+		if len(parts) > 2 && "" != parts[2] {
+			if k.vn, err = p.index(parts[2], len(p.normals), "normal"); nil != err {
+				return err
+			}
+		}
+		if k.vn < 0 {
+			k.smooth = p.smooth
+			if 0 == k.smooth {
+				// flat shaded, the vertex belongs to this face alone
+				k.smooth = -p.faces
+			}
+		}
+		corners[i] = k
+		points[i] = p.positions[k.v]
+	}
+
+	g := p.currentGroup()
+	m := p.model.Mesh
+	for _, tri := range triangulate(points) {
+		var idx [3]uint32
+		for j, c := range tri {
+			idx[j] = p.vertex(corners[c])
+		}
+		m.Indices = append(m.Indices, idx[:]...)
+		g.Count += 3
+
+		// weighted by the corner angle, so how a polygon was split into
+		// triangles does not tilt the normals
+		a, b, c := m.Positions[idx[0]], m.Positions[idx[1]], m.Positions[idx[2]]
+		n := b.Sub(a).Cross(c.Sub(a))
+		if n.Len() == 0 {
+			continue
+		}
+		n = n.Normalize()
+		for j, i := range idx {
+			if p.generated[i] {
+				corner := m.Positions[i]
+				e1 := m.Positions[idx[(j+1)%3]].Sub(corner)
+				e2 := m.Positions[idx[(j+2)%3]].Sub(corner)
+				m.Normals[i] = m.Normals[i].Add(n.Mul(angle(e1, e2)))
+			}
+		}
+	}
+	return nil
+}
+
+// angle returns the angle between a and b in radians.
+func angle(a, b mgl32.Vec3) float32 {
+	la, lb := a.Len(), b.Len()
+	if 0 == la || 0 == lb {
+		return 0
+	}
+	cos := float64(a.Dot(b) / (la * lb))
+	return float32(math.Acos(math.Max(-1, math.Min(1, cos))))
+}
+
+// currentGroup returns the group faces go into, starting a new one when the
+// object, group or material changed since the last face.
+func (p *parser) currentGroup() *Group {
+	groups := p.model.Groups
+	if n := len(groups); n > 0 {
+		last := &groups[n-1]
+		if last.Object == p.object && last.Name == p.group && last.Material == p.material {
+			return last
+		}
+	}
+	p.model.Groups = append(groups, Group{
+		Object:   p.object,
+		Name:     p.group,
+		Material: p.material,
+		First:    len(p.model.Mesh.Indices),
+	})
+	return &p.model.Groups[len(p.model.Groups)-1]
+}
+
+// vertex returns the output index for k, adding the vertex if it is new.
+func (p *parser) vertex(k vertexKey) uint32 {
+	if i, ok := p.vertices[k]; ok {
+		return i
+	}
+	m := p.model.Mesh
+	i := uint32(len(m.Positions))
+	p.vertices[k] = i
+
+	m.Positions = append(m.Positions, p.positions[k.v])
+	var uv mgl32.Vec2
+	if k.vt >= 0 {
+		uv = p.uvs[k.vt]
+	}
+	m.UVs = append(m.UVs, uv)
+	var n mgl32.Vec3
+	if k.vn >= 0 {
+		n = p.normals[k.vn]
+	}
+	m.Normals = append(m.Normals, n)
+	p.generated = append(p.generated, k.vn < 0)
+	if nil != p.colors {
+		c := mgl32.Vec4{1, 1, 1, 1}
+		if k.v < len(p.colors) {
+			c = p.colors[k.v]
+		}
+		for len(m.Colors) < len(m.Positions)-1 {
+			m.Colors = append(m.Colors, mgl32.Vec4{1, 1, 1, 1})
+		}
+		m.Colors = append(m.Colors, c)
+	}
+	return i
+}
+
+// finish normalizes the generated normals and drops attributes no face
+// referenced.
+func (p *parser) finish() {
+	m := p.model.Mesh
+	for i, generated := range p.generated {
+		if generated && m.Normals[i].Len() > 0 {
+			m.Normals[i] = m.Normals[i].Normalize()
+		}
+	}
+	if 0 == len(p.uvs) {
+		m.UVs = nil
+	}
+	if 0 == len(m.Positions) {
+		m.Normals = nil
+	}
+}
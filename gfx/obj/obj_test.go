@@ -0,0 +1,300 @@
+package obj
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// memFiles serves files from a map instead of the disk.
+func memFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(file string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(file)]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %v", file, os.ErrNotExist)
+		}
+		return []byte(src), nil
+	}
+}
+
+const quadOBJ = `
+# a unit quad
+mtllib quad.mtl
+o quad
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+`
+
+const quadMTL = `
+newmtl red
+Ka 0.1 0.1 0.1
+Kd 1 0 0
+Ks 0.5
+Ns 32
+d 0.5
+illum 2
+map_Kd -s 2 2 textures\red.png
+map_Bump -bm 0.25 normal.png
+norm normal.png
+`
+
+func TestDecodeQuad(t *testing.T) {
+	d := &Decoder{ReadFile: memFiles(map[string]string{
+		"models/quad.obj": quadOBJ,
+		"models/quad.mtl": quadMTL,
+	})}
+	model, err := d.DecodeFile("models/quad.obj")
+	if nil != err {
+		t.Fatal(err)
+	}
+	m := model.Mesh
+	if err := m.Validate(); nil != err {
+		t.Fatal(err)
+	}
+	if m.VertexCount() != 4 || m.TriangleCount() != 2 {
+		t.Fatalf("%d vertices, %d triangles, want 4 and 2", m.VertexCount(), m.TriangleCount())
+	}
+	if len(m.UVs) != 4 || len(m.Normals) != 4 || nil != m.Colors {
+		t.Errorf("attributes uv %d normal %d color %d", len(m.UVs), len(m.Normals), len(m.Colors))
+	}
+	for i := 0; i < len(m.Indices); i += 3 {
+		if n := triangleNormal(m.Positions, m.Indices[i:i+3]); n.Z() <= 0 {
+			t.Errorf("triangle %d winds clockwise", i/3)
+		}
+	}
+	if m.Stride() != 8 {
+		t.Errorf("stride %d, want 8 for position, normal and uv", m.Stride())
+	}
+
+	if len(model.Groups) != 1 {
+		t.Fatalf("%d groups, want 1", len(model.Groups))
+	}
+	g := model.Groups[0]
+	if g.Object != "quad" || g.Material != "red" || g.First != 0 || g.Count != 6 {
+		t.Errorf("group %+v", g)
+	}
+	mat := model.Material(g)
+	if nil == mat {
+		t.Fatal("material red not loaded")
+	}
+	if mat.Diffuse != (mgl32.Vec3{1, 0, 0}) || mat.Specular != (mgl32.Vec3{0.5, 0.5, 0.5}) ||
+		mat.Shininess != 32 || mat.Opacity != 0.5 || mat.Illum != 2 {
+		t.Errorf("material %+v", mat)
+	}
+	if want := filepath.Join("models", "textures", "red.png"); mat.DiffuseMap != want {
+		t.Errorf("diffuse map %q, want %q", mat.DiffuseMap, want)
+	}
+	if mat.BumpMap != filepath.Join("models", "normal.png") || mat.BumpScale != 0.25 {
+		t.Errorf("bump map %q scale %v", mat.BumpMap, mat.BumpScale)
+	}
+	if mat.NormalMap != filepath.Join("models", "normal.png") {
+		t.Errorf("normal map %q", mat.NormalMap)
+	}
+}
+
+func TestNegativeIndices(t *testing.T) {
+	model, err := Decode(strings.NewReader(`
+v 0 0 0
+v 1 0 0
+v 0 1 0
+f -3 -2 -1
+v 5 0 0
+v 6 0 0
+v 5 1 0
+f -3 -2 -1
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	m := model.Mesh
+	if m.TriangleCount() != 2 {
+		t.Fatalf("%d triangles, want 2", m.TriangleCount())
+	}
+	if p := m.Positions[m.Indices[3]]; p != (mgl32.Vec3{5, 0, 0}) {
+		t.Errorf("second triangle starts at %v, want (5, 0, 0)", p)
+	}
+}
+
+func TestBadIndex(t *testing.T) {
+	for _, src := range []string{
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf -4 2 3\n",
+	} {
+		_, err := Decode(strings.NewReader(src))
+		if nil == err {
+			t.Errorf("no error for %q", src)
+		} else if !strings.Contains(err.Error(), ":4:") {
+			t.Errorf("error %q does not name line 4", err)
+		}
+	}
+}
+
+func TestConcavePolygon(t *testing.T) {
+	// an L shape, a fan from the first corner would cover the notch
+	model, err := Decode(strings.NewReader(`
+v 0 0 0
+v 2 0 0
+v 2 1 0
+v 1 1 0
+v 1 2 0
+v 0 2 0
+f 1 2 3 4 5 6
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	m := model.Mesh
+	if m.TriangleCount() != 4 {
+		t.Fatalf("%d triangles, want 4", m.TriangleCount())
+	}
+	var area float32
+	for i := 0; i < len(m.Indices); i += 3 {
+		n := triangleNormal(m.Positions, m.Indices[i:i+3])
+		if n.Z() <= 0 {
+			t.Errorf("triangle %d is flipped or degenerate", i/3)
+		}
+		area += n.Z() / 2
+	}
+	if area != 3 {
+		t.Errorf("triangles cover %v, want the L's area 3", area)
+	}
+}
+
+func TestSmoothingGroups(t *testing.T) {
+	// two faces of a roof sharing the ridge edge 2-3
+	roof := `
+v 0 0 0
+v 1 1 0
+v 1 1 1
+v 0 0 1
+v 2 0 0
+v 2 0 1
+%s
+f 1 4 3 2
+%s
+f 2 3 6 5
+`
+	smooth, err := Decode(strings.NewReader(fmt.Sprintf(roof, "s 1", "")))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if n := smooth.Mesh.VertexCount(); n != 6 {
+		t.Errorf("smooth roof has %d vertices, want 6", n)
+	}
+	var ridge mgl32.Vec3
+	for i, p := range smooth.Mesh.Positions {
+		if p == (mgl32.Vec3{1, 1, 0}) {
+			ridge = smooth.Mesh.Normals[i]
+		}
+	}
+	if ridge.Sub(mgl32.Vec3{0, 1, 0}).Len() > 1e-5 {
+		t.Errorf("ridge normal %v, want straight up", ridge)
+	}
+
+	flat, err := Decode(strings.NewReader(fmt.Sprintf(roof, "s off", "s 2")))
+	if nil != err {
+		t.Fatal(err)
+	}
+	m := flat.Mesh
+	if n := m.VertexCount(); n != 8 {
+		t.Errorf("flat roof has %d vertices, want 8", n)
+	}
+	for i, n := range m.Normals {
+		if abs(n.Len()-1) > 1e-5 || abs(n.Y()-0.70710677) > 1e-5 {
+			t.Errorf("normal %d is %v, want a face normal", i, n)
+		}
+	}
+}
+
+func TestGroupsAndColors(t *testing.T) {
+	model, err := Decode(strings.NewReader(`
+v 0 0 0 1 0 0
+v 1 0 0 0 1 0
+v 0 1 0 0 0 1
+g a
+usemtl one
+f 1 2 3
+usemtl two
+f 1 2 3
+g b
+f 1 2 3
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(model.Groups) != 3 {
+		t.Fatalf("%d groups, want 3", len(model.Groups))
+	}
+	want := []Group{
+		{Name: "a", Material: "one", First: 0, Count: 3},
+		{Name: "a", Material: "two", First: 3, Count: 3},
+		{Name: "b", Material: "two", First: 6, Count: 3},
+	}
+	for i, g := range model.Groups {
+		if g != want[i] {
+			t.Errorf("group %d is %+v, want %+v", i, g, want[i])
+		}
+	}
+	if nil != model.Material(model.Groups[0]) {
+		t.Error("material without a library should be nil")
+	}
+	m := model.Mesh
+	if len(m.Colors) != m.VertexCount() || m.Colors[m.Indices[1]] != (mgl32.Vec4{0, 1, 0, 1}) {
+		t.Errorf("colors %v", m.Colors)
+	}
+}
+
+func TestMissingLibrary(t *testing.T) {
+	d := &Decoder{ReadFile: memFiles(nil)}
+	if _, err := d.Decode(strings.NewReader("mtllib gone.mtl\n")); nil == err {
+		t.Error("expected an error for a missing material library")
+	}
+}
+
+func TestDecodeMaterials(t *testing.T) {
+	materials, err := DecodeMaterials(strings.NewReader(`
+newmtl glass
+Tr 0.75
+map_Kd -o 0.5 -clamp on glass pane.png
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	m := materials["glass"]
+	if nil == m {
+		t.Fatal("glass not decoded")
+	}
+	if m.Opacity != 0.25 {
+		t.Errorf("opacity %v, want 0.25", m.Opacity)
+	}
+	if m.DiffuseMap != "glass pane.png" {
+		t.Errorf("diffuse map %q", m.DiffuseMap)
+	}
+	if m.Diffuse != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("default diffuse %v, want white", m.Diffuse)
+	}
+
+	if _, err := DecodeMaterials(strings.NewReader("Kd 1 1 1\n")); nil == err {
+		t.Error("expected an error for a statement before newmtl")
+	}
+}
+
+func triangleNormal(positions []mgl32.Vec3, tri []uint32) mgl32.Vec3 {
+	a, b, c := positions[tri[0]], positions[tri[1]], positions[tri[2]]
+	return b.Sub(a).Cross(c.Sub(a))
+}
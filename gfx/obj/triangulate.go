@@ -0,0 +1,97 @@
+package obj
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// triangulate splits a polygon into triangles by ear clipping in the plane
+// of its Newell normal, keeping the winding of the polygon. The triangles
+// index into points. Concave polygons come out right; self-intersecting
+// ones fall back to a fan.
+func triangulate(points []mgl32.Vec3) [][3]int {
+	n := len(points)
+	if 3 == n {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	// project onto the axis plane the polygon faces most
+	var normal mgl32.Vec3
+	for i, a := range points {
+		b := points[(i+1)%n]
+		normal[0] += (a[1] - b[1]) * (a[2] + b[2])
+		normal[1] += (a[2] - b[2]) * (a[0] + b[0])
+		normal[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	u, v := 0, 1
+	switch {
+	case abs(normal[0]) >= abs(normal[1]) && abs(normal[0]) >= abs(normal[2]):
+		u, v = 1, 2
+	case abs(normal[1]) >= abs(normal[2]):
+		u, v = 2, 0
+	}
+	// after the projection the polygon winds counter-clockwise when the
+	// dropped component of the normal is positive
+	sign := float32(1)
+	if normal[3-u-v] < 0 {
+		sign = -1
+	}
+	p := make([]mgl32.Vec2, n)
+	for i, pt := range points {
+		p[i] = mgl32.Vec2{pt[u], pt[v]}
+	}
+	cross := func(a, b, c int) float32 {
+		ab, ac := p[b].Sub(p[a]), p[c].Sub(p[a])
+		return sign * (ab[0]*ac[1] - ab[1]*ac[0])
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	tris := make([][3]int, 0, n-2)
+	for len(remaining) > 3 {
+		m := len(remaining)
+		clipped := false
+		for i := 0; i < m; i++ {
+			a, b, c := remaining[(i+m-1)%m], remaining[i], remaining[(i+1)%m]
+			if cross(a, b, c) <= 0 {
+				continue // reflex or degenerate corner
+			}
+			ear := true
+			for _, q := range remaining {
+				if q != a && q != b && q != c && inside(cross, a, b, c, q) {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				tris = append(tris, [3]int{a, b, c})
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				clipped = true
+				break
+			}
+		}
+		if !clipped {
+			return fan(n)
+		}
+	}
+	return append(tris, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+// inside reports whether q lies in or on the counter-clockwise triangle abc.
+func inside(cross func(a, b, c int) float32, a, b, c, q int) bool {
+	return cross(a, b, q) >= 0 && cross(b, c, q) >= 0 && cross(c, a, q) >= 0
+}
+
+func fan(n int) [][3]int {
+	tris := make([][3]int, 0, n-2)
+	for i := 1; i+1 < n; i++ {
+		tris = append(tris, [3]int{0, i, i + 1})
+	}
+	return tris
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,345 @@
+// Package orbit is a camera controller for inspecting a model: the camera
+// circles a target point, dragging turns it around the target, scrolling
+// dollies in and out and a second drag pans the target across the screen.
+//
+// Rotation is either a turntable, which keeps the up axis upright, or
+// Shoemake's arcball, which turns the model as if it were a ball under the
+// cursor. Both coast on after the drag ends when Damping is set.
+//
+// The controller only does the math; programs feed it cursor positions and
+// scroll offsets from their glfw callbacks and hand its view and projection
+// matrices to their shaders.
+package orbit
+
+import (
+	"math"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mode selects how dragging turns the camera.
+type Mode int
+
+// The rotation modes.
+const (
+	// Turntable turns around the world up axis and tilts up and down, never
+	// past MaxPitch.
+	Turntable Mode = iota
+	// Arcball turns freely, as Shoemake's arcball.
+	Arcball
+)
+
+// MaxPitch is how far, in radians, the turntable tilts above or below the
+// target, short of looking straight down where the up axis degenerates.
+const MaxPitch = 89 * math.Pi / 180
+
+// stopSpeed is the speed, in radians or distances per second, under which
+// coasting stops.
+const stopSpeed = 1e-4
+
+type drag int
+
+const (
+	none drag = iota
+	rotating
+	panning
+)
+
+// Controller orbits a camera around Target.
+type Controller struct {
+	Target   mgl32.Vec3
+	Distance float32 // from Target to the eye
+	// Rotation turns the camera from looking down -z with +y up. The eye
+	// is at Target + Rotation.Rotate({0, 0, Distance}).
+	Rotation mgl32.Quat
+
+	Fov         float32 // vertical field of view in radians
+	RotateSpeed float32 // turntable radians per pixel
+	ZoomSpeed   float32 // share of Distance per scroll step
+	MinDistance float32
+	MaxDistance float32 // 0 for no limit
+	// Damping is how fast, per second, the camera slows down after a drag
+	// is released. 0 stops it at once.
+	Damping float32
+
+	mode          Mode
+	width, height int
+
+	drag       drag
+	x, y       float64 // last cursor position
+	yaw, pitch float32 // turntable angles
+
+	// motion since the last Update, and the velocity it made
+	turn, turnVelocity mgl32.Vec2 // turntable yaw and pitch
+	spin, spinVelocity mgl32.Vec3 // arcball axis times angle, in camera space
+	pan, panVelocity   mgl32.Vec3 // world
+}
+
+// NewController returns a turntable controller looking at target from
+// distance along +z, for a viewport of width by height pixels.
+func NewController(target mgl32.Vec3, distance float32, width, height int) *Controller {
+	return &Controller{
+		Target:      target,
+		Distance:    distance,
+		Rotation:    mgl32.QuatIdent(),
+		Fov:         mgl32.DegToRad(45),
+		RotateSpeed: 0.01,
+		ZoomSpeed:   0.1,
+		MinDistance: 0.01,
+		Damping:     5,
+		width:       width,
+		height:      height,
+	}
+}
+
+// Mode returns the rotation mode.
+func (c *Controller) Mode() Mode {
+	return c.mode
+}
+
+// SetMode switches the rotation mode. The turntable starts from the current
+// view with any roll the arcball gave it leveled out.
+func (c *Controller) SetMode(mode Mode) {
+	c.mode = mode
+	c.Stop()
+	if Turntable == mode {
+		c.level()
+		c.Rotation = c.turntable()
+	}
+}
+
+// SetViewport sets the size of the viewport in pixels, after the window
+// was resized.
+func (c *Controller) SetViewport(width, height int) {
+	c.width, c.height = width, height
+}
+
+// Aspect returns the aspect ratio of the viewport.
+func (c *Controller) Aspect() float32 {
+	if c.height <= 0 {
+		return 1
+	}
+	return float32(c.width) / float32(c.height)
+}
+
+// BeginRotate starts turning the camera with the cursor at x, y.
+func (c *Controller) BeginRotate(x, y float64) {
+	c.begin(rotating, x, y)
+	if Turntable == c.mode {
+		c.level()
+	}
+}
+
+// BeginPan starts moving the target with the cursor at x, y.
+func (c *Controller) BeginPan(x, y float64) {
+	c.begin(panning, x, y)
+}
+
+func (c *Controller) begin(d drag, x, y float64) {
+	c.Stop()
+	c.drag = d
+	c.x, c.y = x, y
+}
+
+// End ends the drag. The camera coasts on at the speed it had.
+func (c *Controller) End() {
+	c.drag = none
+}
+
+// Dragging reports whether a drag is going on.
+func (c *Controller) Dragging() bool {
+	return none != c.drag
+}
+
+// Stop halts any coasting.
+func (c *Controller) Stop() {
+	c.turn, c.turnVelocity = mgl32.Vec2{}, mgl32.Vec2{}
+	c.spin, c.spinVelocity = mgl32.Vec3{}, mgl32.Vec3{}
+	c.pan, c.panVelocity = mgl32.Vec3{}, mgl32.Vec3{}
+}
+
+// Move follows the cursor to x, y, in window coordinates with y down.
+func (c *Controller) Move(x, y float64) {
+	dx, dy := float32(x-c.x), float32(y-c.y)
+	switch {
+	case rotating == c.drag && Turntable == c.mode:
+		turn := mgl32.Vec2{-dx, -dy}.Mul(c.RotateSpeed)
+		c.turn = c.turn.Add(turn)
+		c.rotateTurntable(turn)
+	case rotating == c.drag && Arcball == c.mode:
+		q := c.arcball(c.x, c.y, x, y)
+		// the model follows the cursor, so the camera turns the other way
+		spin := axisAngle(q.Conjugate())
+		c.spin = c.spin.Add(spin)
+		c.rotateArcball(spin)
+	case panning == c.drag && c.height > 0:
+		// the point under the cursor stays under it on the target plane; a
+		// minimized window has no height to measure pixels by
+		perPixel := 2 * c.Distance * float32(math.Tan(float64(c.Fov/2))) / float32(c.height)
+		right := c.Rotation.Rotate(mgl32.Vec3{1, 0, 0})
+		up := c.Rotation.Rotate(mgl32.Vec3{0, 1, 0})
+		pan := right.Mul(-dx * perPixel).Add(up.Mul(dy * perPixel))
+		c.pan = c.pan.Add(pan)
+		c.Target = c.Target.Add(pan)
+	}
+	c.x, c.y = x, y
+}
+
+// Scroll dollies in for positive offsets and out for negative ones, as
+// glfw reports the wheel.
+func (c *Controller) Scroll(offset float64) {
+	c.Distance *= float32(math.Exp(-float64(c.ZoomSpeed) * offset))
+	c.clampDistance()
+}
+
+func (c *Controller) clampDistance() {
+	if c.Distance < c.MinDistance {
+		c.Distance = c.MinDistance
+	}
+	if c.MaxDistance > 0 && c.Distance > c.MaxDistance {
+		c.Distance = c.MaxDistance
+	}
+}
+
+// Update advances the camera by dt seconds. While dragging it measures how
+// fast the cursor moves, afterwards it keeps moving at that speed, slowing
+// down by Damping.
+func (c *Controller) Update(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	if c.Dragging() {
+		c.turnVelocity = c.turn.Mul(1 / dt)
+		c.spinVelocity = c.spin.Mul(1 / dt)
+		c.panVelocity = c.pan.Mul(1 / dt)
+		c.turn, c.spin, c.pan = mgl32.Vec2{}, mgl32.Vec3{}, mgl32.Vec3{}
+		return
+	}
+	if c.Damping <= 0 {
+		c.Stop()
+		return
+	}
+	// the distance the decaying speed covers in dt, so that how far the
+	// camera coasts does not depend on the frame rate
+	decay := float32(math.Exp(float64(-c.Damping * dt)))
+	travel := (1 - decay) / c.Damping
+	if c.turnVelocity.Len() > stopSpeed {
+		c.rotateTurntable(c.turnVelocity.Mul(travel))
+	}
+	if c.spinVelocity.Len() > stopSpeed {
+		c.rotateArcball(c.spinVelocity.Mul(travel))
+	}
+	if c.panVelocity.Len() > stopSpeed*c.Distance {
+		c.Target = c.Target.Add(c.panVelocity.Mul(travel))
+	}
+	c.turnVelocity = c.turnVelocity.Mul(decay)
+	c.spinVelocity = c.spinVelocity.Mul(decay)
+	c.panVelocity = c.panVelocity.Mul(decay)
+}
+
+func (c *Controller) rotateTurntable(turn mgl32.Vec2) {
+	c.yaw += turn[0]
+	c.pitch = mgl32.Clamp(c.pitch+turn[1], -MaxPitch, MaxPitch)
+	c.Rotation = c.turntable()
+}
+
+func (c *Controller) turntable() mgl32.Quat {
+	yaw := mgl32.QuatRotate(c.yaw, mgl32.Vec3{0, 1, 0})
+	return yaw.Mul(mgl32.QuatRotate(c.pitch, mgl32.Vec3{1, 0, 0}))
+}
+
+// level takes the turntable angles from Rotation, dropping any roll.
+func (c *Controller) level() {
+	front := c.Rotation.Rotate(mgl32.Vec3{0, 0, -1})
+	c.pitch = mgl32.Clamp(float32(math.Asin(float64(mgl32.Clamp(front.Y(), -1, 1)))), -MaxPitch, MaxPitch)
+	if math.Abs(float64(front.Y())) < 1-1e-6 {
+		c.yaw = float32(math.Atan2(float64(-front.X()), float64(-front.Z())))
+	}
+}
+
+func (c *Controller) rotateArcball(spin mgl32.Vec3) {
+	if angle := spin.Len(); angle > 0 {
+		c.Rotation = c.Rotation.Mul(mgl32.QuatRotate(angle, spin.Mul(1/angle))).Normalize()
+	}
+}
+
+// arcball returns Shoemake's rotation for dragging from x0, y0 to x1, y1:
+// twice the arc between the points on the ball, so that dragging around a
+// loop turns the model back where it was.
+func (c *Controller) arcball(x0, y0, x1, y1 float64) mgl32.Quat {
+	a, b := c.onBall(x0, y0), c.onBall(x1, y1)
+	return mgl32.Quat{W: a.Dot(b), V: a.Cross(b)}
+}
+
+// onBall maps a cursor position onto the unit ball filling the smaller side
+// of the viewport, or onto its rim outside of it, in camera space.
+func (c *Controller) onBall(x, y float64) mgl32.Vec3 {
+	radius := math.Min(float64(c.width), float64(c.height)) / 2
+	if radius <= 0 {
+		return mgl32.Vec3{0, 0, 1}
+	}
+	p := mgl32.Vec3{
+		float32((x - float64(c.width)/2) / radius),
+		float32((float64(c.height)/2 - y) / radius),
+	}
+	if d := p.Dot(p); d < 1 {
+		p[2] = float32(math.Sqrt(float64(1 - d)))
+		return p
+	}
+	return p.Normalize()
+}
+
+// axisAngle returns the axis of unit quaternion q scaled by its angle.
+func axisAngle(q mgl32.Quat) mgl32.Vec3 {
+	if q.W < 0 {
+		q = q.Scale(-1)
+	}
+	s := q.V.Len()
+	if s < 1e-9 {
+		return mgl32.Vec3{}
+	}
+	angle := 2 * float32(math.Atan2(float64(s), float64(q.W)))
+	return q.V.Mul(angle / s)
+}
+
+// Frame moves the target to the center of b and the eye back far enough
+// for the sphere around b to fit the view in both directions, keeping the
+// direction it looks from. MaxDistance does not hold it back.
+func (c *Controller) Frame(b mesh.Bounds) {
+	if b.Empty() {
+		return
+	}
+	c.Stop()
+	c.Target = b.Center()
+	radius := b.Radius()
+	if radius <= 0 {
+		radius = 1
+	}
+	half := float64(c.Fov / 2)
+	if aspect := float64(c.Aspect()); aspect < 1 {
+		// the narrower horizontal field of view decides
+		half = math.Atan(math.Tan(half) * aspect)
+	}
+	c.Distance = radius / float32(math.Sin(half))
+	if c.Distance < c.MinDistance {
+		c.Distance = c.MinDistance
+	}
+}
+
+// Eye returns the position of the camera.
+func (c *Controller) Eye() mgl32.Vec3 {
+	return c.Target.Add(c.Rotation.Rotate(mgl32.Vec3{0, 0, c.Distance}))
+}
+
+// ViewMatrix returns the view matrix of the camera.
+func (c *Controller) ViewMatrix() mgl32.Mat4 {
+	eye := c.Eye()
+	return c.Rotation.Conjugate().Mat4().Mul4(mgl32.Translate3D(-eye[0], -eye[1], -eye[2]))
+}
+
+// ProjectionMatrix returns a perspective projection for the viewport, with
+// clip planes that follow Distance so that zooming never clips the target.
+func (c *Controller) ProjectionMatrix() mgl32.Mat4 {
+	return mgl32.Perspective(c.Fov, c.Aspect(), c.Distance/100, c.Distance*100)
+}
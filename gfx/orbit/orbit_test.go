@@ -0,0 +1,218 @@
+package orbit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func near(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-4
+}
+
+func TestTurntable(t *testing.T) {
+	c := NewController(mgl32.Vec3{1, 0, 0}, 5, 800, 600)
+	if !near(c.Eye(), mgl32.Vec3{1, 0, 5}) {
+		t.Fatalf("eye %v", c.Eye())
+	}
+
+	// dragging right turns the model right, so the eye goes left around it
+	c.BeginRotate(400, 300)
+	c.Move(400+math.Pi/2/0.01, 300)
+	c.End()
+	if !near(c.Eye(), mgl32.Vec3{-4, 0, 0}) {
+		t.Errorf("after a quarter turn eye %v", c.Eye())
+	}
+
+	// dragging down tilts the top of the model forward, the eye goes up,
+	// but never over the top
+	c.BeginRotate(0, 0)
+	c.Move(0, 1000)
+	c.End()
+	if c.pitch != -MaxPitch {
+		t.Errorf("pitch %v, want %v", c.pitch, -MaxPitch)
+	}
+	if eye := c.Eye(); eye.Y() < 4.99 || eye.X() > 1 {
+		t.Errorf("eye %v not above the target", eye)
+	}
+	up := c.Rotation.Rotate(mgl32.Vec3{0, 1, 0})
+	right := c.Rotation.Rotate(mgl32.Vec3{1, 0, 0})
+	if up.Y() <= 0 || mgl32.Abs(right.Y()) > 1e-6 {
+		t.Errorf("turntable rolled: up %v right %v", up, right)
+	}
+}
+
+func TestArcball(t *testing.T) {
+	c := NewController(mgl32.Vec3{}, 5, 800, 600)
+	c.SetMode(Arcball)
+
+	// half the radius from the center is a 30 degree arc, Shoemake's
+	// arcball turns twice that
+	c.BeginRotate(400, 300)
+	c.Move(400+150, 300)
+	c.End()
+	front := c.Rotation.Rotate(mgl32.Vec3{0, 0, -1})
+	if d := front.Dot(mgl32.Vec3{0, 0, -1}); mgl32.Abs(d-0.5) > 1e-5 {
+		t.Errorf("turned %v degrees, want 60", mgl32.RadToDeg(float32(math.Acos(float64(d)))))
+	}
+	if !near(c.Eye(), mgl32.Vec3{-5 * 0.8660254, 0, 2.5}) {
+		t.Errorf("eye %v", c.Eye())
+	}
+
+	// any loop, even over the rim, brings the model back
+	c = NewController(mgl32.Vec3{}, 5, 800, 600)
+	c.SetMode(Arcball)
+	c.BeginRotate(400, 300)
+	for _, p := range [][2]float64{{500, 250}, {700, 500}, {50, 20}, {380, 310}, {400, 300}} {
+		c.Move(p[0], p[1])
+	}
+	c.End()
+	if !c.Rotation.OrientationEqualThreshold(mgl32.QuatIdent(), 1e-4) {
+		t.Errorf("loop left rotation %v", c.Rotation)
+	}
+
+	// back on the turntable the roll is leveled out
+	c.Rotation = mgl32.QuatRotate(1, mgl32.Vec3{1, 2, 3}.Normalize())
+	c.SetMode(Turntable)
+	if right := c.Rotation.Rotate(mgl32.Vec3{1, 0, 0}); mgl32.Abs(right.Y()) > 1e-6 {
+		t.Errorf("right %v after leveling", right)
+	}
+}
+
+func TestPan(t *testing.T) {
+	c := NewController(mgl32.Vec3{}, 5, 800, 600)
+	c.BeginPan(100, 100)
+	c.Move(100+600, 100-300)
+	c.End()
+	// a whole viewport height spans the view at the target
+	height := 2 * 5 * float32(math.Tan(float64(c.Fov/2)))
+	if want := (mgl32.Vec3{-height, -height / 2, 0}); !near(c.Target, want) {
+		t.Errorf("target %v, want %v", c.Target, want)
+	}
+	if !near(c.Eye().Sub(c.Target), mgl32.Vec3{0, 0, 5}) {
+		t.Errorf("panning turned the camera")
+	}
+
+	// a minimized window reports a 0x0 framebuffer
+	target := c.Target
+	c.SetViewport(0, 0)
+	c.BeginPan(0, 0)
+	c.Move(50, 50)
+	c.End()
+	if c.Target != target {
+		t.Errorf("panning a minimized window moved the target to %v", c.Target)
+	}
+}
+
+func TestScroll(t *testing.T) {
+	c := NewController(mgl32.Vec3{}, 5, 800, 600)
+	c.Scroll(1)
+	c.Scroll(-1)
+	if mgl32.Abs(c.Distance-5) > 1e-5 {
+		t.Errorf("in and out again: distance %v", c.Distance)
+	}
+	c.Scroll(2)
+	if c.Distance >= 5 {
+		t.Errorf("scrolling up moved out to %v", c.Distance)
+	}
+	c.MaxDistance = 6
+	c.Scroll(-100)
+	if 6 != c.Distance {
+		t.Errorf("distance %v past the limit", c.Distance)
+	}
+	c.Scroll(1000)
+	if c.MinDistance != c.Distance {
+		t.Errorf("distance %v past the limit", c.Distance)
+	}
+}
+
+func TestInertia(t *testing.T) {
+	c := NewController(mgl32.Vec3{}, 5, 800, 600)
+	c.BeginRotate(0, 0)
+	c.Move(10, 0)
+	c.Update(0.1)
+	c.End()
+	// 100 pixels a second at 0.01 radians a pixel
+	if !near(c.turnVelocity.Vec3(0), mgl32.Vec3{-1, 0, 0}) {
+		t.Fatalf("velocity %v", c.turnVelocity)
+	}
+	yaw := c.yaw
+	c.Update(0.1)
+	if c.yaw >= yaw {
+		t.Errorf("did not coast: yaw %v then %v", yaw, c.yaw)
+	}
+	for i := 0; i < 100; i++ {
+		c.Update(0.1)
+	}
+	// it coasts speed / Damping radians in all
+	if moved := yaw - c.yaw; mgl32.Abs(moved-0.2) > 1e-3 {
+		t.Errorf("coasted %v radians", moved)
+	}
+	if c.turnVelocity.Len() > stopSpeed {
+		t.Errorf("still turning at %v", c.turnVelocity)
+	}
+
+	// holding the cursor still before letting go throws nothing
+	c.BeginRotate(0, 0)
+	c.Move(10, 0)
+	c.Update(0.1)
+	c.Update(0.1)
+	c.End()
+	yaw = c.yaw
+	c.Update(0.1)
+	if yaw != c.yaw {
+		t.Errorf("coasted from a still cursor")
+	}
+
+	c.Damping = 0
+	c.BeginRotate(0, 0)
+	c.Move(10, 0)
+	c.Update(0.1)
+	c.End()
+	yaw = c.yaw
+	c.Update(0.1)
+	if yaw != c.yaw {
+		t.Errorf("coasted without damping")
+	}
+}
+
+func TestFrame(t *testing.T) {
+	for _, size := range [][2]int{{800, 600}, {300, 900}} {
+		c := NewController(mgl32.Vec3{}, 1, size[0], size[1])
+		c.Rotation = mgl32.QuatRotate(0.5, mgl32.Vec3{0, 1, 0})
+		m := mesh.Sphere(2, 16, 8)
+		m.Transform(mgl32.Translate3D(10, -3, 4))
+		b := m.Bounds()
+		c.Frame(b)
+		if !near(c.Target, b.Center()) {
+			t.Errorf("%v: target %v, want %v", size, c.Target, b.Center())
+		}
+		// every corner of the box lands on screen, and the sphere touches
+		// the edge of the view
+		vp := c.ProjectionMatrix().Mul4(c.ViewMatrix())
+		for i := 0; i < 8; i++ {
+			corner := b.Min
+			for axis := 0; axis < 3; axis++ {
+				if 0 != i&(1<<uint(axis)) {
+					corner[axis] = b.Max[axis]
+				}
+			}
+			p := mgl32.TransformCoordinate(corner, vp)
+			if mgl32.Abs(p.X()) > 1 || mgl32.Abs(p.Y()) > 1 || mgl32.Abs(p.Z()) > 1 {
+				t.Errorf("%v: corner %v off screen at %v", size, corner, p)
+			}
+		}
+		half := float64(c.Fov / 2)
+		if size[0] < size[1] {
+			half = math.Atan(math.Tan(half) * float64(c.Aspect()))
+		}
+		if got := c.Distance * float32(math.Sin(half)); mgl32.Abs(got-b.Radius()) > 1e-4 {
+			t.Errorf("%v: sphere of radius %v framed as %v", size, b.Radius(), got)
+		}
+		if !near(c.Rotation.Rotate(mgl32.Vec3{0, 0, -1}), mgl32.QuatRotate(0.5, mgl32.Vec3{0, 1, 0}).Rotate(mgl32.Vec3{0, 0, -1})) {
+			t.Errorf("%v: framing turned the camera", size)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// pixelData is an image's memory described the way glTexImage2D wants it.
+type pixelData struct {
+	pix           []uint8 // starts at the first pixel of the image
+	stride        int     // bytes between rows, may exceed width*pixelSize
+	width, height int
+	pixelSize     int
+
+	internal  int32  // internal format without sRGB
+	format    uint32 // gl.RED, gl.RG or gl.RGBA
+	xtype     uint32 // gl.UNSIGNED_BYTE or gl.UNSIGNED_SHORT
+	bigEndian bool   // Go stores 16 bit samples big-endian
+	swizzle   []int32
+}
+
+var (
+	swizzleGray      = []int32{gl.RED, gl.RED, gl.RED, gl.ONE}
+	swizzleGrayAlpha = []int32{gl.RED, gl.RED, gl.RED, gl.GREEN}
+)
+
+// newPixelData picks an upload format matching img's layout, falling back
+// to an RGBA copy for layouts OpenGL cannot read directly. srgb rules out
+// gray+alpha in RG8, as sRGB decoding would apply to its alpha.
+func newPixelData(img image.Image, srgb bool) *pixelData {
+	switch m := img.(type) {
+	case *image.Gray:
+		return packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 1, gl.R8, gl.RED, gl.UNSIGNED_BYTE, swizzleGray)
+	case *image.Gray16:
+		px := packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 2, gl.R16, gl.RED, gl.UNSIGNED_SHORT, swizzleGray)
+		px.bigEndian = true
+		return px
+	case *image.RGBA:
+		return packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 4, gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	case *image.NRGBA:
+		return packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 4, gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	case *image.RGBA64:
+		px := packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 8, gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, nil)
+		px.bigEndian = true
+		return px
+	case *image.NRGBA64:
+		px := packed(m.Pix[m.PixOffset(m.Rect.Min.X, m.Rect.Min.Y):], m.Stride, m.Rect, 8, gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, nil)
+		px.bigEndian = true
+		return px
+	case *image.Paletted:
+		if px := grayPalette(m); nil != px && !(srgb && gl.RG8 == px.internal) {
+			return px
+		}
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return packed(rgba.Pix, rgba.Stride, rgba.Rect, 4, gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+}
+
+func packed(pix []uint8, stride int, rect image.Rectangle, pixelSize int, internal int32, format, xtype uint32, swizzle []int32) *pixelData {
+	return &pixelData{
+		pix:       pix,
+		stride:    stride,
+		width:     rect.Dx(),
+		height:    rect.Dy(),
+		pixelSize: pixelSize,
+		internal:  internal,
+		format:    format,
+		xtype:     xtype,
+		swizzle:   swizzle,
+	}
+}
+
+// grayPalette expands a palette of grays into R8, or RG8 when some entries
+// are translucent. It returns nil for palettes with colors.
+func grayPalette(m *image.Paletted) *pixelData {
+	gray := make([][2]uint8, len(m.Palette))
+	opaque := true
+	for i, c := range m.Palette {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		if n.R != n.G || n.G != n.B {
+			return nil
+		}
+		gray[i] = [2]uint8{n.R, n.A}
+		opaque = opaque && 0xff == n.A
+	}
+
+	channels := 2
+	if opaque {
+		channels = 1
+	}
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	pix := make([]uint8, 0, w*h*channels)
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		row := m.Pix[m.PixOffset(m.Rect.Min.X, y):][:w]
+		for _, index := range row {
+			var g [2]uint8
+			if int(index) < len(gray) {
+				g = gray[index]
+			}
+			pix = append(pix, g[:channels]...)
+		}
+	}
+	rect := image.Rect(0, 0, w, h)
+	if opaque {
+		return packed(pix, w, rect, 1, gl.R8, gl.RED, gl.UNSIGNED_BYTE, swizzleGray)
+	}
+	return packed(pix, 2*w, rect, 2, gl.RG8, gl.RG, gl.UNSIGNED_BYTE, swizzleGrayAlpha)
+}
+
+// rowLength is the UNPACK_ROW_LENGTH for the stride, in pixels.
+func (px *pixelData) rowLength() int {
+	return px.stride / px.pixelSize
+}
+
+// internalFormat returns the storage format. 8 bit data can be stored as sRGB;
+// gray data is then kept in the red channel of SRGB8, which the swizzle
+// spreads back over all channels. There is no 16 bit sRGB format.
+func (px *pixelData) internalFormat(srgb bool) int32 {
+	if !srgb {
+		return px.internal
+	}
+	switch px.internal {
+	case gl.R8:
+		return gl.SRGB8
+	case gl.RGBA8:
+		return gl.SRGB8_ALPHA8
+	}
+	return px.internal
+}
+
+// flipped returns a tightly packed copy with the rows in reverse order.
+func (px *pixelData) flipped() *pixelData {
+	row := px.width * px.pixelSize
+	pix := make([]uint8, row*px.height)
+	for y := 0; y < px.height; y++ {
+		src := px.pix[y*px.stride:][:row]
+		copy(pix[(px.height-1-y)*row:], src)
+	}
+	out := *px
+	out.pix = pix
+	out.stride = row
+	return &out
+}
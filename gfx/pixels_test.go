@@ -0,0 +1,103 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestPixelDataFormats(t *testing.T) {
+	rect := image.Rect(0, 0, 4, 2)
+	cases := []struct {
+		name     string
+		img      image.Image
+		internal int32
+		format   uint32
+		xtype    uint32
+		swap     bool
+	}{
+		{"gray", image.NewGray(rect), gl.R8, gl.RED, gl.UNSIGNED_BYTE, false},
+		{"gray16", image.NewGray16(rect), gl.R16, gl.RED, gl.UNSIGNED_SHORT, true},
+		{"rgba", image.NewRGBA(rect), gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, false},
+		{"nrgba", image.NewNRGBA(rect), gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, false},
+		{"rgba64", image.NewRGBA64(rect), gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, true},
+		{"nrgba64", image.NewNRGBA64(rect), gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, true},
+		{"ycbcr", image.NewYCbCr(rect, image.YCbCrSubsampleRatio420), gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, false},
+		{"gray palette", image.NewPaletted(rect, color.Palette{color.Gray{0}, color.Gray{255}}), gl.R8, gl.RED, gl.UNSIGNED_BYTE, false},
+		{"gray alpha palette", image.NewPaletted(rect, color.Palette{color.Transparent, color.White}), gl.RG8, gl.RG, gl.UNSIGNED_BYTE, false},
+		{"color palette", image.NewPaletted(rect, color.Palette{color.RGBA{255, 0, 0, 255}}), gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, false},
+	}
+	for _, c := range cases {
+		px := newPixelData(c.img, false)
+		if px.internal != c.internal || px.format != c.format || px.xtype != c.xtype || px.bigEndian != c.swap {
+			t.Errorf("%s: got 0x%x/0x%x/0x%x swap %v", c.name, px.internal, px.format, px.xtype, px.bigEndian)
+		}
+		if px.width != 4 || px.height != 2 {
+			t.Errorf("%s: size %dx%d", c.name, px.width, px.height)
+		}
+		if len(px.pix) < (px.height-1)*px.stride+px.width*px.pixelSize {
+			t.Errorf("%s: %d bytes of pixels is too short", c.name, len(px.pix))
+		}
+	}
+}
+
+func TestPixelDataSubImage(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 10, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 10; x++ {
+			gray.SetGray(x, y, color.Gray{uint8(10*y + x)})
+		}
+	}
+	sub := gray.SubImage(image.Rect(3, 2, 7, 5)).(*image.Gray)
+
+	px := newPixelData(sub, false)
+	if px.width != 4 || px.height != 3 {
+		t.Fatalf("size %dx%d, want 4x3", px.width, px.height)
+	}
+	if px.rowLength() != 10 {
+		t.Errorf("row length %d, want 10", px.rowLength())
+	}
+	if px.pix[0] != 23 || px.pix[px.stride] != 33 {
+		t.Errorf("first column %d, %d, want 23, 33", px.pix[0], px.pix[px.stride])
+	}
+
+	flipped := px.flipped()
+	if flipped.rowLength() != 4 {
+		t.Errorf("flipped row length %d, want 4", flipped.rowLength())
+	}
+	want := []uint8{43, 44, 45, 46, 33, 34, 35, 36, 23, 24, 25, 26}
+	for i, v := range want {
+		if flipped.pix[i] != v {
+			t.Fatalf("flipped pixels %v, want %v", flipped.pix, want)
+		}
+	}
+}
+
+func TestPixelDataGrayAlphaPalette(t *testing.T) {
+	pal := color.Palette{color.NRGBA{0, 0, 0, 0}, color.NRGBA{200, 200, 200, 128}}
+	img := image.NewPaletted(image.Rect(0, 0, 2, 1), pal)
+	img.SetColorIndex(1, 0, 1)
+
+	px := newPixelData(img, false)
+	want := []uint8{0, 0, 200, 128}
+	for i, v := range want {
+		if px.pix[i] != v {
+			t.Fatalf("pixels %v, want %v", px.pix, want)
+		}
+	}
+}
+
+func TestPixelDataSRGB(t *testing.T) {
+	if f := newPixelData(image.NewGray(image.Rect(0, 0, 1, 1)), true).internalFormat(true); f != gl.SRGB8 {
+		t.Errorf("gray sRGB format 0x%x, want SRGB8", f)
+	}
+	if f := newPixelData(image.NewRGBA64(image.Rect(0, 0, 1, 1)), true).internalFormat(true); f != gl.RGBA16 {
+		t.Errorf("16 bit sRGB format 0x%x, want RGBA16", f)
+	}
+	pal := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Transparent, color.White})
+	if px := newPixelData(pal, true); px.internalFormat(true) != gl.SRGB8_ALPHA8 || px.format != gl.RGBA {
+		t.Errorf("gray+alpha sRGB uploads as 0x%x/0x%x, want RGBA", px.internalFormat(true), px.format)
+	}
+}
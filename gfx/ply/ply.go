@@ -0,0 +1,502 @@
+// Package ply reads and writes Stanford PLY models, ASCII and binary in
+// either byte order, as indexed meshes ready for gfx.MakeVbo and
+// gfx.MakeEbo.
+//
+// Vertex positions, normals, texture coordinates and colors are read;
+// other properties and elements are skipped. Faces with more than three
+// vertices are split into fans. Nothing here needs a GL context, so
+// meshconv can convert PLY models on a headless machine.
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Format selects the PLY encoding.
+type Format int
+
+// The PLY encodings.
+const (
+	BinaryLittleEndian Format = iota
+	BinaryBigEndian
+	ASCII
+)
+
+var formatNames = map[string]Format{
+	"binary_little_endian": BinaryLittleEndian,
+	"binary_big_endian":    BinaryBigEndian,
+	"ascii":                ASCII,
+}
+
+// scalar types by name and their size in bytes
+var typeSizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4,
+	"float": 4, "float32": 4, "double": 8, "float64": 8,
+}
+
+type property struct {
+	name      string
+	typ       string
+	countType string // the type of the count, for lists
+}
+
+type element struct {
+	name       string
+	count      int
+	properties []property
+}
+
+// Decode reads a PLY model.
+func Decode(r io.Reader) (*mesh.Mesh, error) {
+	br := bufio.NewReader(r)
+	format, elements, err := readHeader(br)
+	if nil != err {
+		return nil, err
+	}
+	var values valueReader
+	switch format {
+	case ASCII:
+		values = &asciiReader{r: br}
+	case BinaryLittleEndian:
+		values = &binaryReader{r: br, order: binary.LittleEndian}
+	default:
+		values = &binaryReader{r: br, order: binary.BigEndian}
+	}
+
+	m := &mesh.Mesh{}
+	for _, e := range elements {
+		var err error
+		switch e.name {
+		case "vertex":
+			err = readVertices(values, e, m)
+		case "face":
+			err = readFaces(values, e, m)
+		default:
+			err = skip(values, e)
+		}
+		if nil != err {
+			return nil, fmt.Errorf("ply: %s: %v", e.name, err)
+		}
+	}
+	if err := m.Validate(); nil != err {
+		return nil, fmt.Errorf("ply: %v", err)
+	}
+	return m, nil
+}
+
+// DecodeFile reads a PLY file.
+func DecodeFile(file string) (*mesh.Mesh, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+func readHeader(br *bufio.Reader) (Format, []*element, error) {
+	var format Format = -1
+	var elements []*element
+	for line := 1; ; line++ {
+		text, err := br.ReadString('\n')
+		if nil != err {
+			return 0, nil, errors.New("ply: header does not end")
+		}
+		fields := strings.Fields(text)
+		if 1 == line {
+			if 1 != len(fields) || "ply" != fields[0] {
+				return 0, nil, errors.New("ply: not a PLY file")
+			}
+			continue
+		}
+		if 0 == len(fields) {
+			continue
+		}
+		bad := func() (Format, []*element, error) {
+			return 0, nil, fmt.Errorf("ply:%d: bad %s line", line, fields[0])
+		}
+		switch fields[0] {
+		case "format":
+			if 3 != len(fields) {
+				return bad()
+			}
+			f, ok := formatNames[fields[1]]
+			if !ok {
+				return bad()
+			}
+			if "1.0" != fields[2] {
+				return 0, nil, fmt.Errorf("ply:%d: version %s", line, fields[2])
+			}
+			format = f
+		case "element":
+			if 3 != len(fields) {
+				return bad()
+			}
+			n, err := strconv.Atoi(fields[2])
+			if nil != err || n < 0 {
+				return bad()
+			}
+			elements = append(elements, &element{name: fields[1], count: n})
+		case "property":
+			if 0 == len(elements) {
+				return 0, nil, fmt.Errorf("ply:%d: property before any element", line)
+			}
+			var p property
+			switch {
+			case 3 == len(fields) && 0 != typeSizes[fields[1]]:
+				p = property{name: fields[2], typ: fields[1]}
+			case 5 == len(fields) && "list" == fields[1] && 0 != typeSizes[fields[2]] && 0 != typeSizes[fields[3]]:
+				p = property{name: fields[4], typ: fields[3], countType: fields[2]}
+			default:
+				return bad()
+			}
+			e := elements[len(elements)-1]
+			e.properties = append(e.properties, p)
+		case "comment", "obj_info":
+		case "end_header":
+			if format < 0 {
+				return 0, nil, errors.New("ply: no format line")
+			}
+			return format, elements, nil
+		default:
+			return 0, nil, fmt.Errorf("ply:%d: unknown keyword %q", line, fields[0])
+		}
+	}
+}
+
+// valueReader reads the body one scalar at a time.
+type valueReader interface {
+	read(typ string) (float64, error)
+}
+
+type asciiReader struct {
+	r *bufio.Reader
+}
+
+func (a *asciiReader) read(typ string) (float64, error) {
+	var word []byte
+	for {
+		c, err := a.r.ReadByte()
+		if nil != err {
+			if io.EOF == err && 0 != len(word) {
+				break
+			}
+			return 0, io.ErrUnexpectedEOF
+		}
+		if ' ' == c || '\t' == c || '\n' == c || '\r' == c {
+			if 0 == len(word) {
+				continue
+			}
+			break
+		}
+		word = append(word, c)
+	}
+	f, err := strconv.ParseFloat(string(word), 64)
+	if nil != err {
+		return 0, fmt.Errorf("bad number %q", word)
+	}
+	return f, nil
+}
+
+type binaryReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (b *binaryReader) read(typ string) (float64, error) {
+	p := b.buf[:typeSizes[typ]]
+	if _, err := io.ReadFull(b.r, p); nil != err {
+		return 0, io.ErrUnexpectedEOF
+	}
+	switch typ {
+	case "char", "int8":
+		return float64(int8(p[0])), nil
+	case "uchar", "uint8":
+		return float64(p[0]), nil
+	case "short", "int16":
+		return float64(int16(b.order.Uint16(p))), nil
+	case "ushort", "uint16":
+		return float64(b.order.Uint16(p)), nil
+	case "int", "int32":
+		return float64(int32(b.order.Uint32(p))), nil
+	case "uint", "uint32":
+		return float64(b.order.Uint32(p)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(b.order.Uint32(p))), nil
+	}
+	return math.Float64frombits(b.order.Uint64(p)), nil
+}
+
+// readRow reads one element into values by property name; lists come
+// back in lists.
+func readRow(values valueReader, e *element, row map[string]float64, lists map[string][]float64) error {
+	for _, p := range e.properties {
+		if "" == p.countType {
+			v, err := values.read(p.typ)
+			if nil != err {
+				return err
+			}
+			row[p.name] = v
+			continue
+		}
+		n, err := values.read(p.countType)
+		if nil != err {
+			return err
+		}
+		if n < 0 || n > 1<<20 {
+			return fmt.Errorf("list of %v items", n)
+		}
+		list := lists[p.name][:0]
+		for i := 0; i < int(n); i++ {
+			v, err := values.read(p.typ)
+			if nil != err {
+				return err
+			}
+			list = append(list, v)
+		}
+		lists[p.name] = list
+	}
+	return nil
+}
+
+func skip(values valueReader, e *element) error {
+	row, lists := map[string]float64{}, map[string][]float64{}
+	for i := 0; i < e.count; i++ {
+		if err := readRow(values, e, row, lists); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// property names the common exporters use
+var (
+	uNames     = []string{"u", "s", "texture_u", "texture_s"}
+	vNames     = []string{"v", "t", "texture_v", "texture_t"}
+	colorNames = [4][]string{{"red", "r", "diffuse_red"}, {"green", "g", "diffuse_green"}, {"blue", "b", "diffuse_blue"}, {"alpha", "a"}}
+)
+
+func readVertices(values valueReader, e *element, m *mesh.Mesh) error {
+	types := map[string]string{}
+	for _, p := range e.properties {
+		if "" == p.countType {
+			types[p.name] = p.typ
+		}
+	}
+	find := func(names ...string) string {
+		for _, n := range names {
+			if "" != types[n] {
+				return n
+			}
+		}
+		return ""
+	}
+	for _, axis := range []string{"x", "y", "z"} {
+		if "" == types[axis] {
+			return fmt.Errorf("no %s property", axis)
+		}
+	}
+	hasNormal := "" != types["nx"] && "" != types["ny"] && "" != types["nz"]
+	u, v := find(uNames...), find(vNames...)
+	var color [4]string
+	for i, names := range colorNames {
+		color[i] = find(names...)
+	}
+	hasColor := "" != color[0] && "" != color[1] && "" != color[2]
+
+	row, lists := map[string]float64{}, map[string][]float64{}
+	for i := 0; i < e.count; i++ {
+		if err := readRow(values, e, row, lists); nil != err {
+			return fmt.Errorf("%d: %v", i, err)
+		}
+		m.Positions = append(m.Positions, mgl32.Vec3{float32(row["x"]), float32(row["y"]), float32(row["z"])})
+		if hasNormal {
+			m.Normals = append(m.Normals, mgl32.Vec3{float32(row["nx"]), float32(row["ny"]), float32(row["nz"])})
+		}
+		if "" != u && "" != v {
+			m.UVs = append(m.UVs, mgl32.Vec2{float32(row[u]), float32(row[v])})
+		}
+		if hasColor {
+			c := mgl32.Vec4{1, 1, 1, 1}
+			for k, name := range color {
+				if "" != name {
+					c[k] = channel(row[name], types[name])
+				}
+			}
+			m.Colors = append(m.Colors, c)
+		}
+	}
+	return nil
+}
+
+// channel scales integer color channels to 0-1, floats are taken as they
+// are.
+func channel(value float64, typ string) float32 {
+	switch typ {
+	case "uchar", "uint8":
+		return float32(value / 255)
+	case "ushort", "uint16":
+		return float32(value / 65535)
+	}
+	return float32(value)
+}
+
+func readFaces(values valueReader, e *element, m *mesh.Mesh) error {
+	name := ""
+	for _, p := range e.properties {
+		if "" != p.countType && ("vertex_indices" == p.name || "vertex_index" == p.name) {
+			name = p.name
+		}
+	}
+	if "" == name {
+		return errors.New("no vertex_indices list")
+	}
+	row, lists := map[string]float64{}, map[string][]float64{}
+	for i := 0; i < e.count; i++ {
+		if err := readRow(values, e, row, lists); nil != err {
+			return fmt.Errorf("%d: %v", i, err)
+		}
+		polygon := lists[name]
+		if len(polygon) < 3 {
+			continue // points and lines are not drawn
+		}
+		for _, index := range polygon {
+			if index < 0 || index >= float64(len(m.Positions)) || index != math.Trunc(index) {
+				return fmt.Errorf("%d: bad vertex index %v", i, index)
+			}
+		}
+		for k := 1; k+1 < len(polygon); k++ {
+			m.Indices = append(m.Indices, uint32(polygon[0]), uint32(polygon[k]), uint32(polygon[k+1]))
+		}
+	}
+	return nil
+}
+
+// Encode writes m to w: positions, then normals, texture coordinates as s
+// and t, and colors as bytes where m has them, and the triangles.
+func Encode(w io.Writer, m *mesh.Mesh, format Format) error {
+	if err := m.Validate(); nil != err {
+		return fmt.Errorf("ply: %v", err)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "ply")
+	for name, f := range formatNames {
+		if f == format {
+			fmt.Fprintf(bw, "format %s 1.0\n", name)
+		}
+	}
+	fmt.Fprintln(bw, "comment written by gfx/ply")
+	fmt.Fprintf(bw, "element vertex %d\n", len(m.Positions))
+	fmt.Fprintln(bw, "property float x\nproperty float y\nproperty float z")
+	if 0 != len(m.Normals) {
+		fmt.Fprintln(bw, "property float nx\nproperty float ny\nproperty float nz")
+	}
+	if 0 != len(m.UVs) {
+		fmt.Fprintln(bw, "property float s\nproperty float t")
+	}
+	if 0 != len(m.Colors) {
+		fmt.Fprintln(bw, "property uchar red\nproperty uchar green\nproperty uchar blue\nproperty uchar alpha")
+	}
+	fmt.Fprintf(bw, "element face %d\n", m.TriangleCount())
+	fmt.Fprintln(bw, "property list uchar uint vertex_indices")
+	fmt.Fprintln(bw, "end_header")
+
+	var out valueWriter = &asciiWriter{w: bw}
+	switch format {
+	case BinaryLittleEndian:
+		out = &binaryWriter{w: bw, order: binary.LittleEndian}
+	case BinaryBigEndian:
+		out = &binaryWriter{w: bw, order: binary.BigEndian}
+	}
+	for i, p := range m.Positions {
+		floats := p[:]
+		if 0 != len(m.Normals) {
+			floats = append(floats, m.Normals[i][:]...)
+		}
+		if 0 != len(m.UVs) {
+			floats = append(floats, m.UVs[i][:]...)
+		}
+		for _, f := range floats {
+			out.float(f)
+		}
+		if 0 != len(m.Colors) {
+			for _, c := range m.Colors[i] {
+				out.byte(uint8(math.Round(float64(mgl32.Clamp(c, 0, 1)) * 255)))
+			}
+		}
+		out.end()
+	}
+	for c := 0; c+2 < len(m.Indices); c += 3 {
+		out.byte(3)
+		for _, index := range m.Indices[c : c+3] {
+			out.uint(index)
+		}
+		out.end()
+	}
+	return bw.Flush()
+}
+
+// EncodeFile writes m to file.
+func EncodeFile(file string, m *mesh.Mesh, format Format) error {
+	f, err := os.Create(file)
+	if nil != err {
+		return err
+	}
+	if err := Encode(f, m, format); nil != err {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type valueWriter interface {
+	float(float32)
+	byte(uint8)
+	uint(uint32)
+	end() // of an element
+}
+
+type asciiWriter struct {
+	w       *bufio.Writer
+	started bool // the line has a word on it
+}
+
+func (a *asciiWriter) word(s string) {
+	if a.started {
+		a.w.WriteByte(' ')
+	}
+	a.w.WriteString(s)
+	a.started = true
+}
+
+func (a *asciiWriter) float(f float32) { a.word(strconv.FormatFloat(float64(f), 'g', -1, 32)) }
+func (a *asciiWriter) byte(b uint8)    { a.word(strconv.Itoa(int(b))) }
+func (a *asciiWriter) uint(u uint32)   { a.word(strconv.FormatUint(uint64(u), 10)) }
+func (a *asciiWriter) end() {
+	a.w.WriteByte('\n')
+	a.started = false
+}
+
+type binaryWriter struct {
+	w     *bufio.Writer
+	order binary.ByteOrder
+}
+
+func (b *binaryWriter) float(f float32) { binary.Write(b.w, b.order, f) }
+func (b *binaryWriter) byte(v uint8)    { b.w.WriteByte(v) }
+func (b *binaryWriter) uint(u uint32)   { binary.Write(b.w, b.order, u) }
+func (b *binaryWriter) end()            {}
@@ -0,0 +1,102 @@
+package ply
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const quad = `ply
+format ascii 1.0
+comment a colored quad
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+0 0 0 0 0 1 255 0 0
+1 0 0 0 0 1 0 255 0
+1 1 0 0 0 1 0 0 255
+0 1 0 0 0 1 255 255 255
+4 0 1 2 3
+0 1
+`
+
+func TestDecodeASCII(t *testing.T) {
+	m, err := Decode(strings.NewReader(quad))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if m.VertexCount() != 4 || m.TriangleCount() != 2 {
+		t.Fatalf("%d vertices %d triangles", m.VertexCount(), m.TriangleCount())
+	}
+	if m.Colors[1] != (mgl32.Vec4{0, 1, 0, 1}) || m.Normals[2] != (mgl32.Vec3{0, 0, 1}) {
+		t.Errorf("color %v normal %v", m.Colors[1], m.Normals[2])
+	}
+	if nil != m.UVs {
+		t.Error("uvs from nowhere")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, src := range []string{
+		"obj\n",
+		"ply\nformat ascii 2.0\nend_header\n",
+		"ply\nformat ascii 1.0\nproperty float x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0\n",
+		strings.Replace(quad, "4 0 1 2 3", "3 0 1 7", 1),
+	} {
+		if _, err := Decode(strings.NewReader(src)); nil == err {
+			t.Errorf("accepted %q", src)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	sphere := mesh.Sphere(1, 8, 6)
+	sphere.Tangents = nil
+	for i := range sphere.Positions {
+		sphere.Colors = append(sphere.Colors, mgl32.Vec4{float32(i%2) * 1, 0.2, 1, 1})
+	}
+	for _, format := range []Format{ASCII, BinaryLittleEndian, BinaryBigEndian} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, sphere, format); nil != err {
+			t.Fatal(err)
+		}
+		m, err := Decode(&buf)
+		if nil != err {
+			t.Fatal(format, err)
+		}
+		if m.VertexCount() != sphere.VertexCount() || len(m.Indices) != len(sphere.Indices) {
+			t.Fatalf("format %d: %d vertices %d indices", format, m.VertexCount(), len(m.Indices))
+		}
+		for i := range m.Positions {
+			if m.Positions[i] != sphere.Positions[i] || m.Normals[i] != sphere.Normals[i] || m.UVs[i] != sphere.UVs[i] {
+				t.Fatalf("format %d: vertex %d differs", format, i)
+			}
+			// colors go through bytes
+			if d := m.Colors[i].Sub(sphere.Colors[i]); d.Len() > 0.5/255*2 {
+				t.Fatalf("format %d: color %v, want %v", format, m.Colors[i], sphere.Colors[i])
+			}
+		}
+		for i := range m.Indices {
+			if m.Indices[i] != sphere.Indices[i] {
+				t.Fatalf("format %d: index %d differs", format, i)
+			}
+		}
+	}
+}
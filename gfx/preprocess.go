@@ -0,0 +1,193 @@
+package gfx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SourceLine is the place a line of preprocessed GLSL came from.
+type SourceLine struct {
+	File string
+	Line int
+}
+
+func (l SourceLine) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// ShaderSource is preprocessed GLSL ready for CompileShaderSource, along with
+// the map from its lines back to the original files.
+type ShaderSource struct {
+	Name  string
+	Code  string
+	Lines []SourceLine // Lines[i] is the origin of line i+1 of Code
+	Files []string     // every file read, the shader itself first
+}
+
+// Origin maps a 1-based line of Code back to its original file and line.
+func (s *ShaderSource) Origin(line int) (SourceLine, bool) {
+	if line < 1 || line > len(s.Lines) {
+		return SourceLine{}, false
+	}
+	return s.Lines[line-1], true
+}
+
+// Preprocessor resolves #include "file" directives and injects #defines into
+// GLSL before it reaches the driver, which supports neither.
+//
+// Includes are resolved relative to the file containing them; sources that
+// do not come from a file resolve them relative to Dir.
+type Preprocessor struct {
+	Dir     string
+	Defines map[string]string // injected right after #version, "" defines a bare flag
+
+	// ReadFile replaces ioutil.ReadFile, mostly for tests.
+	ReadFile func(file string) ([]byte, error)
+}
+
+// PreprocessFile reads file and preprocesses it.
+func (p *Preprocessor) PreprocessFile(file string) (*ShaderSource, error) {
+	src, err := p.readFile(file)
+	if nil != err {
+		return nil, err
+	}
+	return p.preprocess(file, filepath.Dir(file), string(src))
+}
+
+// Preprocess preprocesses source; name is only used in errors and the line
+// map, e.g. "cube.vert".
+func (p *Preprocessor) Preprocess(name, source string) (*ShaderSource, error) {
+	return p.preprocess(name, p.Dir, source)
+}
+
+func (p *Preprocessor) preprocess(name, dir, source string) (*ShaderSource, error) {
+	out := &ShaderSource{Name: name, Files: []string{name}}
+	b := &sourceBuilder{p: p, out: out, seen: map[string]bool{name: true}}
+	if err := b.expand(name, dir, strings.TrimRight(source, "\x00"), []string{name}); nil != err {
+		return nil, err
+	}
+	b.injectDefines()
+	out.Code = strings.Join(b.lines, "\n") + "\n"
+	return out, nil
+}
+
+func (p *Preprocessor) readFile(file string) ([]byte, error) {
+	if nil != p.ReadFile {
+		return p.ReadFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+type sourceBuilder struct {
+	p     *Preprocessor
+	out   *ShaderSource
+	lines []string
+	seen  map[string]bool
+
+	version int // index into lines of the #version directive, -1 if none
+	found   bool
+}
+
+func (b *sourceBuilder) expand(name, dir, source string, stack []string) error {
+	// a trailing newline does not start another line
+	source = strings.TrimSuffix(strings.Replace(source, "\r\n", "\n", -1), "\n")
+	for i, line := range strings.Split(source, "\n") {
+		here := SourceLine{File: name, Line: i + 1}
+
+		directive, arg := splitDirective(line)
+		switch directive {
+		case "version":
+			if len(stack) > 1 {
+				return fmt.Errorf("%v: #version in included file", here)
+			}
+			if !b.found {
+				b.found = true
+				b.version = len(b.lines)
+			}
+		case "include":
+			file, err := includeName(arg)
+			if nil != err {
+				return fmt.Errorf("%v: %v", here, err)
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			for _, open := range stack {
+				if open == file {
+					return fmt.Errorf("%v: include cycle %s -> %s", here, strings.Join(stack, " -> "), file)
+				}
+			}
+			src, err := b.p.readFile(file)
+			if nil != err {
+				return fmt.Errorf("%v: %v", here, err)
+			}
+			if !b.seen[file] {
+				b.seen[file] = true
+				b.out.Files = append(b.out.Files, file)
+			}
+			if err := b.expand(file, filepath.Dir(file), string(src), append(stack, file)); nil != err {
+				return err
+			}
+			continue
+		}
+
+		b.lines = append(b.lines, line)
+		b.out.Lines = append(b.out.Lines, here)
+	}
+	return nil
+}
+
+// injectDefines inserts the caller's #defines after #version, which has to
+// stay the first directive, or at the top when there is none.
+func (b *sourceBuilder) injectDefines() {
+	if 0 == len(b.p.Defines) {
+		return
+	}
+	names := make([]string, 0, len(b.p.Defines))
+	for name := range b.p.Defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	origins := make([]SourceLine, 0, len(names))
+	for i, name := range names {
+		lines = append(lines, strings.TrimSpace("#define "+name+" "+b.p.Defines[name]))
+		origins = append(origins, SourceLine{File: "<defines>", Line: i + 1})
+	}
+
+	at := 0
+	if b.found {
+		at = b.version + 1
+	}
+	b.lines = append(b.lines[:at], append(lines, b.lines[at:]...)...)
+	b.out.Lines = append(b.out.Lines[:at], append(origins, b.out.Lines[at:]...)...)
+}
+
+// splitDirective returns the name and argument of a preprocessor line such as
+// `  #  include "light.glsl"`, or "" if line is not a directive.
+func splitDirective(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	line = strings.TrimSpace(line[1:])
+	end := strings.IndexAny(line, " \t")
+	if end < 0 {
+		return line, ""
+	}
+	return line[:end], strings.TrimSpace(line[end:])
+}
+
+func includeName(arg string) (string, error) {
+	if comment := strings.Index(arg, "//"); comment >= 0 {
+		arg = strings.TrimSpace(arg[:comment])
+	}
+	if len(arg) < 2 || arg[0] != '"' || arg[len(arg)-1] != '"' {
+		return "", fmt.Errorf("malformed #include %s, want #include \"file\"", arg)
+	}
+	return arg[1 : len(arg)-1], nil
+}
@@ -0,0 +1,121 @@
+package gfx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memFiles serves Preprocessor.ReadFile from a map keyed by slash paths.
+func memFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(file string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(file)]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %v", file, os.ErrNotExist)
+		}
+		return []byte(src), nil
+	}
+}
+
+func TestPreprocessIncludes(t *testing.T) {
+	p := &Preprocessor{ReadFile: memFiles(map[string]string{
+		"shaders/main.frag":       "#version 330 core\n#include \"lib/light.glsl\"\nvoid main() {}\n",
+		"shaders/lib/light.glsl":  "// light\n#include \"common.glsl\"\nfloat light() { return K; }\n",
+		"shaders/lib/common.glsl": "const float K = 1.0;\n",
+	})}
+
+	src, err := p.PreprocessFile("shaders/main.frag")
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := "#version 330 core\n// light\nconst float K = 1.0;\nfloat light() { return K; }\nvoid main() {}\n"
+	if src.Code != want {
+		t.Fatalf("code:\n%s\nwant:\n%s", src.Code, want)
+	}
+
+	origins := []string{
+		"shaders/main.frag:1",
+		"shaders/lib/light.glsl:1",
+		"shaders/lib/common.glsl:1",
+		"shaders/lib/light.glsl:3",
+		"shaders/main.frag:3",
+	}
+	for i, want := range origins {
+		got, ok := src.Origin(i + 1)
+		if !ok || filepath.ToSlash(got.String()) != want {
+			t.Errorf("Origin(%d) = %v, want %s", i+1, got, want)
+		}
+	}
+	if _, ok := src.Origin(len(origins) + 2); ok {
+		t.Error("Origin past the end should fail")
+	}
+	if len(src.Files) != 3 {
+		t.Errorf("Files = %v, want all three files", src.Files)
+	}
+}
+
+func TestPreprocessIncludeCycle(t *testing.T) {
+	p := &Preprocessor{ReadFile: memFiles(map[string]string{
+		"a.glsl": "#include \"b.glsl\"\n",
+		"b.glsl": "#include \"a.glsl\"\n",
+	})}
+	_, err := p.PreprocessFile("a.glsl")
+	if nil == err || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("err = %v, want an include cycle", err)
+	}
+}
+
+func TestPreprocessRepeatedInclude(t *testing.T) {
+	// including the same file twice is not a cycle
+	p := &Preprocessor{Dir: "s", ReadFile: memFiles(map[string]string{
+		"s/x.glsl": "x\n",
+	})}
+	src, err := p.Preprocess("inline", "#include \"x.glsl\"\n#include \"x.glsl\"\n")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if src.Code != "x\nx\n" {
+		t.Errorf("code = %q", src.Code)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	p := &Preprocessor{ReadFile: memFiles(map[string]string{
+		"v.glsl": "#version 330\n",
+	})}
+	for _, source := range []string{
+		"#include <v.glsl>\n",
+		"#include \"missing.glsl\"\n",
+		"#include \"v.glsl\"\n",
+	} {
+		if _, err := p.Preprocess("inline", source); nil == err {
+			t.Errorf("Preprocess(%q) succeeded", source)
+		}
+	}
+}
+
+func TestPreprocessDefines(t *testing.T) {
+	p := &Preprocessor{Defines: map[string]string{"USE_FOG": "", "LIGHTS": "4"}}
+	src, err := p.Preprocess("cube.vert", "// cube\n#version 330\nvoid main() {}\n")
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := "// cube\n#version 330\n#define LIGHTS 4\n#define USE_FOG\nvoid main() {}\n"
+	if src.Code != want {
+		t.Fatalf("code:\n%s\nwant:\n%s", src.Code, want)
+	}
+	if got, _ := src.Origin(5); got.String() != "cube.vert:3" {
+		t.Errorf("Origin(5) = %v, want cube.vert:3", got)
+	}
+
+	// no #version: defines go first
+	src, err = p.Preprocess("x", "void main() {}")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(src.Code, "#define LIGHTS 4\n") {
+		t.Errorf("code = %q", src.Code)
+	}
+}
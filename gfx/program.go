@@ -0,0 +1,221 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Uniform describes an active uniform found when the program was linked.
+type Uniform struct {
+	Name     string
+	Location int32
+	Type     uint32 // gl.FLOAT, gl.FLOAT_VEC3, gl.SAMPLER_2D, ...
+	Size     int32  // array length, 1 for plain uniforms
+}
+
+// Program is a linked shader program with its active uniforms cached by name,
+// so the render loop never has to call gl.GetUniformLocation itself.
+//
+// The Set* methods write to the program currently in use, call Use first.
+type Program struct {
+	ID       uint32
+	uniforms map[string]Uniform
+}
+
+// NewProgramFromID wraps an already linked program and introspects its
+// active uniforms.
+func NewProgramFromID(id uint32) *Program {
+	p := &Program{ID: id}
+	p.introspect()
+	return p
+}
+
+func (p *Program) introspect() {
+	p.uniforms = make(map[string]Uniform)
+
+	var count, maxLength int32
+	gl.GetProgramiv(p.ID, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(p.ID, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	buf := make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(p.ID, uint32(i), int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+		location := gl.GetUniformLocation(p.ID, gl.Str(cString(name)))
+		if location < 0 {
+			// members of uniform blocks have no location
+			continue
+		}
+		name = uniformBaseName(name)
+		p.uniforms[name] = Uniform{Name: name, Location: location, Type: xtype, Size: size}
+	}
+}
+
+// Use makes p the current program.
+func (p *Program) Use() {
+	gl.UseProgram(p.ID)
+}
+
+// Delete releases the GL program.
+func (p *Program) Delete() {
+	gl.DeleteProgram(p.ID)
+	p.ID = 0
+	p.uniforms = nil
+}
+
+// Uniforms returns the active uniforms keyed by name. Arrays are listed under
+// their name without the "[0]" suffix.
+func (p *Program) Uniforms() map[string]Uniform {
+	return p.uniforms
+}
+
+// UniformLocation returns the cached location of name, -1 if it is not an
+// active uniform.
+func (p *Program) UniformLocation(name string) int32 {
+	if u, ok := p.uniforms[name]; ok {
+		return u.Location
+	}
+	return -1
+}
+
+// SetFloat sets a float uniform.
+func (p *Program) SetFloat(name string, v float32) error {
+	u, err := p.lookup(name, gl.FLOAT)
+	if nil != err {
+		return err
+	}
+	gl.Uniform1f(u.Location, v)
+	return nil
+}
+
+// SetInt sets an int or bool uniform.
+func (p *Program) SetInt(name string, v int32) error {
+	u, err := p.lookup(name, gl.INT)
+	if nil != err {
+		return err
+	}
+	gl.Uniform1i(u.Location, v)
+	return nil
+}
+
+// SetVec2 sets a vec2 uniform.
+func (p *Program) SetVec2(name string, v mgl32.Vec2) error {
+	u, err := p.lookup(name, gl.FLOAT_VEC2)
+	if nil != err {
+		return err
+	}
+	gl.Uniform2fv(u.Location, 1, &v[0])
+	return nil
+}
+
+// SetVec3 sets a vec3 uniform.
+func (p *Program) SetVec3(name string, v mgl32.Vec3) error {
+	u, err := p.lookup(name, gl.FLOAT_VEC3)
+	if nil != err {
+		return err
+	}
+	gl.Uniform3fv(u.Location, 1, &v[0])
+	return nil
+}
+
+// SetVec4 sets a vec4 uniform.
+func (p *Program) SetVec4(name string, v mgl32.Vec4) error {
+	u, err := p.lookup(name, gl.FLOAT_VEC4)
+	if nil != err {
+		return err
+	}
+	gl.Uniform4fv(u.Location, 1, &v[0])
+	return nil
+}
+
+// SetMat4 sets a mat4 uniform.
+func (p *Program) SetMat4(name string, m mgl32.Mat4) error {
+	u, err := p.lookup(name, gl.FLOAT_MAT4)
+	if nil != err {
+		return err
+	}
+	gl.UniformMatrix4fv(u.Location, 1, false, &m[0])
+	return nil
+}
+
+// SetSampler points a sampler uniform at texture unit, 0 for gl.TEXTURE0.
+func (p *Program) SetSampler(name string, unit int32) error {
+	u, err := p.lookup(name, samplerType)
+	if nil != err {
+		return err
+	}
+	gl.Uniform1i(u.Location, unit)
+	return nil
+}
+
+// samplerType stands for any of the sampler uniform types in lookup.
+const samplerType = 0
+
+func (p *Program) lookup(name string, want uint32) (Uniform, error) {
+	u, ok := p.uniforms[name]
+	if !ok {
+		return u, fmt.Errorf("program %d: no active uniform %q", p.ID, name)
+	}
+	if !uniformTypeMatches(u.Type, want) {
+		return u, fmt.Errorf("program %d: uniform %q is %s, not %s", p.ID, name, uniformTypeName(u.Type), uniformTypeName(want))
+	}
+	return u, nil
+}
+
+// uniformBaseName strips the "[0]" drivers append to array uniform names.
+func uniformBaseName(name string) string {
+	return strings.TrimSuffix(name, "[0]")
+}
+
+func uniformTypeMatches(have, want uint32) bool {
+	switch want {
+	case samplerType:
+		return isSampler(have)
+	case gl.INT:
+		return have == gl.INT || have == gl.BOOL
+	}
+	return have == want
+}
+
+func isSampler(xtype uint32) bool {
+	switch xtype {
+	case gl.SAMPLER_1D, gl.SAMPLER_2D, gl.SAMPLER_3D, gl.SAMPLER_CUBE,
+		gl.SAMPLER_2D_SHADOW, gl.SAMPLER_2D_ARRAY, gl.SAMPLER_2D_MULTISAMPLE,
+		gl.SAMPLER_CUBE_SHADOW, gl.SAMPLER_BUFFER,
+		gl.INT_SAMPLER_2D, gl.UNSIGNED_INT_SAMPLER_2D:
+		return true
+	}
+	return false
+}
+
+func uniformTypeName(xtype uint32) string {
+	switch xtype {
+	case samplerType:
+		return "a sampler"
+	case gl.FLOAT:
+		return "float"
+	case gl.FLOAT_VEC2:
+		return "vec2"
+	case gl.FLOAT_VEC3:
+		return "vec3"
+	case gl.FLOAT_VEC4:
+		return "vec4"
+	case gl.FLOAT_MAT3:
+		return "mat3"
+	case gl.FLOAT_MAT4:
+		return "mat4"
+	case gl.INT:
+		return "int"
+	case gl.BOOL:
+		return "bool"
+	case gl.SAMPLER_2D:
+		return "sampler2D"
+	case gl.SAMPLER_CUBE:
+		return "samplerCube"
+	}
+	return fmt.Sprintf("type 0x%x", xtype)
+}
@@ -0,0 +1,61 @@
+package gfx
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestUniformBaseName(t *testing.T) {
+	cases := map[string]string{
+		"rate":         "rate",
+		"lights[0]":    "lights",
+		"lights[1]":    "lights[1]",
+		"mats[0].diff": "mats[0].diff",
+	}
+	for in, want := range cases {
+		if got := uniformBaseName(in); got != want {
+			t.Errorf("uniformBaseName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestUniformTypeMatches(t *testing.T) {
+	cases := []struct {
+		have, want uint32
+		ok         bool
+	}{
+		{gl.FLOAT, gl.FLOAT, true},
+		{gl.FLOAT_VEC3, gl.FLOAT, false},
+		{gl.FLOAT_MAT4, gl.FLOAT_MAT4, true},
+		{gl.BOOL, gl.INT, true},
+		{gl.SAMPLER_2D, samplerType, true},
+		{gl.SAMPLER_CUBE, samplerType, true},
+		{gl.INT, samplerType, false},
+		{gl.SAMPLER_2D, gl.INT, false},
+	}
+	for _, c := range cases {
+		if got := uniformTypeMatches(c.have, c.want); got != c.ok {
+			t.Errorf("uniformTypeMatches(%s, %s) = %v, want %v",
+				uniformTypeName(c.have), uniformTypeName(c.want), got, c.ok)
+		}
+	}
+}
+
+func TestLookupUnknownUniform(t *testing.T) {
+	p := &Program{ID: 7, uniforms: map[string]Uniform{
+		"rate": {Name: "rate", Location: 3, Type: gl.FLOAT, Size: 1},
+	}}
+	if _, err := p.lookup("tran", gl.FLOAT_MAT4); nil == err {
+		t.Error("expected an error for an unknown uniform")
+	}
+	if _, err := p.lookup("rate", gl.FLOAT_VEC3); nil == err {
+		t.Error("expected an error for a type mismatch")
+	}
+	if u, err := p.lookup("rate", gl.FLOAT); nil != err || u.Location != 3 {
+		t.Errorf("lookup(rate) = %v, %v", u, err)
+	}
+	if got := p.UniformLocation("missing"); got != -1 {
+		t.Errorf("UniformLocation(missing) = %d, want -1", got)
+	}
+}
@@ -0,0 +1,158 @@
+package gfx
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ShaderWatcher owns a program built from shader files and rebuilds it when
+// any of the files change on disk.
+//
+// Change detection runs on its own goroutine, but compiling and linking only
+// happen inside Poll, which must be called from the render thread (once per
+// frame is fine). When a rebuild fails the previous program stays in use and
+// the compile log is printed.
+type ShaderWatcher struct {
+	VertexFile   string
+	FragmentFile string
+
+	program *Program
+	pp      *Preprocessor
+	files   *fileWatcher
+	done    chan struct{}
+	once    sync.Once
+}
+
+// WatchProgram builds the program from vertexFile and fragmentFile and starts
+// watching both files and everything they #include. The first build has to
+// succeed.
+func WatchProgram(vertexFile, fragmentFile string) (*ShaderWatcher, error) {
+	return new(Preprocessor).WatchProgram(vertexFile, fragmentFile)
+}
+
+// WatchProgram is the package level WatchProgram with p's defines applied to
+// every build.
+func (p *Preprocessor) WatchProgram(vertexFile, fragmentFile string) (*ShaderWatcher, error) {
+	program, files, err := p.buildFiles(vertexFile, fragmentFile)
+	if nil != err {
+		return nil, err
+	}
+
+	w := &ShaderWatcher{
+		VertexFile:   vertexFile,
+		FragmentFile: fragmentFile,
+		program:      program,
+		pp:           p,
+		files:        newFileWatcher(files...),
+		done:         make(chan struct{}),
+	}
+	go w.files.run(250*time.Millisecond, w.done)
+	return w, nil
+}
+
+// Program returns the program currently in use.
+func (w *ShaderWatcher) Program() *Program {
+	return w.program
+}
+
+// Poll rebuilds the program if a watched file changed since the last call.
+// It returns true when a new program was swapped in; callers should then
+// re-upload uniforms that are only set once, such as sampler units.
+func (w *ShaderWatcher) Poll() bool {
+	select {
+	case <-w.files.changed:
+	default:
+		return false
+	}
+
+	program, files, err := w.pp.buildFiles(w.VertexFile, w.FragmentFile)
+	if nil != err {
+		log.Printf("shader reload failed, keeping program %d: %v", w.program.ID, err)
+		return false
+	}
+	// includes may have been added or removed
+	w.files.setFiles(files)
+
+	old := w.program
+	w.program = program
+	old.Delete()
+	log.Printf("reloaded %s, %s as program %d", w.VertexFile, w.FragmentFile, program.ID)
+	return true
+}
+
+// Close stops watching and deletes the current program.
+func (w *ShaderWatcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+		w.program.Delete()
+	})
+}
+
+// fileWatcher polls modification times and signals changed, at most one
+// pending notification at a time.
+type fileWatcher struct {
+	mu      sync.Mutex
+	files   []string
+	stamps  map[string]time.Time
+	changed chan struct{}
+}
+
+func newFileWatcher(files ...string) *fileWatcher {
+	fw := &fileWatcher{
+		files:   files,
+		stamps:  make(map[string]time.Time),
+		changed: make(chan struct{}, 1),
+	}
+	fw.scan()
+	return fw
+}
+
+func (fw *fileWatcher) run(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if fw.scan() {
+				select {
+				case fw.changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// scan records the current modification times and reports whether any of
+// them differ from the previous scan. Files that are missing, e.g. while an
+// editor is replacing them, are skipped until they reappear.
+func (fw *fileWatcher) scan() bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	changed := false
+	for _, file := range fw.files {
+		info, err := os.Stat(file)
+		if nil != err {
+			continue
+		}
+		stamp := info.ModTime()
+		if last, ok := fw.stamps[file]; ok && !last.Equal(stamp) {
+			changed = true
+		}
+		fw.stamps[file] = stamp
+	}
+	return changed
+}
+
+// setFiles replaces the watched files, keeping the stamps of those that stay.
+func (fw *fileWatcher) setFiles(files []string) {
+	fw.mu.Lock()
+	fw.files = files
+	fw.mu.Unlock()
+	fw.scan()
+}
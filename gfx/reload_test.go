@@ -0,0 +1,69 @@
+package gfx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherScan(t *testing.T) {
+	dir := t.TempDir()
+	vert := filepath.Join(dir, "vertices.vert")
+	frag := filepath.Join(dir, "fragment.frag")
+	for _, f := range []string{vert, frag} {
+		if err := ioutil.WriteFile(f, []byte("#version 330 core\n"), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	fw := newFileWatcher(vert, frag)
+	if fw.scan() {
+		t.Fatal("scan reported a change on untouched files")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(frag, later, later); nil != err {
+		t.Fatal(err)
+	}
+	if !fw.scan() {
+		t.Fatal("scan missed a modified file")
+	}
+	if fw.scan() {
+		t.Fatal("scan reported the same change twice")
+	}
+
+	// a file being replaced is skipped rather than treated as a change
+	if err := os.Remove(vert); nil != err {
+		t.Fatal(err)
+	}
+	if fw.scan() {
+		t.Fatal("scan reported a change for a missing file")
+	}
+}
+
+func TestFileWatcherRunCoalesces(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.frag")
+	if err := ioutil.WriteFile(file, nil, 0644); nil != err {
+		t.Fatal(err)
+	}
+	fw := newFileWatcher(file)
+	done := make(chan struct{})
+	defer close(done)
+	go fw.run(time.Millisecond, done)
+
+	for i := 1; i <= 3; i++ {
+		stamp := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(file, stamp, stamp); nil != err {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case <-fw.changed:
+	case <-time.After(time.Second):
+		t.Fatal("no change notification")
+	}
+}
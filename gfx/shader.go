@@ -0,0 +1,175 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// CompileShader compiles source as a shader of shaderType (gl.VERTEX_SHADER,
+// gl.FRAGMENT_SHADER, ...). The trailing "\x00" the lessons append by hand is
+// optional. Compile failures are returned as a *ShaderError.
+func CompileShader(source string, shaderType uint32) (uint32, error) {
+	name := shaderTypeName(shaderType)
+	return CompileShaderSource(plainSource(name, source), shaderType)
+}
+
+// CompileShaderSource compiles preprocessed GLSL. Diagnostics in the returned
+// *ShaderError point at the original files.
+func CompileShaderSource(src *ShaderSource, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+	csources, free := gl.Strs(cString(src.Code))
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if gl.FALSE == status {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+		return 0, newShaderError(shaderTypeName(shaderType), src, log)
+	}
+
+	return shader, nil
+}
+
+// ReadShaderFromFile reads file, resolves its #includes and compiles it as a
+// shader of shaderType.
+func ReadShaderFromFile(file string, shaderType uint32) (uint32, error) {
+	src, err := new(Preprocessor).PreprocessFile(file)
+	if nil != err {
+		return 0, err
+	}
+	return CompileShaderSource(src, shaderType)
+}
+
+// NewProgram compiles both shader sources and links them into a program.
+func NewProgram(vertexShaderSource, fragmentShaderSource string) (*Program, error) {
+	vertexShader, err := CompileShader(vertexShaderSource, gl.VERTEX_SHADER)
+	if nil != err {
+		return nil, err
+	}
+	fragmentShader, err := CompileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
+	if nil != err {
+		gl.DeleteShader(vertexShader)
+		return nil, err
+	}
+	return LinkProgram(vertexShader, fragmentShader)
+}
+
+// NewProgramFromFiles is NewProgram for shaders stored on disk, e.g.
+// "./shaders/vertices.vert" and "./shaders/fragment.frag". #include
+// directives are resolved relative to each file.
+func NewProgramFromFiles(vertexFile, fragmentFile string) (*Program, error) {
+	return new(Preprocessor).NewProgramFromFiles(vertexFile, fragmentFile)
+}
+
+// NewProgram preprocesses both shader sources and links them into a program.
+func (p *Preprocessor) NewProgram(vertexShaderSource, fragmentShaderSource string) (*Program, error) {
+	vertex, err := p.Preprocess("vertex", vertexShaderSource)
+	if nil != err {
+		return nil, err
+	}
+	fragment, err := p.Preprocess("fragment", fragmentShaderSource)
+	if nil != err {
+		return nil, err
+	}
+	return linkSources(vertex, fragment)
+}
+
+// NewProgramFromFiles preprocesses both shader files and links them into a
+// program.
+func (p *Preprocessor) NewProgramFromFiles(vertexFile, fragmentFile string) (*Program, error) {
+	program, _, err := p.buildFiles(vertexFile, fragmentFile)
+	return program, err
+}
+
+// buildFiles is NewProgramFromFiles that also reports every file read,
+// includes too, so a ShaderWatcher knows what to watch.
+func (p *Preprocessor) buildFiles(vertexFile, fragmentFile string) (*Program, []string, error) {
+	vertex, err := p.PreprocessFile(vertexFile)
+	if nil != err {
+		return nil, nil, err
+	}
+	fragment, err := p.PreprocessFile(fragmentFile)
+	if nil != err {
+		return nil, nil, err
+	}
+	files := append(append([]string{}, vertex.Files...), fragment.Files...)
+	program, err := linkSources(vertex, fragment)
+	return program, files, err
+}
+
+func linkSources(vertex, fragment *ShaderSource) (*Program, error) {
+	vertexShader, err := CompileShaderSource(vertex, gl.VERTEX_SHADER)
+	if nil != err {
+		return nil, err
+	}
+	fragmentShader, err := CompileShaderSource(fragment, gl.FRAGMENT_SHADER)
+	if nil != err {
+		gl.DeleteShader(vertexShader)
+		return nil, err
+	}
+	return LinkProgram(vertexShader, fragmentShader)
+}
+
+// LinkProgram links already compiled shaders into a new program. The shaders
+// are deleted whether or not linking succeeds.
+func LinkProgram(shaders ...uint32) (*Program, error) {
+	program := gl.CreateProgram()
+	for _, shader := range shaders {
+		gl.AttachShader(program, shader)
+	}
+	gl.LinkProgram(program)
+	for _, shader := range shaders {
+		gl.DetachShader(program, shader)
+		gl.DeleteShader(shader)
+	}
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if gl.FALSE == status {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+		return nil, newShaderError("link", nil, log)
+	}
+
+	return NewProgramFromID(program), nil
+}
+
+// cString makes sure s carries exactly the NUL terminator gl.Strs expects.
+func cString(s string) string {
+	return strings.TrimRight(s, "\x00") + "\x00"
+}
+
+func shaderTypeName(shaderType uint32) string {
+	switch shaderType {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	}
+	return fmt.Sprintf("0x%x", shaderType)
+}
+
+// plainSource wraps source that did not go through the preprocessor, each
+// line maps to itself.
+func plainSource(name, source string) *ShaderSource {
+	code := strings.TrimRight(source, "\x00")
+	n := strings.Count(strings.TrimSuffix(code, "\n"), "\n") + 1
+	src := &ShaderSource{Name: name, Code: code, Lines: make([]SourceLine, n)}
+	for i := range src.Lines {
+		src.Lines[i] = SourceLine{File: name, Line: i + 1}
+	}
+	return src
+}
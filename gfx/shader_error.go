@@ -0,0 +1,160 @@
+package gfx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShaderDiagnostic is one message from a driver's compile or link log.
+type ShaderDiagnostic struct {
+	File     string // original file, after following #includes
+	Line     int    // 1-based line in File, 0 if the driver gave none
+	Column   int    // 1-based, 0 if the driver gave none
+	Severity string // "error" or "warning"
+	Message  string
+
+	codeLine int // line in the preprocessed code, used for snippets
+}
+
+func (d ShaderDiagnostic) String() string {
+	var pos string
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		pos = fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+	case d.Line > 0:
+		pos = fmt.Sprintf("%s:%d: ", d.File, d.Line)
+	case "" != d.File:
+		pos = d.File + ": "
+	}
+	return pos + d.Severity + ": " + d.Message
+}
+
+// ShaderError is returned when a shader fails to compile or a program fails
+// to link. Its message lists every diagnostic with a snippet of the offending
+// source; the raw driver log is kept in Log.
+type ShaderError struct {
+	Stage       string // "vertex", "fragment", ... or "link"
+	Name        string // file or name the source was preprocessed as
+	Log         string
+	Diagnostics []ShaderDiagnostic
+
+	source *ShaderSource
+}
+
+func newShaderError(stage string, src *ShaderSource, log string) *ShaderError {
+	e := &ShaderError{Stage: stage, Log: strings.TrimRight(log, "\x00\n "), source: src}
+	if nil != src {
+		e.Name = src.Name
+	}
+	e.Diagnostics = parseShaderLog(e.Log)
+	for i := range e.Diagnostics {
+		d := &e.Diagnostics[i]
+		d.codeLine = d.Line
+		if nil == src {
+			continue
+		}
+		d.File = src.Name
+		if origin, ok := src.Origin(d.Line); ok {
+			d.File, d.Line = origin.File, origin.Line
+		}
+	}
+	return e
+}
+
+func (e *ShaderError) Error() string {
+	var b strings.Builder
+	if "link" == e.Stage {
+		b.WriteString("failed to link program")
+	} else {
+		fmt.Fprintf(&b, "failed to compile %s shader", e.Stage)
+	}
+	if "" != e.Name && "link" != e.Stage {
+		fmt.Fprintf(&b, " %s", e.Name)
+	}
+
+	if 0 == len(e.Diagnostics) {
+		b.WriteString(": ")
+		b.WriteString(e.Log)
+		return b.String()
+	}
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n")
+		b.WriteString(d.String())
+		e.writeSnippet(&b, d)
+	}
+	return b.String()
+}
+
+// writeSnippet prints the lines around d with the offending one marked.
+func (e *ShaderError) writeSnippet(b *strings.Builder, d ShaderDiagnostic) {
+	if nil == e.source || d.codeLine < 1 {
+		return
+	}
+	lines := strings.Split(e.source.Code, "\n")
+	if d.codeLine > len(lines) {
+		return
+	}
+	for n := d.codeLine - 1; n <= d.codeLine+1; n++ {
+		if n < 1 || n > len(lines) {
+			continue
+		}
+		origin, _ := e.source.Origin(n)
+		if origin.File != d.File {
+			continue
+		}
+		marker := " "
+		if n == d.codeLine {
+			marker = ">"
+		}
+		fmt.Fprintf(b, "\n%s %5d | %s", marker, origin.Line, lines[n-1])
+		if n == d.codeLine && d.Column > 0 {
+			fmt.Fprintf(b, "\n        | %s^", strings.Repeat(" ", d.Column-1))
+		}
+	}
+}
+
+var (
+	// Mesa: 0:12(5): error: `foo' undeclared
+	mesaLog = regexp.MustCompile(`^\d+:(\d+)\((\d+)\): (error|warning)[^:]*: (.*)$`)
+	// NVIDIA: 0(12) : error C1008: undefined variable "foo"
+	nvidiaLog = regexp.MustCompile(`^\d+\((\d+)\) ?: (error|warning)(?: \w+)?: (.*)$`)
+	// AMD, Intel on Windows, Apple: ERROR: 0:12: 'foo' : undeclared identifier
+	amdLog = regexp.MustCompile(`^(ERROR|WARNING): \d+:(\d+): (.*)$`)
+	// Anything else that calls itself an error, link logs mostly.
+	otherLog = regexp.MustCompile(`(?i)^(?:\w+ )?(error|warning)(?: \w+)?: (.*)$`)
+	// AMD's closing summary line.
+	summaryLog = regexp.MustCompile(`(?i)^ERROR: \d+ compilation errors?`)
+)
+
+// parseShaderLog splits a driver log into diagnostics. Lines it does not
+// recognize are dropped; they are still part of ShaderError.Log.
+func parseShaderLog(log string) []ShaderDiagnostic {
+	var diagnostics []ShaderDiagnostic
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(strings.TrimRight(line, "\x00"))
+		if "" == line || summaryLog.MatchString(line) {
+			continue
+		}
+		if m := mesaLog.FindStringSubmatch(line); nil != m {
+			diagnostics = append(diagnostics, ShaderDiagnostic{
+				Line: atoi(m[1]), Column: atoi(m[2]), Severity: m[3], Message: m[4]})
+		} else if m := nvidiaLog.FindStringSubmatch(line); nil != m {
+			diagnostics = append(diagnostics, ShaderDiagnostic{
+				Line: atoi(m[1]), Severity: m[2], Message: m[3]})
+		} else if m := amdLog.FindStringSubmatch(line); nil != m {
+			diagnostics = append(diagnostics, ShaderDiagnostic{
+				Line: atoi(m[2]), Severity: strings.ToLower(m[1]), Message: m[3]})
+		} else if m := otherLog.FindStringSubmatch(line); nil != m {
+			diagnostics = append(diagnostics, ShaderDiagnostic{
+				Severity: strings.ToLower(m[1]), Message: m[2]})
+		}
+	}
+	return diagnostics
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,94 @@
+package gfx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShaderLog(t *testing.T) {
+	cases := []struct {
+		name string
+		log  string
+		want []ShaderDiagnostic
+	}{
+		{"mesa", "0:12(5): error: `foo' undeclared\n0:3(1): warning: extension `GL_X' unsupported\n",
+			[]ShaderDiagnostic{
+				{Line: 12, Column: 5, Severity: "error", Message: "`foo' undeclared"},
+				{Line: 3, Column: 1, Severity: "warning", Message: "extension `GL_X' unsupported"},
+			}},
+		{"nvidia", "Fragment info\n-------------\n0(7) : error C1008: undefined variable \"foo\"\n0(9) : warning C7022: unrecognized profile\n",
+			[]ShaderDiagnostic{
+				{Line: 7, Severity: "error", Message: "undefined variable \"foo\""},
+				{Line: 9, Severity: "warning", Message: "unrecognized profile"},
+			}},
+		{"amd", "ERROR: 0:4: 'foo' : undeclared identifier \nERROR: 1 compilation errors.  No code generated.\n\x00",
+			[]ShaderDiagnostic{
+				{Line: 4, Severity: "error", Message: "'foo' : undeclared identifier"},
+			}},
+		{"link", "error: vertex shader output `tmpColor' not read by fragment shader\n",
+			[]ShaderDiagnostic{
+				{Severity: "error", Message: "vertex shader output `tmpColor' not read by fragment shader"},
+			}},
+		{"noise", "some driver chatter\n", nil},
+	}
+	for _, c := range cases {
+		if got := parseShaderLog(c.log); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: parseShaderLog = %#v, want %#v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestShaderErrorMapsIncludes(t *testing.T) {
+	p := &Preprocessor{ReadFile: memFiles(map[string]string{
+		"s/main.frag":  "#version 330 core\n#include \"light.glsl\"\nvoid main() {}\n",
+		"s/light.glsl": "float a;\nfloat light() { return b; }\nfloat c;\n",
+	})}
+	src, err := p.PreprocessFile("s/main.frag")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	e := newShaderError("fragment", src, "0:3(24): error: `b' undeclared\n\x00")
+	if len(e.Diagnostics) != 1 {
+		t.Fatalf("Diagnostics = %v", e.Diagnostics)
+	}
+	d := e.Diagnostics[0]
+	if d.File != "s/light.glsl" || d.Line != 2 || d.Column != 24 {
+		t.Errorf("diagnostic at %s:%d:%d, want s/light.glsl:2:24", d.File, d.Line, d.Column)
+	}
+
+	msg := e.Error()
+	for _, want := range []string{
+		"failed to compile fragment shader s/main.frag",
+		"s/light.glsl:2:24: error: `b' undeclared",
+		">     2 | float light() { return b; }",
+		"        |                        ^",
+		"      1 | float a;",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Error() is missing %q:\n%s", want, msg)
+		}
+	}
+	// the snippet stays inside the file the error is in
+	if strings.Contains(msg, "#version") {
+		t.Errorf("snippet leaked lines from another file:\n%s", msg)
+	}
+}
+
+func TestShaderErrorUnparsedLog(t *testing.T) {
+	e := newShaderError("link", nil, "something odd happened")
+	if got := e.Error(); got != "failed to link program: something odd happened" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestPlainSource(t *testing.T) {
+	src := plainSource("vertex", "a\nb\nc\n\x00")
+	if len(src.Lines) != 3 {
+		t.Fatalf("Lines = %v", src.Lines)
+	}
+	if got, _ := src.Origin(2); got.String() != "vertex:2" {
+		t.Errorf("Origin(2) = %v", got)
+	}
+}
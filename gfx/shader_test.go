@@ -0,0 +1,17 @@
+package gfx
+
+import "testing"
+
+func TestCString(t *testing.T) {
+	cases := map[string]string{
+		"":                   "\x00",
+		"void main() {}":     "void main() {}\x00",
+		"void main() {}\x00": "void main() {}\x00",
+		"x\x00\x00":          "x\x00",
+	}
+	for in, want := range cases {
+		if got := cString(in); got != want {
+			t.Errorf("cString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var skyboxVertexShader = `
+#version 330 core
+
+uniform mat4 projection;
+uniform mat4 view;
+
+in vec3 position;
+
+out vec3 direction;
+
+void main() {
+    direction = position;
+    // z = w puts every fragment on the far plane
+    gl_Position = (projection * view * vec4(position, 1.0)).xyww;
+}
+`
+
+var skyboxFragmentShader = `
+#version 330 core
+
+uniform samplerCube sky;
+
+in vec3 direction;
+
+out vec4 outputColor;
+
+void main() {
+    outputColor = texture(sky, direction);
+}
+`
+
+// Skybox draws a cubemap around the camera, behind everything else.
+type Skybox struct {
+	Cubemap uint32
+
+	program *Program
+	vao     uint32
+	vbo     uint32
+}
+
+// NewSkybox builds the skybox program and geometry for cubemap.
+func NewSkybox(cubemap uint32) (*Skybox, error) {
+	program, err := NewProgram(skyboxVertexShader, skyboxFragmentShader)
+	if nil != err {
+		return nil, err
+	}
+
+	s := &Skybox{Cubemap: cubemap, program: program}
+	s.vbo = MakeVbo(skyboxVertices)
+	s.vao = MakeVao(s.vbo)
+	if err := skyboxLayout.Bind(program); nil != err {
+		return nil, err
+	}
+	gl.BindVertexArray(0)
+
+	// no seams between faces when sampling near the edges
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	return s, nil
+}
+
+// Draw renders the sky with the camera's view matrix stripped of its
+// translation, so the sky never gets closer. Call it after the scene: only
+// pixels nothing else was drawn on pass the depth test. The depth function,
+// depth mask, program, vertex array and TEXTURE0 binding are changed.
+func (s *Skybox) Draw(view, projection mgl32.Mat4) {
+	var depthFunc int32
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+
+	s.program.Use()
+	s.program.SetMat4("view", RotationOnly(view))
+	s.program.SetMat4("projection", projection)
+	s.program.SetSampler("sky", 0)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.Cubemap)
+	gl.BindVertexArray(s.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(skyboxVertices)/3))
+	gl.BindVertexArray(0)
+
+	gl.DepthMask(true)
+	gl.DepthFunc(uint32(depthFunc))
+}
+
+// Delete releases the program and buffers; the cubemap is left alone.
+func (s *Skybox) Delete() {
+	s.program.Delete()
+	gl.DeleteVertexArrays(1, &s.vao)
+	gl.DeleteBuffers(1, &s.vbo)
+}
+
+// RotationOnly drops the translation from a view matrix.
+func RotationOnly(view mgl32.Mat4) mgl32.Mat4 {
+	return view.Mat3().Mat4()
+}
+
+var skyboxLayout = MustVertexLayout(VertexAttrib{Name: "position", Size: 3})
+
+// skyboxVertices is a unit cube seen from the inside.
+var skyboxVertices = []float32{
+	-1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1, -1,
+	-1, -1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1,
+	1, -1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1,
+	-1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1, 1,
+	-1, 1, -1, 1, 1, -1, 1, 1, 1, 1, 1, 1, -1, 1, 1, -1, 1, -1,
+	-1, -1, -1, -1, -1, 1, 1, -1, -1, 1, -1, -1, -1, -1, 1, 1, -1, 1,
+}
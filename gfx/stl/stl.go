@@ -0,0 +1,271 @@
+// Package stl reads and writes STL models, ASCII and binary, as indexed
+// meshes ready for gfx.MakeVbo and gfx.MakeEbo.
+//
+// STL stores every triangle on its own with a facet normal. Decoding
+// shares the corners that have the same position and facet normal, so flat
+// faces stay flat; ComputeNormals on the mesh smooths them where wanted.
+// Decode and Encode only deal in io streams and meshes, uploading is left
+// to the caller.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Format selects the STL encoding to write.
+type Format int
+
+// The STL encodings.
+const (
+	Binary Format = iota
+	ASCII
+)
+
+const (
+	binaryHeaderSize   = 80
+	binaryTriangleSize = 50
+)
+
+// Decode reads an ASCII or binary STL model.
+func Decode(r io.Reader) (*mesh.Mesh, error) {
+	data, err := ioutil.ReadAll(r)
+	if nil != err {
+		return nil, fmt.Errorf("stl: %v", err)
+	}
+	var facets []facet
+	if isBinary(data) {
+		facets, err = decodeBinary(data)
+	} else {
+		facets, err = decodeASCII(data)
+	}
+	if nil != err {
+		return nil, err
+	}
+	return build(facets), nil
+}
+
+// DecodeFile reads an ASCII or binary STL file.
+func DecodeFile(file string) (*mesh.Mesh, error) {
+	f, err := os.Open(file)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// isBinary tells the encodings apart. Many binary files start with
+// "solid" too, so the size has the last word, and text never holds NULs.
+func isBinary(data []byte) bool {
+	if len(data) >= binaryHeaderSize+4 {
+		count := binary.LittleEndian.Uint32(data[binaryHeaderSize:])
+		if uint64(len(data)) == binaryHeaderSize+4+uint64(count)*binaryTriangleSize {
+			return true
+		}
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return true
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+type facet struct {
+	normal  mgl32.Vec3
+	corners [3]mgl32.Vec3
+}
+
+func decodeBinary(data []byte) ([]facet, error) {
+	if len(data) < binaryHeaderSize+4 {
+		return nil, errors.New("stl: truncated header")
+	}
+	count := binary.LittleEndian.Uint32(data[binaryHeaderSize:])
+	body := data[binaryHeaderSize+4:]
+	if uint64(len(body)) < uint64(count)*binaryTriangleSize {
+		return nil, fmt.Errorf("stl: %d triangles announced, room for %d", count, len(body)/binaryTriangleSize)
+	}
+	facets := make([]facet, count)
+	for i := range facets {
+		t := body[i*binaryTriangleSize:]
+		var v [12]float32
+		for k := range v {
+			v[k] = math.Float32frombits(binary.LittleEndian.Uint32(t[4*k:]))
+		}
+		facets[i] = facet{
+			normal:  mgl32.Vec3{v[0], v[1], v[2]},
+			corners: [3]mgl32.Vec3{{v[3], v[4], v[5]}, {v[6], v[7], v[8]}, {v[9], v[10], v[11]}},
+		}
+		// the trailing attribute byte count is ignored
+	}
+	return facets, nil
+}
+
+func decodeASCII(data []byte) ([]facet, error) {
+	var facets []facet
+	var normal mgl32.Vec3
+	var loop []mgl32.Vec3
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if 0 == len(fields) {
+			continue
+		}
+		vector := func(args []string) (mgl32.Vec3, error) {
+			var v mgl32.Vec3
+			if 3 != len(args) {
+				return v, fmt.Errorf("stl:%d: want 3 numbers, got %d", line, len(args))
+			}
+			for i, a := range args {
+				f, err := strconv.ParseFloat(a, 32)
+				if nil != err {
+					return v, fmt.Errorf("stl:%d: bad number %q", line, a)
+				}
+				v[i] = float32(f)
+			}
+			return v, nil
+		}
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case "facet":
+			if len(fields) < 2 || "normal" != strings.ToLower(fields[1]) {
+				return nil, fmt.Errorf("stl:%d: facet without normal", line)
+			}
+			normal, err = vector(fields[2:])
+			loop = loop[:0]
+		case "vertex":
+			var v mgl32.Vec3
+			v, err = vector(fields[1:])
+			loop = append(loop, v)
+		case "endloop":
+			if len(loop) < 3 {
+				return nil, fmt.Errorf("stl:%d: loop of %d vertices", line, len(loop))
+			}
+			// loops should be triangles, take anything else as a fan
+			for k := 1; k+1 < len(loop); k++ {
+				facets = append(facets, facet{normal, [3]mgl32.Vec3{loop[0], loop[k], loop[k+1]}})
+			}
+			loop = loop[:0]
+		case "solid", "outer", "endfacet", "endsolid":
+		default:
+			return nil, fmt.Errorf("stl:%d: unknown keyword %q", line, fields[0])
+		}
+		if nil != err {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, fmt.Errorf("stl: %v", err)
+	}
+	return facets, nil
+}
+
+// build shares the corners with the same position and normal. Normals the
+// file left zero, or that disagree with the winding, are recomputed.
+func build(facets []facet) *mesh.Mesh {
+	m := &mesh.Mesh{}
+	type key struct{ position, normal mgl32.Vec3 }
+	vertices := map[key]uint32{}
+	for _, f := range facets {
+		a, b, c := f.corners[0], f.corners[1], f.corners[2]
+		geometric := b.Sub(a).Cross(c.Sub(a))
+		normal := f.normal
+		if normal.Len() < 1e-6 || normal.Dot(geometric) < 0 {
+			normal = geometric
+		}
+		if l := normal.Len(); l > 0 {
+			normal = normal.Mul(1 / l)
+		}
+		for _, p := range f.corners {
+			k := key{p, normal}
+			index, ok := vertices[k]
+			if !ok {
+				index = uint32(len(m.Positions))
+				m.Positions = append(m.Positions, p)
+				m.Normals = append(m.Normals, normal)
+				vertices[k] = index
+			}
+			m.Indices = append(m.Indices, index)
+		}
+	}
+	return m
+}
+
+// Encode writes the triangles of m to w, each with its geometric normal.
+// ASCII files are named name.
+func Encode(w io.Writer, m *mesh.Mesh, format Format, name string) error {
+	if err := m.Validate(); nil != err {
+		return fmt.Errorf("stl: %v", err)
+	}
+	bw := bufio.NewWriter(w)
+	triangles := m.TriangleCount()
+	if Binary == format {
+		var header [binaryHeaderSize]byte
+		// "solid" here would make readers take the file for ASCII
+		copy(header[:], "binary STL "+name)
+		bw.Write(header[:])
+		binary.Write(bw, binary.LittleEndian, uint32(triangles))
+	} else {
+		fmt.Fprintf(bw, "solid %s\n", name)
+	}
+	for t := 0; t < triangles; t++ {
+		a := m.Positions[m.Indices[3*t]]
+		b := m.Positions[m.Indices[3*t+1]]
+		c := m.Positions[m.Indices[3*t+2]]
+		n := b.Sub(a).Cross(c.Sub(a))
+		if l := n.Len(); l > 0 {
+			n = n.Mul(1 / l)
+		}
+		if Binary == format {
+			for _, v := range []mgl32.Vec3{n, a, b, c} {
+				binary.Write(bw, binary.LittleEndian, v)
+			}
+			bw.Write([]byte{0, 0})
+			continue
+		}
+		fmt.Fprintf(bw, "facet normal %s\n outer loop\n", vec(n))
+		for _, v := range []mgl32.Vec3{a, b, c} {
+			fmt.Fprintf(bw, "  vertex %s\n", vec(v))
+		}
+		fmt.Fprintf(bw, " endloop\nendfacet\n")
+	}
+	if ASCII == format {
+		fmt.Fprintf(bw, "endsolid %s\n", name)
+	}
+	return bw.Flush()
+}
+
+// EncodeFile writes m to file.
+func EncodeFile(file string, m *mesh.Mesh, format Format) error {
+	f, err := os.Create(file)
+	if nil != err {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if err := Encode(f, m, format, name); nil != err {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func vec(v mgl32.Vec3) string {
+	return fmt.Sprintf("%s %s %s", num(v[0]), num(v[1]), num(v[2]))
+}
+
+func num(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
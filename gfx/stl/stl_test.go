@@ -0,0 +1,104 @@
+package stl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+)
+
+const tetrahedron = `solid tetra
+facet normal 0 0 -1
+ outer loop
+  vertex 0 0 0
+  vertex 0 1 0
+  vertex 1 0 0
+ endloop
+endfacet
+facet normal 0 0 0
+ outer loop
+  vertex 0 0 0
+  vertex 1 0 0
+  vertex 0 0 1
+ endloop
+endfacet
+facet normal 0 0 0
+ outer loop
+  vertex 0 0 0
+  vertex 0 0 1
+  vertex 0 1 0
+ endloop
+endfacet
+facet normal 1 1 1
+ outer loop
+  vertex 1 0 0
+  vertex 0 1 0
+  vertex 0 0 1
+ endloop
+endfacet
+endsolid tetra
+`
+
+func TestDecodeASCII(t *testing.T) {
+	m, err := Decode(strings.NewReader(tetrahedron))
+	if nil != err {
+		t.Fatal(err)
+	}
+	// four flat faces, three corners each
+	if m.TriangleCount() != 4 || m.VertexCount() != 12 {
+		t.Fatalf("%d triangles %d vertices", m.TriangleCount(), m.VertexCount())
+	}
+	// the zero normal of the second facet is computed from its winding
+	if n := m.Normals[m.Indices[3]]; n[1] != -1 {
+		t.Errorf("normal %v, want 0 -1 0", n)
+	}
+	if _, err := Decode(strings.NewReader("solid x\nfacet normal 0 0\n")); nil == err {
+		t.Error("short normal accepted")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	cube := mesh.Cube(2, 2)
+	for _, format := range []Format{Binary, ASCII} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, cube, format, "cube"); nil != err {
+			t.Fatal(err)
+		}
+		m, err := Decode(&buf)
+		if nil != err {
+			t.Fatal(format, err)
+		}
+		if m.TriangleCount() != cube.TriangleCount() {
+			t.Fatalf("format %d: %d triangles", format, m.TriangleCount())
+		}
+		for c, index := range m.Indices {
+			if m.Positions[index] != cube.Positions[cube.Indices[c]] {
+				t.Fatalf("format %d: corner %d moved", format, c)
+			}
+		}
+		// corners are shared within each flat side: 9 per side
+		if m.VertexCount() != 6*9 {
+			t.Errorf("format %d: %d vertices, want 54", format, m.VertexCount())
+		}
+	}
+}
+
+func TestBinarySolidHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, mesh.Cube(1, 1), Binary, ""); nil != err {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	copy(data, "solid cube")
+	m, err := Decode(bytes.NewReader(data))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 12 != m.TriangleCount() {
+		t.Errorf("%d triangles", m.TriangleCount())
+	}
+	if _, err := Decode(bytes.NewReader(data[:len(data)-10])); nil == err {
+		t.Error("truncated binary file accepted")
+	}
+}
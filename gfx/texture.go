@@ -0,0 +1,201 @@
+package gfx
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // register decoders for the lesson textures
+	_ "image/png"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// EXT_texture_filter_anisotropic, core only since 4.6.
+const (
+	textureMaxAnisotropy    = 0x84FE
+	maxTextureMaxAnisotropy = 0x84FF
+)
+
+// TextureOptions controls how a texture is sampled and uploaded. The zero
+// value gives LINEAR filtering and CLAMP_TO_EDGE wrapping, what NewTexture has
+// always done.
+type TextureOptions struct {
+	MinFilter int32 // gl.LINEAR, gl.NEAREST, gl.LINEAR_MIPMAP_LINEAR, ...
+	MagFilter int32 // gl.LINEAR or gl.NEAREST
+	WrapS     int32 // gl.CLAMP_TO_EDGE, gl.REPEAT, gl.MIRRORED_REPEAT, ...
+	WrapT     int32
+
+	// GenerateMipmaps builds the mip chain after upload; MinFilter then
+	// defaults to LINEAR_MIPMAP_LINEAR.
+	GenerateMipmaps bool
+	// Anisotropy above 1 enables anisotropic filtering, clamped to what the
+	// driver supports and ignored where it is not supported at all.
+	Anisotropy float32
+
+	// FlipV flips the image so its first row ends up at t=0, which is what
+	// OpenGL expects and what the lessons did with imaging.FlipV.
+	FlipV bool
+	// SRGB stores the texels as SRGB8_ALPHA8 so sampling returns linear
+	// colors. Use it for color maps, not for normal or data maps.
+	SRGB bool
+}
+
+// withDefaults fills in the fields left zero.
+func (o TextureOptions) withDefaults() TextureOptions {
+	if 0 == o.MinFilter {
+		o.MinFilter = gl.LINEAR
+		if o.GenerateMipmaps {
+			o.MinFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	if 0 == o.MagFilter {
+		o.MagFilter = gl.LINEAR
+	}
+	if 0 == o.WrapS {
+		o.WrapS = gl.CLAMP_TO_EDGE
+	}
+	if 0 == o.WrapT {
+		o.WrapT = gl.CLAMP_TO_EDGE
+	}
+	return o
+}
+
+func (o TextureOptions) internalFormat() int32 {
+	if o.SRGB {
+		return gl.SRGB8_ALPHA8
+	}
+	return gl.RGBA8
+}
+
+// apply sets the sampling parameters on the texture bound to target and
+// builds mipmaps if asked to. Call it after the image data is uploaded.
+func (o TextureOptions) apply(target uint32) {
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, o.MinFilter)
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, o.MagFilter)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, o.WrapS)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, o.WrapT)
+	if gl.TEXTURE_CUBE_MAP == target || gl.TEXTURE_3D == target {
+		gl.TexParameteri(target, gl.TEXTURE_WRAP_R, o.WrapT)
+	}
+	if o.Anisotropy > 1 {
+		var max float32
+		gl.GetFloatv(maxTextureMaxAnisotropy, &max)
+		if max >= 1 {
+			if o.Anisotropy < max {
+				max = o.Anisotropy
+			}
+			gl.TexParameterf(target, textureMaxAnisotropy, max)
+		} else {
+			// GL_INVALID_ENUM, the extension is missing
+			gl.GetError()
+		}
+	}
+	if o.GenerateMipmaps {
+		gl.GenerateMipmap(target)
+	}
+}
+
+// NewTexture loads an image file into a new TEXTURE_2D with linear filtering
+// and CLAMP_TO_EDGE wrapping. The texture is left bound to TEXTURE0.
+func NewTexture(file string) (uint32, error) {
+	return NewTextureWithOptions(file, TextureOptions{})
+}
+
+// NewTextureWithOptions loads an image file into a new TEXTURE_2D configured
+// by opts. The texture is left bound to TEXTURE0.
+//
+// KTX, KTX2 and DDS files are handed to NewGPUTexture and have to hold a
+// plain 2D texture; Radiance .hdr and OpenEXR .exr files go through
+// NewHDRTexture.
+func NewTextureWithOptions(file string, opts TextureOptions) (uint32, error) {
+	if isHDRFile(file) {
+		return NewHDRTexture(file, opts)
+	}
+	if isGPUTextureFile(file) {
+		texture, target, err := NewGPUTexture(file, opts)
+		if nil == err && gl.TEXTURE_2D != target {
+			gl.DeleteTextures(1, &texture)
+			return 0, fmt.Errorf("texture %q is not a 2D texture, load it with NewGPUTexture", file)
+		}
+		return texture, err
+	}
+
+	img, err := loadImage(file)
+	if nil != err {
+		return 0, err
+	}
+	return NewTextureFromImage(img, opts), nil
+}
+
+// NewTextureFromImage uploads an already decoded image. Gray, gray+alpha
+// palettes, RGBA, NRGBA and their 16 bit variants are uploaded as they are
+// laid out in memory, sub-images included; other image types are converted
+// to RGBA first. The texture is left bound to TEXTURE0.
+func NewTextureFromImage(img image.Image, opts TextureOptions) uint32 {
+	opts = opts.withDefaults()
+	px := newPixelData(img, opts.SRGB)
+	if opts.FlipV {
+		px = px.flipped()
+	}
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(px.rowLength()))
+	if px.bigEndian {
+		gl.PixelStorei(gl.UNPACK_SWAP_BYTES, gl.TRUE)
+	}
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		px.internalFormat(opts.SRGB),
+		int32(px.width),
+		int32(px.height),
+		0,
+		px.format,
+		px.xtype,
+		gl.Ptr(px.pix),
+	)
+	gl.PixelStorei(gl.UNPACK_SWAP_BYTES, gl.FALSE)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if nil != px.swizzle {
+		gl.TexParameteriv(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_RGBA, &px.swizzle[0])
+	}
+	opts.apply(gl.TEXTURE_2D)
+
+	return texture
+}
+
+// loadImage decodes file with whichever registered decoder recognizes it.
+func loadImage(file string) (image.Image, error) {
+	imgFile, err := os.Open(file)
+	if nil != err {
+		return nil, fmt.Errorf("texture %q not found on disk: %v", file, err)
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if nil != err {
+		return nil, fmt.Errorf("texture %q decode error: %v", file, err)
+	}
+	return img, nil
+}
+
+// loadRGBA decodes file into a tightly packed RGBA image, for code that
+// needs to work on the pixels such as the cubemap layouts.
+func loadRGBA(file string) (*image.RGBA, error) {
+	img, err := loadImage(file)
+	if nil != err {
+		return nil, err
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba, nil
+}
@@ -0,0 +1,73 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestLoadRGBA(t *testing.T) {
+	// a paletted sub-image exercises both the conversion and the origin shift
+	src := image.NewPaletted(image.Rect(0, 0, 4, 3), color.Palette{color.Black, color.White})
+	src.SetColorIndex(1, 2, 1)
+	file := filepath.Join(t.TempDir(), "p.png")
+	f, err := os.Create(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, src); nil != err {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	rgba, err := loadRGBA(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if rgba.Stride != 4*4 || rgba.Rect != image.Rect(0, 0, 4, 3) {
+		t.Fatalf("unexpected layout stride=%d rect=%v", rgba.Stride, rgba.Rect)
+	}
+	if got := rgba.RGBAAt(1, 2); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel (1,2) = %v, want white", got)
+	}
+	if got := rgba.RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("pixel (0,0) = %v, want black", got)
+	}
+}
+
+func TestLoadRGBAMissing(t *testing.T) {
+	if _, err := loadRGBA(filepath.Join(t.TempDir(), "nope.png")); nil == err {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestTextureOptionsDefaults(t *testing.T) {
+	o := TextureOptions{}.withDefaults()
+	if o.MinFilter != gl.LINEAR || o.MagFilter != gl.LINEAR || o.WrapS != gl.CLAMP_TO_EDGE || o.WrapT != gl.CLAMP_TO_EDGE {
+		t.Errorf("zero options resolved to %+v", o)
+	}
+	if o.internalFormat() != gl.RGBA8 {
+		t.Errorf("internal format 0x%x, want RGBA8", o.internalFormat())
+	}
+
+	o = TextureOptions{GenerateMipmaps: true, WrapS: gl.REPEAT, SRGB: true}.withDefaults()
+	if o.MinFilter != gl.LINEAR_MIPMAP_LINEAR {
+		t.Errorf("mipmapped MinFilter = 0x%x, want LINEAR_MIPMAP_LINEAR", o.MinFilter)
+	}
+	if o.WrapS != gl.REPEAT || o.WrapT != gl.CLAMP_TO_EDGE {
+		t.Errorf("explicit wrap lost: %+v", o)
+	}
+	if o.internalFormat() != gl.SRGB8_ALPHA8 {
+		t.Errorf("internal format 0x%x, want SRGB8_ALPHA8", o.internalFormat())
+	}
+
+	o = TextureOptions{GenerateMipmaps: true, MinFilter: gl.NEAREST}.withDefaults()
+	if o.MinFilter != gl.NEAREST {
+		t.Errorf("explicit MinFilter overridden: 0x%x", o.MinFilter)
+	}
+}
@@ -0,0 +1,202 @@
+// Command meshconv converts OBJ, glTF, STL and PLY models into the binary mesh cache
+// of gfx/meshcache, which gfx.LoadMeshCache uploads without parsing.
+//
+//	meshconv [flags] model.obj model.mesh
+//	meshconv -info model.mesh
+//
+// A glTF scene is flattened into one mesh, each primitive moved by its
+// node, keeping the attributes all of them have.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexniver/opengl-dev-go/gfx/gltf"
+	"github.com/alexniver/opengl-dev-go/gfx/mesh"
+	"github.com/alexniver/opengl-dev-go/gfx/meshcache"
+	"github.com/alexniver/opengl-dev-go/gfx/obj"
+	"github.com/alexniver/opengl-dev-go/gfx/ply"
+	"github.com/alexniver/opengl-dev-go/gfx/stl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	optimize = flag.Bool("optimize", true, "reorder triangles and vertices for the GPU caches")
+	lods     = flag.Int("lods", 1, "levels of detail to store, 1 for none")
+	ratio    = flag.Float64("ratio", 0.5, "fraction of the triangles each level keeps of the one before")
+	tangents = flag.Bool("tangents", false, "generate tangents for normal mapping where missing")
+	info     = flag.Bool("info", false, "describe a mesh cache instead of writing one")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("meshconv: ")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: meshconv [flags] model.{obj,gltf,glb,stl,ply} out.mesh")
+		fmt.Fprintln(os.Stderr, "       meshconv -info file.mesh")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *info {
+		if 1 != flag.NArg() {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := describe(flag.Arg(0)); nil != err {
+			log.Fatal(err)
+		}
+		return
+	}
+	if 2 != flag.NArg() {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := convert(flag.Arg(0), flag.Arg(1)); nil != err {
+		log.Fatal(err)
+	}
+}
+
+func convert(in, out string) error {
+	m, err := load(in)
+	if nil != err {
+		return err
+	}
+	if err := m.Validate(); nil != err {
+		return err
+	}
+	if *tangents && 0 == len(m.Tangents) && 0 != len(m.UVs) {
+		if 0 == len(m.Normals) {
+			m.SmoothNormals()
+		}
+		if err := m.ComputeTangents(); nil != err {
+			return err
+		}
+	}
+	if *optimize {
+		before := m.Stats()
+		m.Optimize()
+		log.Printf("ACMR %.3f -> %.3f", before.ACMR, m.Stats().ACMR)
+	}
+	levels := m.GenerateLODs(*lods, float32(*ratio))
+	if err := meshcache.WriteFile(out, m, levels); nil != err {
+		return err
+	}
+	return describe(out)
+}
+
+// load reads a model into one mesh.
+func load(file string) (*mesh.Mesh, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".obj":
+		model, err := obj.DecodeFile(file)
+		if nil != err {
+			return nil, err
+		}
+		return model.Mesh, nil
+	case ".gltf", ".glb":
+		model, err := gltf.DecodeFile(file)
+		if nil != err {
+			return nil, err
+		}
+		return flatten(model)
+	case ".stl":
+		return stl.DecodeFile(file)
+	case ".ply":
+		return ply.DecodeFile(file)
+	}
+	return nil, fmt.Errorf("%s: not an .obj, .gltf, .glb, .stl or .ply file", file)
+}
+
+// flatten merges the primitives of the default scene, or of every mesh
+// where there is no scene, in world space.
+func flatten(model *gltf.Model) (*mesh.Mesh, error) {
+	type part struct {
+		m     *mesh.Mesh
+		world mgl32.Mat4
+	}
+	var parts []part
+	if nil != model.Scene {
+		model.Scene.Walk(func(n *gltf.Node, world mgl32.Mat4) {
+			if nil == n.Mesh {
+				return
+			}
+			for _, p := range n.Mesh.Primitives {
+				parts = append(parts, part{p.Mesh, world})
+			}
+		})
+	} else {
+		for _, m := range model.Meshes {
+			for _, p := range m.Primitives {
+				parts = append(parts, part{p.Mesh, mgl32.Ident4()})
+			}
+		}
+	}
+	if 0 == len(parts) {
+		return nil, errors.New("no meshes")
+	}
+
+	// attributes only some primitives have are dropped
+	common := map[string]int{}
+	for _, p := range parts {
+		for _, a := range p.m.Attributes() {
+			common[a.Name]++
+		}
+	}
+	out := &mesh.Mesh{}
+	for _, p := range parts {
+		// a copy, meshes can be used by more than one node
+		m := &mesh.Mesh{}
+		m.Append(p.m)
+		for name, count := range common {
+			if count == len(parts) {
+				continue
+			}
+			switch name {
+			case "normal":
+				m.Normals = nil
+			case "uv":
+				m.UVs = nil
+			case "tangent":
+				m.Tangents = nil
+			case "color":
+				m.Colors = nil
+			}
+		}
+		m.Transform(p.world)
+		if err := out.Append(m); nil != err {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func describe(file string) error {
+	f, err := meshcache.Open(file)
+	if nil != err {
+		return err
+	}
+	defer f.Close()
+	stat, err := os.Stat(file)
+	if nil != err {
+		return err
+	}
+
+	var attrs []string
+	for _, a := range f.Attributes {
+		attrs = append(attrs, fmt.Sprintf("%s%d", a.Name, a.Size))
+	}
+	fmt.Printf("%s: %d bytes, %d vertices of %d bytes (%s)\n",
+		file, stat.Size(), f.VertexCount, f.Stride(), strings.Join(attrs, " "))
+	fmt.Printf("  bounds %v to %v\n", f.Bounds.Min, f.Bounds.Max)
+	for i, l := range f.Levels {
+		fmt.Printf("  level %d: %d triangles, error %g\n", i, l.Count/3, l.Error)
+	}
+	return nil
+}